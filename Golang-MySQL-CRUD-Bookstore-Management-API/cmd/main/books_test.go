@@ -0,0 +1,213 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/yoloxsta/go-bookstore/pkg/middleware"
+	"github.com/yoloxsta/go-bookstore/pkg/models"
+	"github.com/yoloxsta/go-bookstore/pkg/utils"
+)
+
+// newTestAPI is newTestRouter behind the request ID middleware, as main
+// serves it.
+func newTestAPI(t *testing.T) http.Handler {
+	t.Helper()
+	router, _ := newTestRouter(t)
+	return middleware.RequestID(router)
+}
+
+// serve runs one request through h. headers are name, value pairs.
+func serve(t *testing.T, h http.Handler, method, path, body string, headers ...string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func decode(t *testing.T, rec *httptest.ResponseRecorder, v interface{}) {
+	t.Helper()
+	if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+		t.Fatalf("decoding %q: %v", rec.Body.String(), err)
+	}
+}
+
+// wantError checks that rec is an error envelope with status and code whose
+// request_id matches the X-Request-ID header, and returns its body.
+func wantError(t *testing.T, rec *httptest.ResponseRecorder, status int, code string) utils.ErrorBody {
+	t.Helper()
+	if rec.Code != status {
+		t.Fatalf("status = %d, want %d; body %s", rec.Code, status, rec.Body)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", ct)
+	}
+	var res utils.ErrorResponse
+	decode(t, rec, &res)
+	if res.Error.Code != code {
+		t.Errorf("code = %q, want %q", res.Error.Code, code)
+	}
+	if res.Error.Message == "" {
+		t.Error("message is empty")
+	}
+	if id := rec.Header().Get(middleware.RequestIDHeader); res.Error.RequestID == "" || res.Error.RequestID != id {
+		t.Errorf("request_id = %q, want the X-Request-ID %q", res.Error.RequestID, id)
+	}
+	return res.Error
+}
+
+const validBook = `{"name":"The Go Programming Language","author":"Donovan","publication":"Addison-Wesley","price_cents":3999}`
+
+func TestBookCRUD(t *testing.T) {
+	api := newTestAPI(t)
+
+	rec := serve(t, api, http.MethodPost, "/book/", validBook)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create: status = %d; body %s", rec.Code, rec.Body)
+	}
+	var created models.Book
+	decode(t, rec, &created)
+	if created.ID == 0 || created.Version != 1 || created.Name != "The Go Programming Language" {
+		t.Fatalf("create returned %+v", created)
+	}
+	etag := rec.Header().Get("ETag")
+	if etag == "" {
+		t.Error("create sent no ETag")
+	}
+
+	rec = serve(t, api, http.MethodGet, "/book/1", "")
+	if rec.Code != http.StatusOK || rec.Header().Get("ETag") != etag {
+		t.Fatalf("get: status = %d, ETag = %q; want 200 %q", rec.Code, rec.Header().Get("ETag"), etag)
+	}
+	if rec = serve(t, api, http.MethodGet, "/book/1", "", "If-None-Match", etag); rec.Code != http.StatusNotModified {
+		t.Errorf("get with If-None-Match: status = %d, want 304", rec.Code)
+	}
+
+	rec = serve(t, api, http.MethodGet, "/book/", "")
+	var books []models.Book
+	decode(t, rec, &books)
+	if rec.Code != http.StatusOK || len(books) != 1 || rec.Header().Get("X-Total-Count") != "1" {
+		t.Fatalf("list: status = %d, %d books, X-Total-Count %q", rec.Code, len(books), rec.Header().Get("X-Total-Count"))
+	}
+
+	rec = serve(t, api, http.MethodPut, "/book/1",
+		`{"name":"The Go Programming Language","author":"Donovan & Kernighan","publication":"Addison-Wesley"}`, "If-Match", etag)
+	var updated models.Book
+	decode(t, rec, &updated)
+	if rec.Code != http.StatusOK || updated.Version != 2 || updated.Author != "Donovan & Kernighan" {
+		t.Fatalf("update: status = %d, book %+v", rec.Code, updated)
+	}
+	wantError(t, serve(t, api, http.MethodPut, "/book/1", validBook, "If-Match", etag),
+		http.StatusPreconditionFailed, utils.CodePreconditionFailed)
+
+	if rec = serve(t, api, http.MethodDelete, "/book/1", ""); rec.Code != http.StatusOK {
+		t.Fatalf("delete: status = %d; body %s", rec.Code, rec.Body)
+	}
+	wantError(t, serve(t, api, http.MethodGet, "/book/1", ""), http.StatusNotFound, utils.CodeNotFound)
+}
+
+func TestBookErrors(t *testing.T) {
+	api := newTestAPI(t)
+	if rec := serve(t, api, http.MethodPost, "/book/", validBook); rec.Code != http.StatusCreated {
+		t.Fatalf("create: status = %d", rec.Code)
+	}
+
+	for _, tc := range []struct {
+		name         string
+		method, path string
+		body         string
+		status       int
+		code         string
+		fields       []string
+	}{
+		{"non-numeric id", http.MethodGet, "/book/abc", "", http.StatusBadRequest, utils.CodeBadRequest, nil},
+		{"bad limit", http.MethodGet, "/book/?limit=-1", "", http.StatusBadRequest, utils.CodeBadRequest, nil},
+		{"bad sort", http.MethodGet, "/book/?sort=colour", "", http.StatusBadRequest, utils.CodeBadRequest, nil},
+		{"malformed json", http.MethodPost, "/book/", `{"name":`, http.StatusBadRequest, utils.CodeBadRequest, nil},
+		{"missing book", http.MethodGet, "/book/99", "", http.StatusNotFound, utils.CodeNotFound, nil},
+		{"update missing book", http.MethodPut, "/book/99", validBook, http.StatusNotFound, utils.CodeNotFound, nil},
+		{"delete missing book", http.MethodDelete, "/book/99", "", http.StatusNotFound, utils.CodeNotFound, nil},
+		{"unknown route", http.MethodGet, "/nope", "", http.StatusNotFound, utils.CodeNotFound, nil},
+		{"wrong method", http.MethodPost, "/book/1", validBook, http.StatusMethodNotAllowed, utils.CodeMethod, nil},
+		{"missing fields", http.MethodPost, "/book/", `{"price_cents":-1}`, http.StatusUnprocessableEntity, utils.CodeValidation,
+			[]string{"name", "author", "price_cents"}},
+		{"invalid update", http.MethodPut, "/book/1", `{"name":"","author":"y"}`, http.StatusUnprocessableEntity, utils.CodeValidation,
+			[]string{"name"}},
+		{"unknown field", http.MethodPost, "/book/", `{"name":"x","author":"y","colour":"red"}`, http.StatusUnprocessableEntity, utils.CodeValidation,
+			[]string{"colour"}},
+		{"bad isbn", http.MethodPost, "/book/", `{"name":"x","author":"y","isbn13":"9780306406158"}`, http.StatusUnprocessableEntity, utils.CodeValidation,
+			[]string{"isbn13"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			body := wantError(t, serve(t, api, tc.method, tc.path, tc.body), tc.status, tc.code)
+			got := map[string]bool{}
+			for _, f := range body.Fields {
+				if f.Message == "" {
+					t.Errorf("field %q has no message", f.Field)
+				}
+				got[f.Field] = true
+			}
+			for _, f := range tc.fields {
+				if !got[f] {
+					t.Errorf("fields = %+v, want one for %q", body.Fields, f)
+				}
+			}
+			if tc.fields == nil && len(body.Fields) > 0 {
+				t.Errorf("unexpected fields %+v", body.Fields)
+			}
+		})
+	}
+}
+
+func TestBookETagFollowsIncludes(t *testing.T) {
+	api := newTestAPI(t)
+	if rec := serve(t, api, http.MethodPost, "/author/", `{"name":"Donovan"}`); rec.Code != http.StatusCreated {
+		t.Fatalf("create author: status = %d; body %s", rec.Code, rec.Body)
+	}
+	if rec := serve(t, api, http.MethodPost, "/book/", `{"name":"Go","author_ids":[1]}`); rec.Code != http.StatusCreated {
+		t.Fatalf("create book: status = %d; body %s", rec.Code, rec.Body)
+	}
+
+	plain := serve(t, api, http.MethodGet, "/book/1", "").Header().Get("ETag")
+	before := serve(t, api, http.MethodGet, "/book/1?include=authors", "").Header().Get("ETag")
+	if before == plain {
+		t.Fatalf("ETag with include = %s, the same as without", before)
+	}
+	if rec := serve(t, api, http.MethodGet, "/book/1?include=authors", "", "If-None-Match", before); rec.Code != http.StatusNotModified {
+		t.Errorf("unchanged include: status = %d, want 304", rec.Code)
+	}
+
+	if rec := serve(t, api, http.MethodPut, "/author/1", `{"name":"Alan Donovan"}`); rec.Code != http.StatusOK {
+		t.Fatalf("rename author: status = %d; body %s", rec.Code, rec.Body)
+	}
+	rec := serve(t, api, http.MethodGet, "/book/1?include=authors", "", "If-None-Match", before)
+	var b models.Book
+	decode(t, rec, &b)
+	if rec.Code != http.StatusOK || len(b.Authors) != 1 || b.Authors[0].Name != "Alan Donovan" {
+		t.Fatalf("after renaming the author: status = %d, authors %+v", rec.Code, b.Authors)
+	}
+	after := rec.Header().Get("ETag")
+	if after == before {
+		t.Error("ETag did not change with the included author")
+	}
+	if got := serve(t, api, http.MethodGet, "/book/1", "").Header().Get("ETag"); got != plain {
+		t.Errorf("ETag without include = %s, want %s unchanged", got, plain)
+	}
+
+	// The tag still guards writes to the book itself.
+	if rec := serve(t, api, http.MethodPut, "/book/1", `{"name":"Go","author_ids":[1]}`, "If-Match", after); rec.Code != http.StatusOK {
+		t.Fatalf("update with the included tag: status = %d; body %s", rec.Code, rec.Body)
+	}
+	wantError(t, serve(t, api, http.MethodPut, "/book/1", `{"name":"Go","author_ids":[1]}`, "If-Match", after),
+		http.StatusPreconditionFailed, utils.CodePreconditionFailed)
+}
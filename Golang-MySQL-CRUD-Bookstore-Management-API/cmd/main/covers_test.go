@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// uploadCover puts a small PNG as the cover of book 1.
+func uploadCover(t *testing.T, api http.Handler) *httptest.ResponseRecorder {
+	t.Helper()
+	var img bytes.Buffer
+	if err := png.Encode(&img, image.NewGray(image.Rect(0, 0, 200, 300))); err != nil {
+		t.Fatal(err)
+	}
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	part, err := mw.CreateFormFile("cover", "front.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write(img.Bytes())
+	mw.Close()
+	req := httptest.NewRequest(http.MethodPut, "/book/1/cover", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	rec := httptest.NewRecorder()
+	api.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestCoversStayOutOfSharedCaches(t *testing.T) {
+	api := newTestAPI(t)
+	if rec := serve(t, api, http.MethodPost, "/book/", validBook); rec.Code != http.StatusCreated {
+		t.Fatalf("create book: status = %d", rec.Code)
+	}
+	rec := uploadCover(t, api)
+	var c struct {
+		URL        string            `json:"url"`
+		Thumbnails map[string]string `json:"thumbnails"`
+	}
+	decode(t, rec, &c)
+	if rec.Code != http.StatusOK || c.Thumbnails["small"] == "" {
+		t.Fatalf("upload: status = %d; body %s", rec.Code, rec.Body)
+	}
+
+	for _, url := range []string{c.URL, c.Thumbnails["small"]} {
+		rec := serve(t, api, http.MethodGet, url, "")
+		if got := rec.Header().Get("Cache-Control"); rec.Code != http.StatusOK || got != "private, max-age=31536000, immutable" {
+			t.Errorf("GET %s: status = %d, Cache-Control %q", url, rec.Code, got)
+		}
+	}
+	if got := serve(t, api, http.MethodGet, "/book/1/cover", "").Header().Get("Cache-Control"); got != "no-cache" {
+		t.Errorf("GET without v: Cache-Control %q, want no-cache", got)
+	}
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/yoloxsta/go-bookstore/pkg/auth"
+	"github.com/yoloxsta/go-bookstore/pkg/config"
+	"github.com/yoloxsta/go-bookstore/pkg/middleware"
+	"github.com/yoloxsta/go-bookstore/pkg/models"
+	"github.com/yoloxsta/go-bookstore/pkg/payment"
+	"github.com/yoloxsta/go-bookstore/pkg/storage"
+	"github.com/yoloxsta/go-bookstore/pkg/utils"
+)
+
+// newSignedAPI serves store behind HS256 authentication. bearer returns the
+// Authorization header, as a name, value pair, of a token for sub and role.
+func newSignedAPI(t *testing.T, store *models.Store) (api http.Handler, bearer func(sub, role string) []string) {
+	t.Helper()
+	cfg := config.Default()
+	cfg.Auth.Algorithm = "HS256"
+	cfg.Auth.Secret = "0123456789abcdef0123456789abcdef"
+	verifier, err := auth.NewVerifier(cfg.Auth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	api = middleware.RequestID(newRouter(cfg, store, payment.NewFake(), storage.NewLocal(t.TempDir()), verifier))
+	bearer = func(sub, role string) []string {
+		token, err := verifier.Sign(auth.Claims{Subject: sub, Role: role, ExpiresAt: time.Now().Add(time.Hour).Unix()})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return []string{"Authorization", "Bearer " + token}
+	}
+	return api, bearer
+}
+
+// historyEntry is the part of a book history entry the tests check;
+// models.BookAudit only marshals its snapshots.
+type historyEntry struct {
+	Revision  uint   `json:"revision"`
+	Action    string `json:"action"`
+	Actor     string `json:"actor"`
+	RequestID string `json:"request_id"`
+	Note      string `json:"note"`
+}
+
+func TestRevertIsAuditedAsTheCaller(t *testing.T) {
+	api, bearer := newSignedAPI(t, models.NewMemoryStore(config.Default().Inventory))
+	ed, eve := bearer("ed", "editor"), bearer("eve", "editor")
+
+	if rec := serve(t, api, http.MethodPost, "/book/", validBook, ed...); rec.Code != http.StatusCreated {
+		t.Fatalf("create: status = %d; body %s", rec.Code, rec.Body)
+	}
+	if rec := serve(t, api, http.MethodPut, "/book/1",
+		`{"name":"The Go Programming Language","author":"Kernighan","publication":"Addison-Wesley"}`, eve...); rec.Code != http.StatusOK {
+		t.Fatalf("update: status = %d; body %s", rec.Code, rec.Body)
+	}
+
+	rec := serve(t, api, http.MethodPost, "/book/1/revert", `{"revision":1}`, ed...)
+	var reverted models.Book
+	decode(t, rec, &reverted)
+	if rec.Code != http.StatusOK || reverted.Version != 3 || reverted.Author != "Donovan" {
+		t.Fatalf("revert: status = %d, book %+v", rec.Code, reverted)
+	}
+	if rec.Header().Get("ETag") == "" {
+		t.Error("revert sent no ETag")
+	}
+
+	var history []historyEntry
+	decode(t, serve(t, api, http.MethodGet, "/book/1/history", "", ed...), &history)
+	if len(history) != 3 {
+		t.Fatalf("history has %d entries, want 3: %+v", len(history), history)
+	}
+	want := []struct {
+		revision uint
+		action   string
+		actor    string
+		note     string
+	}{
+		{3, models.AuditUpdate, "ed", "revert to revision 1"},
+		{2, models.AuditUpdate, "eve", ""},
+		{1, models.AuditCreate, "ed", ""},
+	}
+	for i, w := range want {
+		e := history[i]
+		if e.Revision != w.revision || e.Action != w.action || e.Actor != w.actor || e.Note != w.note {
+			t.Errorf("entry %d = revision %d %s by %q note %q; want revision %d %s by %q note %q",
+				i, e.Revision, e.Action, e.Actor, e.Note, w.revision, w.action, w.actor, w.note)
+		}
+	}
+	if id := rec.Header().Get(middleware.RequestIDHeader); history[0].RequestID != id {
+		t.Errorf("revert entry request_id = %q, want the X-Request-ID %q", history[0].RequestID, id)
+	}
+
+	// The revert is a revision of its own, so it can be reverted to as well.
+	rec = serve(t, api, http.MethodPost, "/book/1/revert", `{"revision":2}`, ed...)
+	decode(t, rec, &reverted)
+	if rec.Code != http.StatusOK || reverted.Version != 4 || reverted.Author != "Kernighan" {
+		t.Fatalf("second revert: status = %d, book %+v", rec.Code, reverted)
+	}
+	rec = serve(t, api, http.MethodPost, "/book/1/revert", `{"revision":3}`, ed...)
+	decode(t, rec, &reverted)
+	if rec.Code != http.StatusOK || reverted.Version != 5 || reverted.Author != "Donovan" {
+		t.Fatalf("revert to a revert: status = %d, book %+v", rec.Code, reverted)
+	}
+}
+
+// conflictingBooks loses every update to another request.
+type conflictingBooks struct {
+	models.BookRepository
+}
+
+func (conflictingBooks) Update(*models.Book) error { return models.ErrConflict }
+
+func (r conflictingBooks) WithAudit(info models.AuditInfo) models.BookRepository {
+	return conflictingBooks{r.BookRepository.WithAudit(info)}
+}
+
+func TestRevertErrors(t *testing.T) {
+	store := models.NewMemoryStore(config.Default().Inventory)
+	apiFor := func(store *models.Store) http.Handler {
+		return middleware.RequestID(newRouter(config.Default(), store, payment.NewFake(), storage.NewLocal(t.TempDir()), nil))
+	}
+	api := apiFor(store)
+	if rec := serve(t, api, http.MethodPost, "/book/", validBook); rec.Code != http.StatusCreated {
+		t.Fatalf("create: status = %d; body %s", rec.Code, rec.Body)
+	}
+	rec := serve(t, api, http.MethodPut, "/book/1", `{"name":"Go","author":"Donovan","publication":"Addison-Wesley"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("update: status = %d; body %s", rec.Code, rec.Body)
+	}
+	etag := rec.Header().Get("ETag")
+
+	wantError(t, serve(t, api, http.MethodPost, "/book/1/revert", `{}`), http.StatusUnprocessableEntity, utils.CodeValidation)
+	if e := wantError(t, serve(t, api, http.MethodPost, "/book/1/revert", `{"revision":9}`),
+		http.StatusNotFound, utils.CodeNotFound); e.Message != "revision not found" {
+		t.Errorf("unknown revision: message = %q", e.Message)
+	}
+	wantError(t, serve(t, api, http.MethodPost, "/book/1/revert", `{"revision":1}`, "If-Match", `"stale"`),
+		http.StatusPreconditionFailed, utils.CodePreconditionFailed)
+
+	// Another request changing the book between the read and the write.
+	racing := *store
+	racing.Books = conflictingBooks{store.Books}
+	wantError(t, serve(t, apiFor(&racing), http.MethodPost, "/book/1/revert", `{"revision":1}`), http.StatusConflict, utils.CodeConflict)
+	wantError(t, serve(t, apiFor(&racing), http.MethodPost, "/book/1/revert", `{"revision":1}`, "If-Match", etag),
+		http.StatusPreconditionFailed, utils.CodePreconditionFailed)
+
+	// Another book has taken the ISBN the old revision carried.
+	if rec := serve(t, api, http.MethodPut, "/book/1",
+		`{"name":"Go","author":"Donovan","publication":"Addison-Wesley","isbn13":"9780306406157"}`); rec.Code != http.StatusOK {
+		t.Fatalf("set ISBN: status = %d; body %s", rec.Code, rec.Body)
+	}
+	if rec := serve(t, api, http.MethodPut, "/book/1", `{"name":"Go","author":"Donovan","publication":"Addison-Wesley"}`); rec.Code != http.StatusOK {
+		t.Fatalf("clear ISBN: status = %d; body %s", rec.Code, rec.Body)
+	}
+	if rec := serve(t, api, http.MethodPost, "/book/",
+		`{"name":"Other","author":"Someone","publication":"Press","isbn13":"9780306406157"}`); rec.Code != http.StatusCreated {
+		t.Fatalf("create other: status = %d; body %s", rec.Code, rec.Body)
+	}
+	wantError(t, serve(t, api, http.MethodPost, "/book/1/revert", `{"revision":3}`), http.StatusUnprocessableEntity, utils.CodeValidation)
+
+	// A trashed book has to be restored first, and a purged one is gone,
+	// though its history stays readable.
+	if rec := serve(t, api, http.MethodDelete, "/book/1", ""); rec.Code != http.StatusOK {
+		t.Fatalf("delete: status = %d; body %s", rec.Code, rec.Body)
+	}
+	wantError(t, serve(t, api, http.MethodPost, "/book/1/revert", `{"revision":1}`), http.StatusNotFound, utils.CodeNotFound)
+	if rec := serve(t, api, http.MethodDelete, "/book/1?purge=true", ""); rec.Code != http.StatusOK {
+		t.Fatalf("purge: status = %d; body %s", rec.Code, rec.Body)
+	}
+	if e := wantError(t, serve(t, api, http.MethodPost, "/book/1/revert", `{"revision":1}`),
+		http.StatusNotFound, utils.CodeNotFound); e.Message != "book not found" {
+		t.Errorf("purged book: message = %q", e.Message)
+	}
+	var history []historyEntry
+	rec = serve(t, api, http.MethodGet, "/book/1/history", "")
+	decode(t, rec, &history)
+	if rec.Code != http.StatusOK || len(history) == 0 || history[0].Action != models.AuditPurge {
+		t.Fatalf("history after purge: status = %d, %d entries", rec.Code, len(history))
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/yoloxsta/go-bookstore/pkg/cache"
+	"github.com/yoloxsta/go-bookstore/pkg/config"
+	"github.com/yoloxsta/go-bookstore/pkg/middleware"
+	"github.com/yoloxsta/go-bookstore/pkg/models"
+	"github.com/yoloxsta/go-bookstore/pkg/payment"
+	"github.com/yoloxsta/go-bookstore/pkg/storage"
+)
+
+type importReport struct {
+	DryRun   bool `json:"dry_run"`
+	Rows     int  `json:"rows"`
+	Imported int  `json:"imported"`
+	Failed   int  `json:"failed"`
+	Errors   []struct {
+		Line   int `json:"line"`
+		Fields []struct {
+			Field   string `json:"field"`
+			Message string `json:"message"`
+		} `json:"fields"`
+	} `json:"errors"`
+}
+
+func TestImportDryRunChecksUniqueness(t *testing.T) {
+	api := newTestAPI(t)
+	if rec := serve(t, api, http.MethodPost, "/book/", `{"name":"Existing","author":"A","sku":"SKU-1"}`); rec.Code != http.StatusCreated {
+		t.Fatalf("create: status = %d; body %s", rec.Code, rec.Body)
+	}
+	rows := strings.Join([]string{
+		`{"name":"Clashes with a book","author":"A","sku":"SKU-1"}`,
+		`{"name":"First","author":"A","isbn13":"978-0-306-40615-7"}`,
+		`{"name":"Same ISBN as line 2","author":"A","isbn13":"9780306406157"}`,
+		`{"name":"Fine","author":"A","sku":"SKU-2"}`,
+	}, "\n")
+
+	for _, dryRun := range []bool{true, false} {
+		rec := serve(t, api, http.MethodPost, fmt.Sprintf("/book/import?format=ndjson&dry_run=%t", dryRun), rows)
+		var rep importReport
+		decode(t, rec, &rep)
+		if rec.Code != http.StatusOK || rep.DryRun != dryRun || rep.Rows != 4 || rep.Imported != 2 || rep.Failed != 2 {
+			t.Fatalf("dry_run=%t: status = %d, report %+v", dryRun, rec.Code, rep)
+		}
+		var got []string
+		for _, e := range rep.Errors {
+			for _, f := range e.Fields {
+				got = append(got, fmt.Sprintf("%d:%s", e.Line, f.Field))
+			}
+		}
+		if strings.Join(got, " ") != "1:sku 3:isbn13" {
+			t.Errorf("dry_run=%t: errors %v, want the SKU on line 1 and the ISBN on line 3", dryRun, got)
+		}
+	}
+
+	var books []models.Book
+	decode(t, serve(t, api, http.MethodGet, "/book/", ""), &books)
+	if len(books) != 3 {
+		t.Errorf("%d books after the dry run and the import, want 3", len(books))
+	}
+}
+
+// slowBooks delays every page after the first, as a large table would.
+type slowBooks struct {
+	models.BookRepository
+	delay time.Duration
+}
+
+func (s slowBooks) List(q models.BookQuery) (models.BookPage, error) {
+	if q.Cursor != "" {
+		time.Sleep(s.delay)
+	}
+	return s.BookRepository.List(q)
+}
+
+func TestExportOutlastsTheWriteTimeout(t *testing.T) {
+	cfg := config.Default()
+	store := models.NewMemoryStore(cfg.Inventory)
+	n := models.MaxPageSize + 1
+	for i := 0; i < n; i++ {
+		if err := store.Books.Create(&models.Book{Name: fmt.Sprintf("Book %d", i), Author: "A"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	store.Books = slowBooks{store.Books, 200 * time.Millisecond}
+	srv := httptest.NewUnstartedServer(newRouter(cfg, store, payment.NewFake(), storage.NewLocal(t.TempDir()), nil))
+	srv.Config.WriteTimeout = 100 * time.Millisecond
+	srv.Start()
+	defer srv.Close()
+
+	res, err := srv.Client().Get(srv.URL + "/book/export?format=ndjson")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	lines := 0
+	sc := bufio.NewScanner(res.Body)
+	for sc.Scan() {
+		lines++
+	}
+	if err := sc.Err(); err != nil || lines != n {
+		t.Fatalf("exported %d of %d books; err %v", lines, n, err)
+	}
+}
+
+// listCache counts the book list pages stored in the cache it wraps.
+type listCache struct {
+	cache.Cache
+	lists atomic.Int32
+}
+
+func (c *listCache) Set(key string, value []byte, ttl time.Duration) error {
+	if strings.Contains(key, "list:") {
+		c.lists.Add(1)
+	}
+	return c.Cache.Set(key, value, ttl)
+}
+
+func TestExportBypassesTheCache(t *testing.T) {
+	cfg := config.Default()
+	store := models.NewMemoryStore(cfg.Inventory)
+	lists := &listCache{Cache: cache.NewLRU(1000)}
+	store.UseCache(lists, time.Minute)
+	n := models.MaxPageSize + 1
+	for i := 0; i < n; i++ {
+		if err := store.Books.Create(&models.Book{Name: fmt.Sprintf("Book %d", i), Author: "A"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	api := middleware.RequestID(newRouter(cfg, store, payment.NewFake(), storage.NewLocal(t.TempDir()), nil))
+
+	rec := serve(t, api, http.MethodGet, "/book/export?format=ndjson", "")
+	if lines := strings.Count(rec.Body.String(), "\n"); rec.Code != http.StatusOK || lines != n {
+		t.Fatalf("export: status = %d, %d of %d books", rec.Code, lines, n)
+	}
+	if got := lists.lists.Load(); got != 0 {
+		t.Fatalf("the export cached %d list pages", got)
+	}
+
+	if rec := serve(t, api, http.MethodGet, "/book/", ""); rec.Code != http.StatusOK {
+		t.Fatalf("list: status = %d", rec.Code)
+	}
+	if got := lists.lists.Load(); got != 1 {
+		t.Fatalf("a listing cached %d pages, want 1", got)
+	}
+}
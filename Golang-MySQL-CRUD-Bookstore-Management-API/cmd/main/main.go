@@ -1,17 +1,170 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"log"
+	"log/slog"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 
-	"github.com/gorilla/mux"
-	_ "github.com/jinzhu/gorm/dialects/mysql"
-	"github.com/yoloxsta/go-bookstore/pkg/routes"
+	"github.com/jinzhu/gorm"
+	"github.com/yoloxsta/go-bookstore/pkg/auth"
+	"github.com/yoloxsta/go-bookstore/pkg/cache"
+	"github.com/yoloxsta/go-bookstore/pkg/config"
+	"github.com/yoloxsta/go-bookstore/pkg/jobs"
+	"github.com/yoloxsta/go-bookstore/pkg/metrics"
+	"github.com/yoloxsta/go-bookstore/pkg/middleware"
+	"github.com/yoloxsta/go-bookstore/pkg/models"
+	"github.com/yoloxsta/go-bookstore/pkg/openapi"
+	"github.com/yoloxsta/go-bookstore/pkg/payment"
+	"github.com/yoloxsta/go-bookstore/pkg/rpc"
+	"github.com/yoloxsta/go-bookstore/pkg/server"
+	"github.com/yoloxsta/go-bookstore/pkg/storage"
 )
 
 func main() {
-	r := mux.NewRouter()
-	routes.RegisterBookStoreRoutes(r)
-	http.Handle("/", r)
-	log.Fatal(http.ListenAndServe("localhost:9010", r))
+	configPath := flag.String("config", os.Getenv("BOOKSTORE_CONFIG"), "path to an optional YAML or TOML config file")
+	flag.Parse()
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	slog.SetDefault(newLogger(cfg.Log))
+
+	args := flag.Args()
+	if len(args) > 0 && args[0] == "token" {
+		if err := runToken(cfg.Auth, args[1:], os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if len(args) > 0 && args[0] != "migrate" {
+		log.Fatalf("unknown command %q; %s; %s", args[0], migrateUsage, tokenUsage)
+	}
+
+	var db *gorm.DB
+	if cfg.Database.Driver != config.DriverMemory {
+		db, err = config.Connect(cfg.Database)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if len(args) > 0 {
+		if db == nil {
+			log.Fatal("migrate: the memory driver has no schema to migrate")
+		}
+		err := runMigrate(db, args[1:], os.Stdout)
+		db.Close()
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if db != nil {
+		if err := checkSchema(db); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if db != nil {
+		metrics.InstrumentDB(db, cfg.Database.Driver)
+	}
+	store, err := models.NewStore(cfg, db)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var books cache.Cache
+	if cfg.Cache.Driver != config.CacheNone {
+		if books, err = cache.New(cfg.Cache); err != nil {
+			log.Fatal(err)
+		}
+		store.UseCache(books, cfg.Cache.TTL)
+	}
+
+	payments, err := payment.New(cfg.Payment.Provider)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	blobs, err := storage.New(cfg.Storage)
+	if err != nil {
+		log.Fatal(err)
+	}
+	store.UseBlobs(blobs)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
+	go jobs.PurgeTrash(ctx, store.Books, cfg.Trash)
+
+	var verifier *auth.Verifier
+	if cfg.Auth.Algorithm == config.AuthNone {
+		slog.Warn("auth: disabled; every endpoint is open")
+	} else if verifier, err = auth.NewVerifier(cfg.Auth); err != nil {
+		log.Fatal(err)
+	}
+	r := newRouter(cfg, store, payments, blobs, verifier)
+
+	srv := server.New(cfg.Server, middleware.RequestID(middleware.Instrument(r)))
+	srv.Handle(openapi.SpecPath, openapi.Handler())
+	srv.Handle(openapi.UIPath, openapi.UIHandler())
+	if books != nil {
+		srv.OnClose(books.Close)
+	}
+	if db != nil {
+		srv.AddCheck("database", db.DB().PingContext)
+		srv.OnClose(db.Close)
+	}
+	if cfg.Server.AdminAddr != "" {
+		lis, err := net.Listen("tcp", cfg.Server.AdminAddr)
+		if err != nil {
+			log.Fatal(err)
+		}
+		admin := newAdminServer(cfg.Server)
+		go func() {
+			if err := admin.Serve(lis); !errors.Is(err, http.ErrServerClosed) {
+				slog.Error("admin: serving", "err", err)
+			}
+		}()
+		slog.Info("admin: listening", "addr", lis.Addr().String())
+		srv.OnShutdown(func(ctx context.Context) { admin.Shutdown(ctx) })
+	}
+	if cfg.GRPC.Addr != "" {
+		lis, err := net.Listen("tcp", cfg.GRPC.Addr)
+		if err != nil {
+			log.Fatal(err)
+		}
+		rpcServer := rpc.NewServer(store, verifier, cfg.GRPC)
+		go func() {
+			if err := rpcServer.Serve(lis); err != nil {
+				slog.Error("grpc: serving", "err", err)
+			}
+		}()
+		slog.Info("grpc: listening", "addr", lis.Addr().String())
+		srv.OnShutdown(rpcServer.Shutdown)
+	}
+	if err := srv.Run(ctx); err != nil {
+		log.Fatal(err)
+	}
+	slog.Info("server: stopped")
+}
+
+// newLogger writes one JSON or text record per line to stderr, tagging
+// records logged with a request's context with its request_id. The config
+// has already checked the format and level.
+func newLogger(cfg config.LogConfig) *slog.Logger {
+	var level slog.Level
+	level.UnmarshalText([]byte(cfg.Level))
+	opts := &slog.HandlerOptions{Level: level}
+	var h slog.Handler = slog.NewJSONHandler(os.Stderr, opts)
+	if cfg.Format == config.LogText {
+		h = slog.NewTextHandler(os.Stderr, opts)
+	}
+	return slog.New(middleware.NewLogHandler(h))
 }
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/jinzhu/gorm"
+	"github.com/yoloxsta/go-bookstore/pkg/migrate"
+)
+
+const migrateUsage = "usage: bookstore migrate up | down [steps] | status"
+
+// runMigrate implements `bookstore migrate up|down|status`.
+func runMigrate(db *gorm.DB, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+	m, err := migrate.New(db, migrate.All)
+	if err != nil {
+		return err
+	}
+	switch args[0] {
+	case "up":
+		applied, err := m.Up()
+		for _, mg := range applied {
+			fmt.Fprintf(out, "applied  %04d %s\n", mg.Version, mg.Name)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Fprintln(out, "schema is up to date")
+		}
+		return err
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps <= 0 {
+				return fmt.Errorf("down: steps must be a positive integer")
+			}
+		}
+		rolled, err := m.Down(steps)
+		for _, mg := range rolled {
+			fmt.Fprintf(out, "reverted %04d %s\n", mg.Version, mg.Name)
+		}
+		return err
+	case "status":
+		statuses, err := m.Status()
+		if err != nil {
+			return err
+		}
+		for _, s := range statuses {
+			applied := "pending"
+			if s.AppliedAt != nil {
+				applied = "applied " + s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(out, "%04d %-30s %s\n", s.Version, s.Name, applied)
+		}
+		unknown, err := m.Unknown()
+		for _, v := range unknown {
+			fmt.Fprintf(out, "%04d %-30s applied, unknown to this binary\n", v, "?")
+		}
+		return err
+	}
+	return errors.New(migrateUsage)
+}
+
+// checkSchema refuses to serve against a database with pending migrations.
+func checkSchema(db *gorm.DB) error {
+	m, err := migrate.New(db, migrate.All)
+	if err != nil {
+		return err
+	}
+	pending, err := m.Pending()
+	if err != nil {
+		return err
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("schema is behind by %d migration(s), starting with %04d %s; run `bookstore migrate up`",
+			len(pending), pending[0].Version, pending[0].Name)
+	}
+	return nil
+}
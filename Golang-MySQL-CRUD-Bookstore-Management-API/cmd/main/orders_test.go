@@ -0,0 +1,277 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/yoloxsta/go-bookstore/pkg/config"
+	"github.com/yoloxsta/go-bookstore/pkg/middleware"
+	"github.com/yoloxsta/go-bookstore/pkg/models"
+	"github.com/yoloxsta/go-bookstore/pkg/payment"
+	"github.com/yoloxsta/go-bookstore/pkg/storage"
+	"github.com/yoloxsta/go-bookstore/pkg/utils"
+)
+
+// flakyRefunds is the fake provider with refunds that fail while failing is
+// set.
+type flakyRefunds struct {
+	*payment.Fake
+	failing atomic.Bool
+}
+
+func (p *flakyRefunds) Refund(ctx context.Context, chargeID string) error {
+	if p.failing.Load() {
+		return errors.New("provider unavailable")
+	}
+	return p.Fake.Refund(ctx, chargeID)
+}
+
+// newShop serves the API on payments, with one book holding stock copies.
+func newShop(t *testing.T, payments payment.Provider, stock int) (http.Handler, *models.Store) {
+	t.Helper()
+	cfg := config.Default()
+	store := models.NewMemoryStore(cfg.Inventory)
+	api := middleware.RequestID(newRouter(cfg, store, payments, storage.NewLocal(t.TempDir()), nil))
+	if rec := serve(t, api, http.MethodPost, "/book/", validBook); rec.Code != http.StatusCreated {
+		t.Fatalf("create book: status = %d", rec.Code)
+	}
+	if err := store.Inventory.Move(&models.StockMovement{BookID: 1, Kind: models.MovementReceive, Quantity: stock}); err != nil {
+		t.Fatal(err)
+	}
+	return api, store
+}
+
+func wantStock(t *testing.T, store *models.Store, want int) {
+	t.Helper()
+	level, err := store.Inventory.Get(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if level.Quantity != want {
+		t.Errorf("stock = %d, want %d", level.Quantity, want)
+	}
+}
+
+// checkout places an order for quantity copies of the book.
+func checkout(t *testing.T, api http.Handler, quantity string) *httptest.ResponseRecorder {
+	t.Helper()
+	if rec := serve(t, api, http.MethodPost, "/cart/", ""); rec.Code != http.StatusCreated {
+		t.Fatalf("create cart: status = %d; body %s", rec.Code, rec.Body)
+	}
+	if rec := serve(t, api, http.MethodPut, "/cart/1/items/1", `{"quantity":`+quantity+`}`); rec.Code != http.StatusOK {
+		t.Fatalf("add item: status = %d; body %s", rec.Code, rec.Body)
+	}
+	return serve(t, api, http.MethodPost, "/order/", `{"cart_id":1}`)
+}
+
+func TestCheckoutPayAndRefund(t *testing.T) {
+	provider := payment.NewFake()
+	api, store := newShop(t, provider, 5)
+
+	rec := checkout(t, api, "2")
+	var order models.Order
+	decode(t, rec, &order)
+	if rec.Code != http.StatusCreated || order.Status != models.OrderPending || order.TotalCents != 2*3999 || len(order.Items) != 1 {
+		t.Fatalf("checkout: status = %d, order %+v", rec.Code, order)
+	}
+	wantStock(t, store, 3)
+	wantError(t, serve(t, api, http.MethodPut, "/cart/1/items/1", `{"quantity":1}`), http.StatusConflict, utils.CodeConflict)
+
+	wantError(t, serve(t, api, http.MethodPost, "/order/1/pay", `{"payment_token":"`+payment.TokenDecline+`"}`),
+		http.StatusPaymentRequired, utils.CodePaymentDeclined)
+	rec = serve(t, api, http.MethodPost, "/order/1/pay", `{"payment_token":"tok_visa"}`)
+	decode(t, rec, &order)
+	if rec.Code != http.StatusOK || order.Status != models.OrderPaid || order.PaymentID == "" {
+		t.Fatalf("pay: status = %d, order %+v", rec.Code, order)
+	}
+	wantError(t, serve(t, api, http.MethodPost, "/order/1/cancel", ""), http.StatusConflict, utils.CodeConflict)
+
+	rec = serve(t, api, http.MethodPost, "/order/1/refund", "")
+	decode(t, rec, &order)
+	if rec.Code != http.StatusOK || order.Status != models.OrderRefunded {
+		t.Fatalf("refund: status = %d, order %+v", rec.Code, order)
+	}
+	if !provider.Refunded(order.PaymentID) {
+		t.Error("the charge was not refunded")
+	}
+	wantStock(t, store, 5)
+
+	var events []models.OrderEvent
+	decode(t, serve(t, api, http.MethodGet, "/order/1/history", ""), &events)
+	if len(events) != 3 {
+		t.Errorf("history has %d events, want 3: %+v", len(events), events)
+	}
+}
+
+func TestCheckoutInsufficientStock(t *testing.T) {
+	api, store := newShop(t, payment.NewFake(), 1)
+	wantError(t, checkout(t, api, "2"), http.StatusConflict, utils.CodeInsufficientStock)
+	wantStock(t, store, 1)
+	wantError(t, serve(t, api, http.MethodGet, "/order/1", ""), http.StatusNotFound, utils.CodeNotFound)
+}
+
+func TestRefundRetriesAFailedProviderRefund(t *testing.T) {
+	provider := &flakyRefunds{Fake: payment.NewFake()}
+	api, store := newShop(t, provider, 5)
+	checkout(t, api, "1")
+	var order models.Order
+	decode(t, serve(t, api, http.MethodPost, "/order/1/pay", `{"payment_token":"tok_visa"}`), &order)
+
+	provider.failing.Store(true)
+	wantError(t, serve(t, api, http.MethodPost, "/order/1/refund", ""), http.StatusInternalServerError, utils.CodeInternal)
+	decode(t, serve(t, api, http.MethodGet, "/order/1", ""), &order)
+	if order.Status != models.OrderRefunded || provider.Refunded(order.PaymentID) {
+		t.Fatalf("after a failed refund: order %q, charge refunded %v; want refunded, false", order.Status, provider.Refunded(order.PaymentID))
+	}
+	wantStock(t, store, 5)
+
+	provider.failing.Store(false)
+	if rec := serve(t, api, http.MethodPost, "/order/1/refund", ""); rec.Code != http.StatusOK {
+		t.Fatalf("retry: status = %d; body %s", rec.Code, rec.Body)
+	}
+	if !provider.Refunded(order.PaymentID) {
+		t.Error("the retry did not refund the charge")
+	}
+	wantStock(t, store, 5)
+}
+
+func TestCustomersOnlyReachTheirOwnCartsAndOrders(t *testing.T) {
+	store := models.NewMemoryStore(config.Default().Inventory)
+	api, bearer := newSignedAPI(t, store)
+	ana, bob, editor := bearer("ana", "reader"), bearer("bob", "reader"), bearer("ed", "editor")
+
+	if rec := serve(t, api, http.MethodPost, "/book/", validBook, editor...); rec.Code != http.StatusCreated {
+		t.Fatalf("create book: status = %d", rec.Code)
+	}
+	if err := store.Inventory.Move(&models.StockMovement{BookID: 1, Kind: models.MovementReceive, Quantity: 5}); err != nil {
+		t.Fatal(err)
+	}
+
+	// A reader shops without the editor role POSTs otherwise need.
+	rec := serve(t, api, http.MethodPost, "/cart/", "", ana...)
+	var cart models.Cart
+	decode(t, rec, &cart)
+	if rec.Code != http.StatusCreated || cart.Customer != "ana" {
+		t.Fatalf("create cart: status = %d, cart %+v", rec.Code, cart)
+	}
+	if rec = serve(t, api, http.MethodPut, "/cart/1/items/1", `{"quantity":1}`, ana...); rec.Code != http.StatusOK {
+		t.Fatalf("add item: status = %d; body %s", rec.Code, rec.Body)
+	}
+
+	// Someone else's cart does not exist as far as another reader knows.
+	wantError(t, serve(t, api, http.MethodGet, "/cart/1", "", bob...), http.StatusNotFound, utils.CodeNotFound)
+	wantError(t, serve(t, api, http.MethodPut, "/cart/1/items/1", `{"quantity":3}`, bob...), http.StatusNotFound, utils.CodeNotFound)
+	wantError(t, serve(t, api, http.MethodPost, "/order/", `{"cart_id":1}`, bob...), http.StatusNotFound, utils.CodeNotFound)
+	wantError(t, serve(t, api, http.MethodPost, "/order/", `{"cart_id":1,"customer":"bob"}`, ana...),
+		http.StatusUnprocessableEntity, utils.CodeValidation)
+
+	rec = serve(t, api, http.MethodPost, "/order/", `{"cart_id":1}`, ana...)
+	var order models.Order
+	decode(t, rec, &order)
+	if rec.Code != http.StatusCreated || order.Customer != "ana" {
+		t.Fatalf("checkout: status = %d, order %+v", rec.Code, order)
+	}
+
+	wantError(t, serve(t, api, http.MethodGet, "/order/1", "", bob...), http.StatusNotFound, utils.CodeNotFound)
+	wantError(t, serve(t, api, http.MethodPost, "/order/1/cancel", "", bob...), http.StatusNotFound, utils.CodeNotFound)
+	for _, tc := range []struct {
+		name    string
+		headers []string
+		want    int
+	}{
+		{"owner", ana, 1},
+		{"other reader asking for the owner's", bob, 0},
+		{"editor", editor, 1},
+	} {
+		var orders []models.Order
+		decode(t, serve(t, api, http.MethodGet, "/order/?customer=ana", "", tc.headers...), &orders)
+		if len(orders) != tc.want {
+			t.Errorf("%s: listed %d orders, want %d", tc.name, len(orders), tc.want)
+		}
+	}
+
+	// Shipping and refunding stay with editors.
+	if rec = serve(t, api, http.MethodPost, "/order/1/pay", `{"payment_token":"tok_visa"}`, ana...); rec.Code != http.StatusOK {
+		t.Fatalf("pay: status = %d; body %s", rec.Code, rec.Body)
+	}
+	wantError(t, serve(t, api, http.MethodPost, "/order/1/refund", "", ana...), http.StatusForbidden, utils.CodeForbidden)
+	if rec = serve(t, api, http.MethodPost, "/order/1/ship", "", editor...); rec.Code != http.StatusOK {
+		t.Fatalf("ship: status = %d; body %s", rec.Code, rec.Body)
+	}
+}
+
+// conflictingOrders loses every status change to another request.
+type conflictingOrders struct {
+	models.OrderRepository
+}
+
+func (conflictingOrders) Transition(int64, models.OrderTransition) (*models.Order, error) {
+	return nil, models.ErrConflict
+}
+
+func TestOrderConflictNamesTheOrder(t *testing.T) {
+	api, store := newShop(t, payment.NewFake(), 5)
+	checkout(t, api, "1")
+	cfg := config.Default()
+	store.Orders = conflictingOrders{store.Orders}
+	api = middleware.RequestID(newRouter(cfg, store, payment.NewFake(), storage.NewLocal(t.TempDir()), nil))
+
+	body := wantError(t, serve(t, api, http.MethodPost, "/order/1/cancel", ""), http.StatusConflict, utils.CodeConflict)
+	if body.Message != "order was changed by another request; reload and retry" {
+		t.Errorf("message = %q", body.Message)
+	}
+}
+
+// gatedCharges holds every charge until n of them are in flight, so that
+// concurrent pay requests all pass the status check before any is paid.
+type gatedCharges struct {
+	*payment.Fake
+	arrived chan struct{}
+	n       int
+}
+
+func (p *gatedCharges) Charge(ctx context.Context, req payment.ChargeRequest) (payment.Charge, error) {
+	p.arrived <- struct{}{}
+	for len(p.arrived) < p.n {
+		time.Sleep(time.Millisecond)
+	}
+	return p.Fake.Charge(ctx, req)
+}
+
+func TestConcurrentPayKeepsTheCharge(t *testing.T) {
+	const n = 2
+	provider := &gatedCharges{Fake: payment.NewFake(), arrived: make(chan struct{}, n), n: n}
+	api, _ := newShop(t, provider, 5)
+	checkout(t, api, "1")
+
+	results := make(chan *httptest.ResponseRecorder, n)
+	for i := 0; i < n; i++ {
+		go func() {
+			req := httptest.NewRequest(http.MethodPost, "/order/1/pay", strings.NewReader(`{"payment_token":"tok_visa"}`))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+			api.ServeHTTP(rec, req)
+			results <- rec
+		}()
+	}
+	for i := 0; i < n; i++ {
+		rec := <-results
+		var order models.Order
+		decode(t, rec, &order)
+		if rec.Code != http.StatusOK || order.Status != models.OrderPaid || order.PaymentID == "" {
+			t.Errorf("pay %d: status = %d, order %+v", i, rec.Code, order)
+		}
+	}
+
+	var order models.Order
+	decode(t, serve(t, api, http.MethodGet, "/order/1", ""), &order)
+	if order.Status != models.OrderPaid || provider.Refunded(order.PaymentID) {
+		t.Fatalf("order %s with payment %s refunded %t; want paid and kept", order.Status, order.PaymentID, provider.Refunded(order.PaymentID))
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/yoloxsta/go-bookstore/pkg/auth"
+	"github.com/yoloxsta/go-bookstore/pkg/config"
+	"github.com/yoloxsta/go-bookstore/pkg/controllers"
+	"github.com/yoloxsta/go-bookstore/pkg/graph"
+	"github.com/yoloxsta/go-bookstore/pkg/metrics"
+	"github.com/yoloxsta/go-bookstore/pkg/models"
+	"github.com/yoloxsta/go-bookstore/pkg/payment"
+	"github.com/yoloxsta/go-bookstore/pkg/routes"
+	"github.com/yoloxsta/go-bookstore/pkg/storage"
+	"github.com/yoloxsta/go-bookstore/pkg/utils"
+)
+
+// newRouter registers every REST and GraphQL route behind authentication. A
+// nil verifier leaves every endpoint open.
+func newRouter(cfg config.Config, store *models.Store, payments payment.Provider, blobs storage.Storage, verifier *auth.Verifier) *mux.Router {
+	r := mux.NewRouter()
+	r.NotFoundHandler = utils.NotFoundHandler
+	r.MethodNotAllowedHandler = utils.MethodNotAllowedHandler
+	if verifier == nil {
+		r.Use(auth.Anonymous)
+	} else {
+		r.Use(auth.Authenticate(verifier))
+	}
+	exempt := append([]string{routes.GraphQLRoute, routes.CreateReviewRoute}, routes.CustomerRoutes...)
+	r.Use(auth.ByMethod(exempt...))
+	routes.RegisterGraphQLRoutes(r, graph.NewHandler(store))
+	routes.RegisterBookStoreRoutes(r, controllers.NewBookController(store, cfg.Cache.MaxAge))
+	routes.RegisterOrderRoutes(r, controllers.NewCartController(store.Carts),
+		controllers.NewOrderController(store, payments, cfg.Payment.Currency))
+	routes.RegisterAuthorRoutes(r, controllers.NewAuthorController(store.Authors))
+	routes.RegisterPublisherRoutes(r, controllers.NewPublisherController(store.Publishers))
+	routes.RegisterInventoryRoutes(r, controllers.NewInventoryController(store.Inventory))
+	routes.RegisterCoverRoutes(r, controllers.NewCoverController(store, blobs, cfg.Covers.MaxBytes))
+	routes.RegisterReviewRoutes(r, controllers.NewReviewController(store.Reviews, store.Books, cfg.Reviews.AutoApprove))
+	return r
+}
+
+// newAdminServer serves the metrics on cfg.AdminAddr, away from the API and
+// its authentication.
+func newAdminServer(cfg config.ServerConfig) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle(metrics.Path, metrics.Handler())
+	return &http.Server{
+		Addr:         cfg.AdminAddr,
+		Handler:      mux,
+		ReadTimeout:  cfg.ReadTimeout,
+		WriteTimeout: cfg.WriteTimeout,
+		IdleTimeout:  cfg.IdleTimeout,
+	}
+}
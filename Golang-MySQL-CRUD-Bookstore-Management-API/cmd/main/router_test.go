@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/yoloxsta/go-bookstore/pkg/config"
+	"github.com/yoloxsta/go-bookstore/pkg/metrics"
+	"github.com/yoloxsta/go-bookstore/pkg/models"
+	"github.com/yoloxsta/go-bookstore/pkg/openapi"
+	"github.com/yoloxsta/go-bookstore/pkg/payment"
+	"github.com/yoloxsta/go-bookstore/pkg/storage"
+	"github.com/yoloxsta/go-bookstore/pkg/utils"
+)
+
+// newTestRouter serves the full API, without authentication, from a fresh
+// memory store.
+func newTestRouter(t *testing.T) (*mux.Router, *models.Store) {
+	t.Helper()
+	cfg := config.Default()
+	store := models.NewMemoryStore(cfg.Inventory)
+	return newRouter(cfg, store, payment.NewFake(), storage.NewLocal(t.TempDir()), nil), store
+}
+
+func TestOpenAPIDescribesEveryRoute(t *testing.T) {
+	var spec struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(openapi.Spec, &spec); err != nil {
+		t.Fatalf("parsing openapi.json: %v", err)
+	}
+	router, _ := newTestRouter(t)
+
+	routed := map[string]bool{}
+	err := router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			return err
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return err
+		}
+		for _, method := range methods {
+			key := strings.ToLower(method) + " " + path
+			routed[key] = true
+			if _, ok := spec.Paths[path][strings.ToLower(method)]; !ok {
+				t.Errorf("%s %s is routed but missing from openapi.json", method, path)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, ops := range spec.Paths {
+		for method := range ops {
+			if !routed[method+" "+path] {
+				t.Errorf("openapi.json describes %s %s, which is not routed", strings.ToUpper(method), path)
+			}
+		}
+	}
+}
+
+func TestMetricsStayOffTheAPI(t *testing.T) {
+	wantError(t, serve(t, newTestAPI(t), http.MethodGet, metrics.Path, ""), http.StatusNotFound, utils.CodeNotFound)
+
+	rec := serve(t, newAdminServer(config.Default().Server).Handler, http.MethodGet, metrics.Path, "")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "bookstore_http_requests_in_flight") {
+		t.Fatalf("admin %s: status = %d; body %.200s", metrics.Path, rec.Code, rec.Body)
+	}
+}
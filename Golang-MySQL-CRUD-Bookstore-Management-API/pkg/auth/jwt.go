@@ -0,0 +1,270 @@
+// Package auth verifies the JWT bearer tokens that authenticate API callers.
+package auth
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"hash"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/yoloxsta/go-bookstore/pkg/config"
+
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+)
+
+var (
+	ErrMalformed = errors.New("token is malformed")
+	ErrSignature = errors.New("token signature is invalid")
+	ErrExpired   = errors.New("token has expired")
+	ErrNotYet    = errors.New("token is not valid yet")
+	ErrClaims    = errors.New("token issuer or audience does not match")
+	ErrSubject   = errors.New("token has no subject")
+)
+
+// Claims are the parts of a token payload the bookstore uses. Roles may be
+// given as a "roles" array, a single "role", or both.
+type Claims struct {
+	Subject   string   `json:"sub"`
+	Issuer    string   `json:"iss,omitempty"`
+	Audience  Audience `json:"aud,omitempty"`
+	ExpiresAt int64    `json:"exp"`
+	NotBefore int64    `json:"nbf,omitempty"`
+	IssuedAt  int64    `json:"iat,omitempty"`
+	Role      string   `json:"role,omitempty"`
+	Roles     []string `json:"roles,omitempty"`
+}
+
+// HighestRole is the strongest role the token grants.
+func (c Claims) HighestRole() Role {
+	best := ParseRole(c.Role)
+	for _, name := range c.Roles {
+		best = max(best, ParseRole(name))
+	}
+	return best
+}
+
+// Audience accepts both forms of the "aud" claim: a string or an array.
+type Audience []string
+
+func (a *Audience) UnmarshalJSON(data []byte) error {
+	var one string
+	if err := json.Unmarshal(data, &one); err == nil {
+		*a = Audience{one}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return err
+	}
+	*a = many
+	return nil
+}
+
+func (a Audience) MarshalJSON() ([]byte, error) {
+	if len(a) == 1 {
+		return json.Marshal(a[0])
+	}
+	return json.Marshal([]string(a))
+}
+
+type header struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ,omitempty"`
+}
+
+var hashes = map[string]crypto.Hash{
+	"256": crypto.SHA256,
+	"384": crypto.SHA384,
+	"512": crypto.SHA512,
+}
+
+// Verifier checks tokens signed with one configured algorithm and key.
+// Tokens naming any other algorithm, including "none", are rejected.
+type Verifier struct {
+	alg      string
+	hash     crypto.Hash
+	secret   []byte
+	key      *rsa.PublicKey
+	issuer   string
+	audience string
+	leeway   time.Duration
+	now      func() time.Time
+}
+
+// NewVerifier loads the key named by cfg.
+func NewVerifier(cfg config.AuthConfig) (*Verifier, error) {
+	v := &Verifier{
+		alg:      cfg.Algorithm,
+		issuer:   cfg.Issuer,
+		audience: cfg.Audience,
+		leeway:   cfg.Leeway,
+		now:      time.Now,
+	}
+	if len(cfg.Algorithm) != 5 {
+		return nil, fmt.Errorf("auth: unsupported algorithm %q", cfg.Algorithm)
+	}
+	h, ok := hashes[cfg.Algorithm[2:]]
+	if !ok {
+		return nil, fmt.Errorf("auth: unsupported algorithm %q", cfg.Algorithm)
+	}
+	v.hash = h
+	switch cfg.Algorithm[:2] {
+	case "HS":
+		v.secret = []byte(cfg.Secret)
+	case "RS":
+		data, err := os.ReadFile(cfg.PublicKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("auth: reading public key: %w", err)
+		}
+		if v.key, err = parseRSAPublicKey(data); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("auth: unsupported algorithm %q", cfg.Algorithm)
+	}
+	return v, nil
+}
+
+func parseRSAPublicKey(data []byte) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("auth: public key is not PEM encoded")
+	}
+	if key, err := x509.ParsePKCS1PublicKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if cert, err := x509.ParseCertificate(block.Bytes); err == nil {
+		if key, ok := cert.PublicKey.(*rsa.PublicKey); ok {
+			return key, nil
+		}
+	}
+	parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("auth: parsing public key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PublicKey)
+	if !ok {
+		return nil, errors.New("auth: public key is not an RSA key")
+	}
+	return key, nil
+}
+
+// Verify checks the token's signature, lifetime, issuer and audience and
+// returns its claims. Tokens must carry an expiry and a subject, which names
+// the caller's carts, orders and audit entries.
+func (v *Verifier) Verify(token string) (Claims, error) {
+	var claims Claims
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return claims, ErrMalformed
+	}
+	var h header
+	if err := decodeSegment(parts[0], &h); err != nil {
+		return claims, ErrMalformed
+	}
+	if h.Alg != v.alg {
+		return claims, ErrSignature
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return claims, ErrMalformed
+	}
+	signed := parts[0] + "." + parts[1]
+	if !v.checkSignature(signed, sig) {
+		return claims, ErrSignature
+	}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return claims, ErrMalformed
+	}
+
+	now := v.now()
+	if claims.ExpiresAt == 0 || now.After(time.Unix(claims.ExpiresAt, 0).Add(v.leeway)) {
+		return claims, ErrExpired
+	}
+	if claims.NotBefore != 0 && now.Add(v.leeway).Before(time.Unix(claims.NotBefore, 0)) {
+		return claims, ErrNotYet
+	}
+	if v.issuer != "" && claims.Issuer != v.issuer {
+		return claims, ErrClaims
+	}
+	if v.audience != "" && !contains(claims.Audience, v.audience) {
+		return claims, ErrClaims
+	}
+	if claims.Subject == "" {
+		return claims, ErrSubject
+	}
+	return claims, nil
+}
+
+func (v *Verifier) checkSignature(signed string, sig []byte) bool {
+	if v.key != nil {
+		digest := v.digest(signed)
+		return rsa.VerifyPKCS1v15(v.key, v.hash, digest, sig) == nil
+	}
+	return hmac.Equal(sig, v.mac(signed))
+}
+
+func (v *Verifier) digest(s string) []byte {
+	h := v.hash.New()
+	h.Write([]byte(s))
+	return h.Sum(nil)
+}
+
+func (v *Verifier) mac(s string) []byte {
+	m := hmac.New(func() hash.Hash { return v.hash.New() }, v.secret)
+	m.Write([]byte(s))
+	return m.Sum(nil)
+}
+
+// Sign issues an HMAC-signed token for claims, for local use and scripts.
+// RSA deployments mint tokens with their identity provider instead.
+func (v *Verifier) Sign(claims Claims) (string, error) {
+	if v.key != nil {
+		return "", errors.New("auth: only HMAC tokens can be signed locally")
+	}
+	head, err := encodeSegment(header{Alg: v.alg, Typ: "JWT"})
+	if err != nil {
+		return "", err
+	}
+	body, err := encodeSegment(claims)
+	if err != nil {
+		return "", err
+	}
+	signed := head + "." + body
+	return signed + "." + base64.RawURLEncoding.EncodeToString(v.mac(signed)), nil
+}
+
+func decodeSegment(seg string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func encodeSegment(v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,276 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yoloxsta/go-bookstore/pkg/config"
+)
+
+var testNow = time.Unix(1_700_000_000, 0)
+
+func hmacVerifier(t *testing.T, cfg config.AuthConfig) *Verifier {
+	t.Helper()
+	if cfg.Algorithm == "" {
+		cfg.Algorithm = "HS256"
+	}
+	if cfg.Secret == "" {
+		cfg.Secret = "0123456789abcdef0123456789abcdef"
+	}
+	v, err := NewVerifier(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v.now = func() time.Time { return testNow }
+	return v
+}
+
+func validClaims() Claims {
+	return Claims{Subject: "alice", Role: "editor", ExpiresAt: testNow.Add(time.Hour).Unix()}
+}
+
+func sign(t *testing.T, v *Verifier, c Claims) string {
+	t.Helper()
+	token, err := v.Sign(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+// rawToken assembles a token from a literal header and claims with sig as
+// its signature.
+func rawToken(t *testing.T, head string, c Claims, sig func(signed string) []byte) string {
+	t.Helper()
+	body, err := encodeSegment(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed := base64.RawURLEncoding.EncodeToString([]byte(head)) + "." + body
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig(signed))
+}
+
+func TestVerifyAcceptsAValidToken(t *testing.T) {
+	v := hmacVerifier(t, config.AuthConfig{})
+	c := validClaims()
+	c.Roles = []string{"reader", "admin"}
+	got, err := v.Verify(sign(t, v, c))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Subject != "alice" || got.HighestRole() != RoleAdmin {
+		t.Errorf("claims = %+v, highest role %s", got, got.HighestRole())
+	}
+}
+
+func TestVerifyRejectsAlgNone(t *testing.T) {
+	v := hmacVerifier(t, config.AuthConfig{})
+	for _, head := range []string{`{"alg":"none","typ":"JWT"}`, `{"alg":"None"}`, `{"alg":""}`} {
+		token := rawToken(t, head, validClaims(), func(string) []byte { return nil })
+		if _, err := v.Verify(token); !errors.Is(err, ErrSignature) {
+			t.Errorf("%s: Verify = %v, want ErrSignature", head, err)
+		}
+	}
+	if _, err := NewVerifier(config.AuthConfig{Algorithm: "none"}); err == nil {
+		t.Error(`NewVerifier accepted algorithm "none"`)
+	}
+}
+
+func TestVerifyRejectsBadSignatures(t *testing.T) {
+	v := hmacVerifier(t, config.AuthConfig{})
+	token := sign(t, v, validClaims())
+	parts := strings.Split(token, ".")
+
+	other := hmacVerifier(t, config.AuthConfig{Secret: "another secret of enough length!"})
+	tampered := validClaims()
+	tampered.Role = "admin"
+	forged, _ := encodeSegment(tampered)
+
+	for name, tc := range map[string]struct {
+		token string
+		want  error
+	}{
+		"other secret":   {sign(t, other, validClaims()), ErrSignature},
+		"changed claims": {parts[0] + "." + forged + "." + parts[2], ErrSignature},
+		"no signature":   {parts[0] + "." + parts[1] + ".", ErrSignature},
+		"other hash":     {sign(t, hmacVerifier(t, config.AuthConfig{Algorithm: "HS512"}), validClaims()), ErrSignature},
+		"two parts":      {parts[0] + "." + parts[1], ErrMalformed},
+		"bad base64":     {parts[0] + "." + parts[1] + ".!!", ErrMalformed},
+		"bad header":     {"e30." + parts[1] + "." + parts[2], ErrSignature},
+		"garbage header": {"bm90IGpzb24." + parts[1] + "." + parts[2], ErrMalformed},
+	} {
+		if _, err := v.Verify(tc.token); !errors.Is(err, tc.want) {
+			t.Errorf("%s: Verify = %v, want %v", name, err, tc.want)
+		}
+	}
+}
+
+func TestVerifyLifetimeWithLeeway(t *testing.T) {
+	v := hmacVerifier(t, config.AuthConfig{Leeway: 30 * time.Second})
+	for name, tc := range map[string]struct {
+		exp, nbf time.Duration
+		want     error
+	}{
+		"valid":                      {exp: time.Hour},
+		"expired within leeway":      {exp: -20 * time.Second},
+		"expired beyond leeway":      {exp: -40 * time.Second, want: ErrExpired},
+		"not yet, within leeway":     {exp: time.Hour, nbf: 20 * time.Second},
+		"not yet, beyond leeway":     {exp: time.Hour, nbf: 40 * time.Second, want: ErrNotYet},
+		"no expiry":                  {want: ErrExpired},
+		"nbf in the past is ignored": {exp: time.Hour, nbf: -time.Hour},
+	} {
+		c := validClaims()
+		c.ExpiresAt = 0
+		if tc.exp != 0 {
+			c.ExpiresAt = testNow.Add(tc.exp).Unix()
+		}
+		if tc.nbf != 0 {
+			c.NotBefore = testNow.Add(tc.nbf).Unix()
+		}
+		if _, err := v.Verify(sign(t, v, c)); !errors.Is(err, tc.want) {
+			t.Errorf("%s: Verify = %v, want %v", name, err, tc.want)
+		}
+	}
+}
+
+func TestVerifyIssuerAudienceAndSubject(t *testing.T) {
+	v := hmacVerifier(t, config.AuthConfig{Issuer: "https://id.example", Audience: "bookstore"})
+	for name, tc := range map[string]struct {
+		edit func(*Claims)
+		want error
+	}{
+		"matching":          {edit: func(c *Claims) {}},
+		"audience in array": {edit: func(c *Claims) { c.Audience = Audience{"shop", "bookstore"} }},
+		"wrong issuer":      {edit: func(c *Claims) { c.Issuer = "https://evil.example" }, want: ErrClaims},
+		"no issuer":         {edit: func(c *Claims) { c.Issuer = "" }, want: ErrClaims},
+		"wrong audience":    {edit: func(c *Claims) { c.Audience = Audience{"shop"} }, want: ErrClaims},
+		"no subject":        {edit: func(c *Claims) { c.Subject = "" }, want: ErrSubject},
+	} {
+		c := validClaims()
+		c.Issuer, c.Audience = "https://id.example", Audience{"bookstore"}
+		tc.edit(&c)
+		if _, err := v.Verify(sign(t, v, c)); !errors.Is(err, tc.want) {
+			t.Errorf("%s: Verify = %v, want %v", name, err, tc.want)
+		}
+	}
+}
+
+// writeKey PEM-encodes der under blockType into a file and returns its path.
+func writeKey(t *testing.T, blockType string, der []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "key.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func rsaToken(t *testing.T, key *rsa.PrivateKey, alg string, hash crypto.Hash, c Claims) string {
+	t.Helper()
+	return rawToken(t, `{"alg":"`+alg+`","typ":"JWT"}`, c, func(signed string) []byte {
+		h := hash.New()
+		h.Write([]byte(signed))
+		sig, err := rsa.SignPKCS1v15(rand.Reader, key, hash, h.Sum(nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sig
+	})
+}
+
+func TestRSAKeysAndAlgorithmConfusion(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubDER, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{SerialNumber: big.NewInt(1), Subject: pkix.Name{CommonName: "id.example"},
+		NotBefore: testNow.Add(-time.Hour), NotAfter: testNow.Add(time.Hour)}
+	cert, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, path := range map[string]string{
+		"PKIX":        writeKey(t, "PUBLIC KEY", pubDER),
+		"PKCS1":       writeKey(t, "RSA PUBLIC KEY", x509.MarshalPKCS1PublicKey(&key.PublicKey)),
+		"certificate": writeKey(t, "CERTIFICATE", cert),
+	} {
+		v, err := NewVerifier(config.AuthConfig{Algorithm: "RS256", PublicKeyFile: path})
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		v.now = func() time.Time { return testNow }
+		if _, err := v.Verify(rsaToken(t, key, "RS256", crypto.SHA256, validClaims())); err != nil {
+			t.Errorf("%s: Verify = %v", name, err)
+		}
+		if _, err := v.Sign(validClaims()); err == nil {
+			t.Errorf("%s: Sign with a public key succeeded", name)
+		}
+	}
+
+	path := writeKey(t, "PUBLIC KEY", pubDER)
+	v, err := NewVerifier(config.AuthConfig{Algorithm: "RS256", PublicKeyFile: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	v.now = func() time.Time { return testNow }
+	// The classic confusion: an HS256 token whose secret is the public key
+	// file, which an attacker can read.
+	pemBytes, _ := os.ReadFile(path)
+	confused := hmacVerifier(t, config.AuthConfig{Secret: string(pemBytes)})
+	if _, err := v.Verify(sign(t, confused, validClaims())); !errors.Is(err, ErrSignature) {
+		t.Errorf("HS256 token signed with the public key: Verify = %v, want ErrSignature", err)
+	}
+	if _, err := v.Verify(rsaToken(t, key, "RS512", crypto.SHA512, validClaims())); !errors.Is(err, ErrSignature) {
+		t.Errorf("RS512 token for an RS256 verifier: Verify = %v, want ErrSignature", err)
+	}
+	other, _ := rsa.GenerateKey(rand.Reader, 2048)
+	if _, err := v.Verify(rsaToken(t, other, "RS256", crypto.SHA256, validClaims())); !errors.Is(err, ErrSignature) {
+		t.Errorf("token from another key: Verify = %v, want ErrSignature", err)
+	}
+
+	ec, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ecDER, _ := x509.MarshalPKIXPublicKey(&ec.PublicKey)
+	for name, path := range map[string]string{
+		"missing file": filepath.Join(t.TempDir(), "absent.pem"),
+		"not PEM":      writeRaw(t, "not a key"),
+		"not RSA":      writeKey(t, "PUBLIC KEY", ecDER),
+		"garbage DER":  writeKey(t, "PUBLIC KEY", []byte("junk")),
+	} {
+		if _, err := NewVerifier(config.AuthConfig{Algorithm: "RS256", PublicKeyFile: path}); err == nil {
+			t.Errorf("%s: NewVerifier succeeded", name)
+		}
+	}
+	for _, alg := range []string{"ES256", "HS1", "HS224", "PS256"} {
+		if _, err := NewVerifier(config.AuthConfig{Algorithm: alg, Secret: "s"}); err == nil {
+			t.Errorf("NewVerifier accepted %s", alg)
+		}
+	}
+}
+
+func writeRaw(t *testing.T, data string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "key.pem")
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
@@ -0,0 +1,130 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/yoloxsta/go-bookstore/pkg/config"
+	"github.com/yoloxsta/go-bookstore/pkg/utils"
+)
+
+// newProtected serves /book and /review behind Authenticate and ByMethod,
+// with the review route exempt. Handlers answer with the caller's actor.
+func newProtected(v *Verifier) http.Handler {
+	r := mux.NewRouter()
+	r.Use(Authenticate(v), ByMethod("create-review"))
+	actor := func(w http.ResponseWriter, r *http.Request) { w.Write([]byte(Actor(r.Context()))) }
+	r.HandleFunc("/book", actor)
+	r.HandleFunc("/review", actor).Name("create-review")
+	return r
+}
+
+func call(h http.Handler, method, path, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", token)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func errorCode(t *testing.T, rec *httptest.ResponseRecorder) string {
+	t.Helper()
+	var body utils.ErrorResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decoding %q: %v", rec.Body, err)
+	}
+	return body.Error.Code
+}
+
+func TestAuthenticate(t *testing.T) {
+	v := hmacVerifier(t, config.AuthConfig{})
+	h := newProtected(v)
+	expired := validClaims()
+	expired.ExpiresAt = testNow.Add(-time.Hour).Unix()
+
+	for name, tc := range map[string]struct {
+		header    string
+		challenge string
+	}{
+		"no header":    {"", `Bearer realm="bookstore"`},
+		"basic auth":   {"Basic YWxpY2U6cHc=", `Bearer realm="bookstore"`},
+		"empty bearer": {"Bearer ", `Bearer realm="bookstore"`},
+		"malformed":    {"Bearer not.a.jwt", `Bearer realm="bookstore", error="invalid_token"`},
+		"expired":      {"Bearer " + sign(t, v, expired), `Bearer realm="bookstore", error="invalid_token"`},
+		"no subject":   {"Bearer " + sign(t, v, Claims{Role: "admin", ExpiresAt: validClaims().ExpiresAt}), `Bearer realm="bookstore", error="invalid_token"`},
+	} {
+		rec := call(h, http.MethodGet, "/book", tc.header)
+		if rec.Code != http.StatusUnauthorized || errorCode(t, rec) != utils.CodeUnauthorized {
+			t.Errorf("%s: status = %d; body %s", name, rec.Code, rec.Body)
+		}
+		if got := rec.Header().Get("WWW-Authenticate"); got != tc.challenge {
+			t.Errorf("%s: WWW-Authenticate = %q, want %q", name, got, tc.challenge)
+		}
+	}
+
+	rec := call(h, http.MethodGet, "/book", "bearer "+sign(t, v, validClaims()))
+	if rec.Code != http.StatusOK || rec.Body.String() != "alice" {
+		t.Errorf("valid token: status = %d, actor %q", rec.Code, rec.Body)
+	}
+}
+
+func TestByMethod(t *testing.T) {
+	v := hmacVerifier(t, config.AuthConfig{})
+	h := newProtected(v)
+	token := func(role string) string {
+		c := validClaims()
+		c.Role = role
+		return "Bearer " + sign(t, v, c)
+	}
+	for _, tc := range []struct {
+		role, method, path string
+		want               int
+	}{
+		{"reader", http.MethodGet, "/book", http.StatusOK},
+		{"reader", http.MethodPost, "/book", http.StatusForbidden},
+		{"editor", http.MethodPut, "/book", http.StatusOK},
+		{"editor", http.MethodDelete, "/book", http.StatusForbidden},
+		{"admin", http.MethodDelete, "/book", http.StatusOK},
+		{"", http.MethodGet, "/book", http.StatusForbidden},
+		{"superuser", http.MethodGet, "/book", http.StatusForbidden},
+		// Exempt routes leave the role check to their handler.
+		{"reader", http.MethodPost, "/review", http.StatusOK},
+		{"", http.MethodPost, "/review", http.StatusOK},
+	} {
+		rec := call(h, tc.method, tc.path, token(tc.role))
+		if rec.Code != tc.want {
+			t.Errorf("%q %s %s: status = %d, want %d", tc.role, tc.method, tc.path, rec.Code, tc.want)
+			continue
+		}
+		if tc.want == http.StatusForbidden {
+			if code := errorCode(t, rec); code != utils.CodeForbidden || !strings.Contains(rec.Body.String(), "requires the") {
+				t.Errorf("%q %s %s: body %s", tc.role, tc.method, tc.path, rec.Body)
+			}
+		}
+	}
+	// An exempt route still needs a valid token.
+	if rec := call(h, http.MethodPost, "/review", ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("exempt route without a token: status = %d, want 401", rec.Code)
+	}
+}
+
+func TestAnonymousIsAdminWithoutSubject(t *testing.T) {
+	var claims Claims
+	h := Anonymous(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, _ = FromContext(r.Context())
+		if !Allowed(r.Context(), RoleAdmin) || Actor(r.Context()) != AnonymousActor {
+			t.Errorf("anonymous caller: admin %t, actor %q", Allowed(r.Context(), RoleAdmin), Actor(r.Context()))
+		}
+	}))
+	call(h, http.MethodDelete, "/", "")
+	if claims.Subject != "" || claims.HighestRole() != RoleAdmin {
+		t.Errorf("claims = %+v", claims)
+	}
+}
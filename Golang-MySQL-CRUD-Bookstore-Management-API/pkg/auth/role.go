@@ -0,0 +1,44 @@
+package auth
+
+import "net/http"
+
+// Role is a level of access. Each role includes the ones below it.
+type Role int
+
+const (
+	RoleNone Role = iota
+	RoleReader
+	RoleEditor
+	RoleAdmin
+)
+
+var roleNames = map[string]Role{
+	"reader": RoleReader,
+	"editor": RoleEditor,
+	"admin":  RoleAdmin,
+}
+
+func ParseRole(name string) Role {
+	return roleNames[name]
+}
+
+func (r Role) String() string {
+	for name, role := range roleNames {
+		if role == r {
+			return name
+		}
+	}
+	return "none"
+}
+
+// RequiredRole is the role a request method needs: reading takes a reader,
+// creating and changing takes an editor and deleting takes an admin.
+func RequiredRole(method string) Role {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return RoleReader
+	case http.MethodDelete:
+		return RoleAdmin
+	}
+	return RoleEditor
+}
@@ -0,0 +1,64 @@
+// Package bulk reads and writes books as CSV or newline-delimited JSON, one
+// row at a time, so imports and exports never hold a whole catalog in memory.
+package bulk
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+
+	"github.com/yoloxsta/go-bookstore/pkg/models"
+)
+
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+)
+
+// ErrUnknownFormat is returned for a format or media type that is neither
+// CSV nor NDJSON.
+var ErrUnknownFormat = errors.New("format must be csv or ndjson")
+
+// RowError is a problem confined to one row; the rows after it can still be
+// read. Err is a validation.Errors when the row could be attributed to fields.
+type RowError struct {
+	Line int
+	Err  error
+}
+
+func (e *RowError) Error() string { return fmt.Sprintf("line %d: %v", e.Line, e.Err) }
+
+func (e *RowError) Unwrap() error { return e.Err }
+
+// Decoder yields one book per row. Next returns the line the row started on,
+// a *RowError for a row that cannot be decoded, io.EOF at the end, and any
+// other error when the stream itself is unreadable.
+type Decoder interface {
+	Next(b *models.Book) (line int, err error)
+}
+
+// Encoder writes one book per row. Flush must be called after the last row.
+type Encoder interface {
+	Encode(b models.Book) error
+	Flush() error
+}
+
+// FormatForMediaType maps a Content-Type to a format name.
+func FormatForMediaType(contentType string) (string, error) {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "text/csv":
+		return FormatCSV, nil
+	case "application/x-ndjson", "application/jsonl", "application/jsonlines", "application/x-jsonlines":
+		return FormatNDJSON, nil
+	}
+	return "", ErrUnknownFormat
+}
+
+// MediaType is the Content-Type used when writing format.
+func MediaType(format string) string {
+	if format == FormatCSV {
+		return "text/csv; charset=utf-8"
+	}
+	return "application/x-ndjson"
+}
@@ -0,0 +1,197 @@
+package bulk
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yoloxsta/go-bookstore/pkg/models"
+	"github.com/yoloxsta/go-bookstore/pkg/validation"
+)
+
+// Columns is the CSV header written by exports. Lists of IDs are separated
+// by semicolons. Imports accept the same columns so an export can be loaded
+// back, but ignore id, version and the timestamps: every imported row becomes
+// a new book.
+var Columns = []string{
+	"id", "name", "author", "publication", "author_ids", "publisher_id", "sku", "isbn13", "isbn10",
+	"price_cents", "version", "created_at", "updated_at",
+}
+
+type csvDecoder struct {
+	r       *csv.Reader
+	columns []string
+}
+
+// NewCSVDecoder reads the header row and returns a decoder for the rows that
+// follow. Column names are matched case-insensitively and may come in any
+// order; an unknown column is an error for the whole stream.
+func NewCSVDecoder(r io.Reader) (Decoder, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.ReuseRecord = true
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, errors.New("csv: missing header row")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("csv: header: %w", err)
+	}
+	known := map[string]bool{}
+	for _, c := range Columns {
+		known[c] = true
+	}
+	seen := map[string]bool{}
+	columns := make([]string, len(header))
+	for i, h := range header {
+		h = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))
+		if !known[h] {
+			return nil, fmt.Errorf("csv: unknown column %q; expected some of %s", h, strings.Join(Columns, ", "))
+		}
+		if seen[h] {
+			return nil, fmt.Errorf("csv: column %q appears twice", h)
+		}
+		seen[h] = true
+		columns[i] = h
+	}
+	return &csvDecoder{r: cr, columns: columns}, nil
+}
+
+func (d *csvDecoder) Next(b *models.Book) (int, error) {
+	record, err := d.r.Read()
+	var parseErr *csv.ParseError
+	switch {
+	case err == io.EOF:
+		return 0, io.EOF
+	case errors.As(err, &parseErr):
+		return parseErr.StartLine, &RowError{Line: parseErr.StartLine, Err: parseErr.Err}
+	case err != nil:
+		return 0, err
+	}
+	line, _ := d.r.FieldPos(0)
+	if len(record) != len(d.columns) {
+		return line, &RowError{Line: line, Err: fmt.Errorf("row has %d fields, header has %d", len(record), len(d.columns))}
+	}
+
+	*b = models.Book{}
+	var errs validation.Errors
+	for i, col := range d.columns {
+		v := record[i]
+		switch col {
+		case "name":
+			b.Name = v
+		case "author":
+			b.Author = v
+		case "publication":
+			b.Publication = v
+		case "author_ids":
+			b.AuthorIDs = nil
+			for _, s := range strings.Split(v, ";") {
+				if s = strings.TrimSpace(s); s == "" {
+					continue
+				}
+				id, err := strconv.ParseUint(s, 10, 32)
+				if err != nil || id == 0 {
+					errs.Add(col, "%q is not a positive integer", s)
+					continue
+				}
+				b.AuthorIDs = append(b.AuthorIDs, uint(id))
+			}
+		case "publisher_id":
+			if v = strings.TrimSpace(v); v == "" {
+				continue
+			}
+			id, err := strconv.ParseUint(v, 10, 32)
+			if err != nil || id == 0 {
+				errs.Add(col, "%q is not a positive integer", v)
+				continue
+			}
+			pid := uint(id)
+			b.PublisherID = &pid
+		case "sku":
+			if v = strings.TrimSpace(v); v != "" {
+				b.SKU = &v
+			}
+		case "isbn13":
+			if v = strings.TrimSpace(v); v != "" {
+				b.ISBN13 = &v
+			}
+		case "isbn10":
+			if v = strings.TrimSpace(v); v != "" {
+				b.ISBN10 = &v
+			}
+		case "price_cents":
+			if v = strings.TrimSpace(v); v == "" {
+				continue
+			}
+			price, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				errs.Add(col, "%q is not an integer", v)
+				continue
+			}
+			b.PriceCents = price
+		}
+	}
+	if err := errs.Err(); err != nil {
+		return line, &RowError{Line: line, Err: err}
+	}
+	return line, nil
+}
+
+type csvEncoder struct {
+	w      *csv.Writer
+	record []string
+}
+
+// NewCSVEncoder writes the header row straight away, so an empty export is
+// still a valid file.
+func NewCSVEncoder(w io.Writer) (Encoder, error) {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(Columns); err != nil {
+		return nil, err
+	}
+	return &csvEncoder{w: cw, record: make([]string, len(Columns))}, nil
+}
+
+func (e *csvEncoder) Encode(b models.Book) error {
+	ids := make([]string, len(b.AuthorIDs))
+	for i, id := range b.AuthorIDs {
+		ids[i] = strconv.FormatUint(uint64(id), 10)
+	}
+	publisher := ""
+	if b.PublisherID != nil {
+		publisher = strconv.FormatUint(uint64(*b.PublisherID), 10)
+	}
+	e.record = append(e.record[:0],
+		strconv.FormatUint(uint64(b.ID), 10),
+		b.Name,
+		b.Author,
+		b.Publication,
+		strings.Join(ids, ";"),
+		publisher,
+		optional(b.SKU),
+		optional(b.ISBN13),
+		optional(b.ISBN10),
+		strconv.FormatInt(b.PriceCents, 10),
+		strconv.FormatUint(uint64(b.Version), 10),
+		b.CreatedAt.UTC().Format(time.RFC3339Nano),
+		b.UpdatedAt.UTC().Format(time.RFC3339Nano),
+	)
+	return e.w.Write(e.record)
+}
+
+func optional(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func (e *csvEncoder) Flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
@@ -0,0 +1,70 @@
+package bulk
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/yoloxsta/go-bookstore/pkg/models"
+	"github.com/yoloxsta/go-bookstore/pkg/utils"
+)
+
+// MaxLineBytes caps a single NDJSON line, matching the limit on a single
+// book sent to POST /book/.
+const MaxLineBytes = utils.MaxBodyBytes
+
+type ndjsonDecoder struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+// NewNDJSONDecoder reads one JSON object per line with the same strict rules
+// as the JSON API. Blank lines are skipped.
+func NewNDJSONDecoder(r io.Reader) Decoder {
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 0, 64*1024), MaxLineBytes)
+	return &ndjsonDecoder{scanner: s}
+}
+
+func (d *ndjsonDecoder) Next(b *models.Book) (int, error) {
+	for d.scanner.Scan() {
+		d.line++
+		data := bytes.TrimSpace(d.scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+		*b = models.Book{}
+		if err := utils.DecodeJSON(data, b); err != nil {
+			return d.line, &RowError{Line: d.line, Err: err}
+		}
+		return d.line, nil
+	}
+	if err := d.scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			err = fmt.Errorf("line %d is longer than %d bytes", d.line+1, MaxLineBytes)
+		}
+		return d.line + 1, err
+	}
+	return d.line, io.EOF
+}
+
+type ndjsonEncoder struct {
+	w   *bufio.Writer
+	enc *json.Encoder
+}
+
+func NewNDJSONEncoder(w io.Writer) Encoder {
+	bw := bufio.NewWriter(w)
+	return &ndjsonEncoder{w: bw, enc: json.NewEncoder(bw)}
+}
+
+func (e *ndjsonEncoder) Encode(b models.Book) error {
+	return e.enc.Encode(b)
+}
+
+func (e *ndjsonEncoder) Flush() error {
+	return e.w.Flush()
+}
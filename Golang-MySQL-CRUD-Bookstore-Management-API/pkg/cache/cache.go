@@ -0,0 +1,48 @@
+// Package cache provides the byte caches behind the read-through book cache:
+// an in-process LRU and a client for any server speaking the Redis protocol.
+package cache
+
+import (
+	"errors"
+	"time"
+
+	"github.com/yoloxsta/go-bookstore/pkg/config"
+	"github.com/yoloxsta/go-bookstore/pkg/metrics"
+)
+
+// Cache stores values under string keys. A zero ttl keeps a value until it
+// is deleted or evicted.
+type Cache interface {
+	// Get reports false, with a nil error, when key is missing or expired.
+	Get(key string) ([]byte, bool, error)
+	Set(key string, value []byte, ttl time.Duration) error
+	Delete(keys ...string) error
+	Close() error
+}
+
+// New returns the cache selected by cfg.Driver, which must not be
+// config.CacheNone.
+func New(cfg config.CacheConfig) (Cache, error) {
+	switch cfg.Driver {
+	case config.CacheMemory:
+		return NewLRU(cfg.MaxEntries), nil
+	case config.CacheRedis:
+		return NewRedis(cfg.Redis), nil
+	}
+	return nil, errors.New("cache: unsupported driver " + cfg.Driver)
+}
+
+// Record counts a lookup of the named kind as a hit or a miss.
+func Record(name string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	metrics.CacheLookups.WithLabelValues(name, result).Inc()
+}
+
+// RecordError counts a cache call that failed and fell back to the
+// database.
+func RecordError() {
+	metrics.CacheErrors.Inc()
+}
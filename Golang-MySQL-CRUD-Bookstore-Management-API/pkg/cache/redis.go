@@ -0,0 +1,227 @@
+package cache
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/yoloxsta/go-bookstore/pkg/config"
+)
+
+// Redis is a Cache on a server speaking RESP, the Redis protocol, such as
+// Redis, Valkey or KeyDB. It uses GET, SET with PX, DEL, and AUTH and
+// SELECT when configured. Keys are stored under cfg.Prefix.
+type Redis struct {
+	cfg  config.RedisConfig
+	idle chan *redisConn
+
+	mu     sync.Mutex // guards closed and sends on idle
+	closed bool
+}
+
+type redisConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+// redisError is an error reply from the server. It leaves the connection
+// usable, unlike network and protocol errors.
+type redisError string
+
+func (e redisError) Error() string { return "redis: " + string(e) }
+
+// NewRedis returns a client for cfg.Addr. Connections are opened on demand
+// and up to cfg.PoolSize idle ones are kept for reuse.
+func NewRedis(cfg config.RedisConfig) *Redis {
+	return &Redis{cfg: cfg, idle: make(chan *redisConn, cfg.PoolSize)}
+}
+
+func (c *Redis) Get(key string) ([]byte, bool, error) {
+	reply, err := c.do("GET", c.cfg.Prefix+key)
+	if err != nil {
+		return nil, false, err
+	}
+	if reply == nil {
+		return nil, false, nil
+	}
+	v, ok := reply.([]byte)
+	if !ok {
+		return nil, false, fmt.Errorf("redis: GET replied %T", reply)
+	}
+	return v, true, nil
+}
+
+func (c *Redis) Set(key string, value []byte, ttl time.Duration) error {
+	args := []string{"SET", c.cfg.Prefix + key, string(value)}
+	if ttl > 0 {
+		args = append(args, "PX", strconv.FormatInt(max(ttl.Milliseconds(), 1), 10))
+	}
+	_, err := c.do(args...)
+	return err
+}
+
+func (c *Redis) Delete(keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	args := []string{"DEL"}
+	for _, k := range keys {
+		args = append(args, c.cfg.Prefix+k)
+	}
+	_, err := c.do(args...)
+	return err
+}
+
+// Close closes the idle connections; ones in use are closed when they are
+// returned.
+func (c *Redis) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	for {
+		select {
+		case conn := <-c.idle:
+			conn.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+// do sends one command and reads its reply: nil, string, int64, []byte or
+// []interface{}.
+func (c *Redis) do(args ...string) (interface{}, error) {
+	conn, err := c.conn()
+	if err != nil {
+		return nil, err
+	}
+	reply, err := conn.do(c.cfg.Timeout, args)
+	var replyErr redisError
+	if err != nil && !errors.As(err, &replyErr) {
+		conn.Close()
+		return nil, err
+	}
+	c.put(conn)
+	return reply, err
+}
+
+// put keeps conn for reuse, or closes it when the pool is full or the client
+// has been closed.
+func (c *Redis) put(conn *redisConn) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.closed {
+		select {
+		case c.idle <- conn:
+			return
+		default:
+		}
+	}
+	conn.Close()
+}
+
+func (c *Redis) conn() (*redisConn, error) {
+	select {
+	case conn := <-c.idle:
+		return conn, nil
+	default:
+	}
+	nc, err := net.DialTimeout("tcp", c.cfg.Addr, c.cfg.Timeout)
+	if err != nil {
+		return nil, fmt.Errorf("redis: %w", err)
+	}
+	conn := &redisConn{Conn: nc, r: bufio.NewReader(nc)}
+	if c.cfg.Password != "" {
+		if _, err := conn.do(c.cfg.Timeout, []string{"AUTH", c.cfg.Password}); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	if c.cfg.DB != 0 {
+		if _, err := conn.do(c.cfg.Timeout, []string{"SELECT", strconv.Itoa(c.cfg.DB)}); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+func (conn *redisConn) do(timeout time.Duration, args []string) (interface{}, error) {
+	if timeout > 0 {
+		conn.SetDeadline(time.Now().Add(timeout))
+	}
+	buf := []byte("*" + strconv.Itoa(len(args)) + "\r\n")
+	for _, a := range args {
+		buf = append(buf, '$')
+		buf = strconv.AppendInt(buf, int64(len(a)), 10)
+		buf = append(buf, "\r\n"...)
+		buf = append(buf, a...)
+		buf = append(buf, "\r\n"...)
+	}
+	if _, err := conn.Write(buf); err != nil {
+		return nil, fmt.Errorf("redis: %w", err)
+	}
+	return conn.read()
+}
+
+// read parses one RESP2 reply.
+func (conn *redisConn) read() (interface{}, error) {
+	line, err := conn.r.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("redis: %w", err)
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, fmt.Errorf("redis: malformed reply %q", line)
+	}
+	kind, body := line[0], line[1:len(line)-2]
+	switch kind {
+	case '+':
+		return body, nil
+	case '-':
+		return nil, redisError(body)
+	case ':':
+		n, err := strconv.ParseInt(body, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("redis: malformed integer %q", body)
+		}
+		return n, nil
+	case '$':
+		n, err := strconv.Atoi(body)
+		if err != nil || n < -1 {
+			return nil, fmt.Errorf("redis: malformed bulk length %q", body)
+		}
+		if n == -1 {
+			return nil, nil
+		}
+		v := make([]byte, n+2)
+		if _, err := io.ReadFull(conn.r, v); err != nil {
+			return nil, fmt.Errorf("redis: %w", err)
+		}
+		return v[:n], nil
+	case '*':
+		n, err := strconv.Atoi(body)
+		if err != nil || n < -1 {
+			return nil, fmt.Errorf("redis: malformed array length %q", body)
+		}
+		if n == -1 {
+			return nil, nil
+		}
+		items := make([]interface{}, n)
+		for i := range items {
+			items[i], err = conn.read()
+			var replyErr redisError
+			if errors.As(err, &replyErr) {
+				items[i] = replyErr
+			} else if err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("redis: unknown reply type %q", kind)
+}
@@ -0,0 +1,318 @@
+package cache
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/yoloxsta/go-bookstore/pkg/config"
+)
+
+// fakeRedis serves the part of RESP the client uses on a real listener,
+// keeping values in memory. It counts connections so tests can see the
+// client's pooling.
+type fakeRedis struct {
+	addr     string
+	password string
+	dials    atomic.Int32
+	open     atomic.Int32
+
+	mu       sync.Mutex
+	data     map[string]string
+	commands [][]string
+	failNext string
+	// hold, when set, delays GET replies until it is closed; each held GET
+	// is announced on held first.
+	hold chan struct{}
+	held chan struct{}
+}
+
+func newFakeRedis(t *testing.T) *fakeRedis {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeRedis{addr: lis.Addr().String(), data: map[string]string{}}
+	t.Cleanup(func() { lis.Close() })
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			f.dials.Add(1)
+			f.open.Add(1)
+			go f.serve(conn)
+		}
+	}()
+	return f
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer f.open.Add(-1)
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		if _, err := io.WriteString(conn, f.reply(args)); err != nil {
+			return
+		}
+	}
+}
+
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "*")))
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, n)
+	for i := range args {
+		if line, err = r.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "$")))
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
+
+func (f *fakeRedis) reply(args []string) string {
+	f.mu.Lock()
+	f.commands = append(f.commands, args)
+	hold, held := f.hold, f.held
+	if f.failNext != "" {
+		msg := f.failNext
+		f.failNext = ""
+		f.mu.Unlock()
+		return "-" + msg + "\r\n"
+	}
+	f.mu.Unlock()
+
+	switch strings.ToUpper(args[0]) {
+	case "AUTH":
+		if args[1] != f.password {
+			return "-WRONGPASS invalid password\r\n"
+		}
+		return "+OK\r\n"
+	case "SELECT":
+		return "+OK\r\n"
+	case "GET":
+		if hold != nil {
+			held <- struct{}{}
+			<-hold
+		}
+		f.mu.Lock()
+		v, ok := f.data[args[1]]
+		f.mu.Unlock()
+		if !ok {
+			return "$-1\r\n"
+		}
+		return "$" + strconv.Itoa(len(v)) + "\r\n" + v + "\r\n"
+	case "SET":
+		f.mu.Lock()
+		f.data[args[1]] = args[2]
+		f.mu.Unlock()
+		return "+OK\r\n"
+	case "DEL":
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		n := 0
+		for _, k := range args[1:] {
+			if _, ok := f.data[k]; ok {
+				delete(f.data, k)
+				n++
+			}
+		}
+		return ":" + strconv.Itoa(n) + "\r\n"
+	}
+	return "-ERR unknown command '" + args[0] + "'\r\n"
+}
+
+func (f *fakeRedis) sent() [][]string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([][]string{}, f.commands...)
+}
+
+func (f *fakeRedis) client(t *testing.T, cfg config.RedisConfig) *Redis {
+	t.Helper()
+	cfg.Addr = f.addr
+	cfg.Timeout = time.Second
+	if cfg.PoolSize == 0 {
+		cfg.PoolSize = 2
+	}
+	c := NewRedis(cfg)
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+// waitClosed waits for the fake to see every connection closed.
+func (f *fakeRedis) waitClosed(t *testing.T) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for f.open.Load() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d connections left open", f.open.Load())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestRedisGetSetDelete(t *testing.T) {
+	f := newFakeRedis(t)
+	c := f.client(t, config.RedisConfig{Prefix: "bookstore:"})
+
+	if _, ok, err := c.Get("book:1"); ok || err != nil {
+		t.Fatalf("Get before Set = %v, %v; want a miss", ok, err)
+	}
+	if err := c.Set("book:1", []byte("hello\r\nworld"), 1500*time.Microsecond); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Set("book:2", []byte{}, 0); err != nil {
+		t.Fatal(err)
+	}
+	v, ok, err := c.Get("book:1")
+	if err != nil || !ok || string(v) != "hello\r\nworld" {
+		t.Fatalf("Get = %q, %v, %v", v, ok, err)
+	}
+	if v, ok, err := c.Get("book:2"); err != nil || !ok || len(v) != 0 {
+		t.Fatalf("Get of an empty value = %q, %v, %v", v, ok, err)
+	}
+	if err := c.Delete("book:1", "book:2"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := c.Get("book:1"); ok {
+		t.Error("Get after Delete hit")
+	}
+
+	want := []string{
+		"GET bookstore:book:1",
+		"SET bookstore:book:1 hello\r\nworld PX 1",
+		"SET bookstore:book:2 ",
+		"GET bookstore:book:1",
+		"GET bookstore:book:2",
+		"DEL bookstore:book:1 bookstore:book:2",
+		"GET bookstore:book:1",
+	}
+	sent := f.sent()
+	if len(sent) != len(want) {
+		t.Fatalf("sent %q, want %q", sent, want)
+	}
+	for i, args := range sent {
+		if got := strings.Join(args, " "); got != want[i] {
+			t.Errorf("command %d = %q, want %q", i, got, want[i])
+		}
+	}
+	if n := f.dials.Load(); n != 1 {
+		t.Errorf("dialled %d connections for sequential commands, want 1", n)
+	}
+}
+
+func TestRedisAuthAndSelect(t *testing.T) {
+	f := newFakeRedis(t)
+	f.password = "s3cret"
+
+	c := f.client(t, config.RedisConfig{Password: "s3cret", DB: 3})
+	if _, _, err := c.Get("k"); err != nil {
+		t.Fatal(err)
+	}
+	sent := f.sent()
+	if len(sent) != 3 || strings.Join(sent[0], " ") != "AUTH s3cret" || strings.Join(sent[1], " ") != "SELECT 3" {
+		t.Fatalf("sent %q, want AUTH and SELECT before GET", sent)
+	}
+
+	wrong := f.client(t, config.RedisConfig{Password: "guess"})
+	var replyErr redisError
+	if _, _, err := wrong.Get("k"); !errors.As(err, &replyErr) {
+		t.Fatalf("Get with a wrong password = %v, want a redis error", err)
+	}
+	wrong.Close()
+	c.Close()
+	f.waitClosed(t)
+}
+
+func TestRedisErrorReplyKeepsTheConnection(t *testing.T) {
+	f := newFakeRedis(t)
+	c := f.client(t, config.RedisConfig{})
+
+	f.mu.Lock()
+	f.failNext = "ERR out of memory"
+	f.mu.Unlock()
+	var replyErr redisError
+	if err := c.Set("k", []byte("v"), 0); !errors.As(err, &replyErr) || !strings.Contains(err.Error(), "out of memory") {
+		t.Fatalf("Set = %v, want the server's error", err)
+	}
+	if err := c.Set("k", []byte("v"), 0); err != nil {
+		t.Fatal(err)
+	}
+	if n := f.dials.Load(); n != 1 {
+		t.Errorf("dialled %d connections, want the first one reused", n)
+	}
+}
+
+func TestRedisServerGoneIsAnError(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := lis.Addr().String()
+	lis.Close()
+	c := NewRedis(config.RedisConfig{Addr: addr, Timeout: time.Second, PoolSize: 1})
+	if _, _, err := c.Get("k"); err == nil {
+		t.Fatal("Get without a server succeeded")
+	}
+}
+
+func TestRedisCloseClosesConnectionsInUse(t *testing.T) {
+	f := newFakeRedis(t)
+	c := f.client(t, config.RedisConfig{})
+	if err := c.Set("k", []byte("v"), 0); err != nil {
+		t.Fatal(err)
+	}
+
+	f.mu.Lock()
+	f.hold, f.held = make(chan struct{}), make(chan struct{}, 1)
+	f.mu.Unlock()
+	done := make(chan error)
+	go func() {
+		_, _, err := c.Get("k")
+		done <- err
+	}()
+	<-f.held
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+	close(f.hold)
+	if err := <-done; err != nil {
+		t.Fatalf("Get in flight during Close = %v", err)
+	}
+	f.waitClosed(t)
+
+	// A command after Close still works, without pooling its connection.
+	if err := c.Set("k", []byte("v"), 0); err != nil {
+		t.Fatal(err)
+	}
+	f.waitClosed(t)
+}
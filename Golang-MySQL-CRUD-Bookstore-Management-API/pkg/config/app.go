@@ -1,22 +1,40 @@
 package config
 
 import (
+	"fmt"
+
 	"github.com/jinzhu/gorm"
 	_ "github.com/jinzhu/gorm/dialects/mysql"
+	_ "github.com/jinzhu/gorm/dialects/sqlite"
 )
 
-var (
-	db *gorm.DB
-)
-
-func Connect() {
-	d, err := gorm.Open("mysql", "root:root@tcp(127.0.0.1:3306)/bookstore?charset=utf8&parseTime=True&loc=Local")
-	if err != nil {
-		panic(err)
+// Connect opens the SQL database described by cfg. It is an error to call it
+// with the memory driver, which has no connection.
+func Connect(cfg DatabaseConfig) (*gorm.DB, error) {
+	var (
+		d   *gorm.DB
+		err error
+	)
+	switch cfg.Driver {
+	case DriverMySQL:
+		d, err = gorm.Open("mysql", cfg.DSN())
+		if err != nil {
+			return nil, fmt.Errorf("config: connecting to %s:%d/%s: %w", cfg.Host, cfg.Port, cfg.Name, err)
+		}
+	case DriverSQLite:
+		d, err = gorm.Open("sqlite3", cfg.Path)
+		if err != nil {
+			return nil, fmt.Errorf("config: opening %s: %w", cfg.Path, err)
+		}
+	default:
+		return nil, fmt.Errorf("config: driver %q has no SQL connection", cfg.Driver)
 	}
-	db = d
-}
-
-func GetDB() *gorm.DB {
-	return db
+	d.DB().SetMaxOpenConns(cfg.MaxOpenConns)
+	if cfg.Driver == DriverSQLite && cfg.Path == ":memory:" {
+		// Every new connection would otherwise see its own empty database.
+		d.DB().SetMaxOpenConns(1)
+	}
+	d.DB().SetMaxIdleConns(cfg.MaxIdleConns)
+	d.DB().SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	return d, nil
 }
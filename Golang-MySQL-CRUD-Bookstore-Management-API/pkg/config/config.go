@@ -0,0 +1,629 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Config holds everything the bookstore needs to start. Values come from
+// defaults, then an optional YAML or TOML file, then BOOKSTORE_* environment
+// variables.
+type Config struct {
+	Server    ServerConfig    `yaml:"server" toml:"server"`
+	GRPC      GRPCConfig      `yaml:"grpc" toml:"grpc"`
+	Database  DatabaseConfig  `yaml:"database" toml:"database"`
+	Trash     TrashConfig     `yaml:"trash" toml:"trash"`
+	Inventory InventoryConfig `yaml:"inventory" toml:"inventory"`
+	Payment   PaymentConfig   `yaml:"payment" toml:"payment"`
+	Storage   StorageConfig   `yaml:"storage" toml:"storage"`
+	Covers    CoverConfig     `yaml:"covers" toml:"covers"`
+	Auth      AuthConfig      `yaml:"auth" toml:"auth"`
+	Cache     CacheConfig     `yaml:"cache" toml:"cache"`
+	Reviews   ReviewConfig    `yaml:"reviews" toml:"reviews"`
+	Log       LogConfig       `yaml:"log" toml:"log"`
+}
+
+// Supported values for LogConfig.Format.
+const (
+	LogJSON = "json"
+	LogText = "text"
+)
+
+// LogConfig sets how the server logs. Every line of a request, including
+// its access log line, carries the request's X-Request-ID as request_id.
+type LogConfig struct {
+	Format string `yaml:"format" toml:"format"`
+	// Level is the lowest level logged: debug, info, warn or error.
+	Level string `yaml:"level" toml:"level"`
+}
+
+// ReviewConfig controls moderation of book reviews. Without AutoApprove new
+// reviews wait, unpublished, for an editor to approve them.
+type ReviewConfig struct {
+	AutoApprove bool `yaml:"auto_approve" toml:"auto_approve"`
+}
+
+// Supported values for CacheConfig.Driver.
+const (
+	CacheNone   = "none"
+	CacheMemory = "memory"
+	CacheRedis  = "redis"
+)
+
+// CacheConfig sets up the read-through cache in front of book lookups and
+// listings. Each server process has its own memory cache, so when several
+// run behind a load balancer use redis, or accept that a change may take up
+// to TTL to show on the others.
+type CacheConfig struct {
+	Driver string        `yaml:"driver" toml:"driver"`
+	TTL    time.Duration `yaml:"ttl" toml:"ttl"`
+	// MaxEntries bounds the memory driver.
+	MaxEntries int `yaml:"max_entries" toml:"max_entries"`
+	// MaxAge is sent as the Cache-Control max-age of book reads. Zero asks
+	// clients to revalidate with the ETag every time.
+	MaxAge time.Duration `yaml:"max_age" toml:"max_age"`
+	Redis  RedisConfig   `yaml:"redis" toml:"redis"`
+}
+
+// RedisConfig points the redis cache driver at any server speaking the Redis
+// protocol.
+type RedisConfig struct {
+	Addr     string `yaml:"addr" toml:"addr"`
+	Password string `yaml:"password" toml:"password"`
+	DB       int    `yaml:"db" toml:"db"`
+	// Prefix is prepended to every key, so several deployments can share a
+	// server.
+	Prefix string `yaml:"prefix" toml:"prefix"`
+	// Timeout bounds dialling and each command.
+	Timeout  time.Duration `yaml:"timeout" toml:"timeout"`
+	PoolSize int           `yaml:"pool_size" toml:"pool_size"`
+}
+
+// AuthNone turns authentication off, leaving every endpoint open.
+const AuthNone = "none"
+
+// AuthConfig sets how bearer tokens are verified. Algorithm is one of HS256,
+// HS384, HS512 (signed with Secret), RS256, RS384 or RS512 (checked against
+// the PEM key in PublicKeyFile), or "none".
+type AuthConfig struct {
+	Algorithm     string `yaml:"algorithm" toml:"algorithm"`
+	Secret        string `yaml:"secret" toml:"secret"`
+	PublicKeyFile string `yaml:"public_key_file" toml:"public_key_file"`
+	// Issuer and Audience, when set, must match the token's iss and aud.
+	Issuer   string `yaml:"issuer" toml:"issuer"`
+	Audience string `yaml:"audience" toml:"audience"`
+	// Leeway tolerates clock skew when checking exp and nbf.
+	Leeway time.Duration `yaml:"leeway" toml:"leeway"`
+}
+
+// Supported values for StorageConfig.Driver.
+const (
+	StorageLocal = "local"
+	StorageS3    = "s3"
+)
+
+// StorageConfig picks where uploaded blobs such as cover images are kept.
+type StorageConfig struct {
+	Driver string `yaml:"driver" toml:"driver"`
+	// Dir is the root directory of the local driver.
+	Dir string   `yaml:"dir" toml:"dir"`
+	S3  S3Config `yaml:"s3" toml:"s3"`
+}
+
+// S3Config points the s3 driver at AWS or any S3-compatible service.
+type S3Config struct {
+	// Endpoint is the service URL, e.g. https://s3.eu-west-1.amazonaws.com.
+	Endpoint  string `yaml:"endpoint" toml:"endpoint"`
+	Region    string `yaml:"region" toml:"region"`
+	Bucket    string `yaml:"bucket" toml:"bucket"`
+	AccessKey string `yaml:"access_key" toml:"access_key"`
+	SecretKey string `yaml:"secret_key" toml:"secret_key"`
+	// PathStyle addresses objects as endpoint/bucket/key rather than
+	// bucket.endpoint/key; most self-hosted services need it.
+	PathStyle bool `yaml:"path_style" toml:"path_style"`
+}
+
+// CoverConfig limits cover image uploads.
+type CoverConfig struct {
+	MaxBytes int `yaml:"max_bytes" toml:"max_bytes"`
+}
+
+// PaymentConfig picks the payment provider for orders. Only the offline
+// "fake" provider is built in.
+type PaymentConfig struct {
+	Provider string `yaml:"provider" toml:"provider"`
+	// Currency is the ISO 4217 code that book prices and orders are in.
+	Currency string `yaml:"currency" toml:"currency"`
+}
+
+// InventoryConfig holds defaults for books that do not set their own.
+type InventoryConfig struct {
+	LowStockThreshold int `yaml:"low_stock_threshold" toml:"low_stock_threshold"`
+}
+
+// TrashConfig controls how long soft-deleted books are kept. A zero
+// Retention keeps them forever.
+type TrashConfig struct {
+	Retention     time.Duration `yaml:"retention" toml:"retention"`
+	PurgeInterval time.Duration `yaml:"purge_interval" toml:"purge_interval"`
+}
+
+type ServerConfig struct {
+	Addr string `yaml:"addr" toml:"addr"`
+	// AdminAddr serves /metrics apart from the API, so the public listener
+	// never exposes them. Empty turns it off.
+	AdminAddr string `yaml:"admin_addr" toml:"admin_addr"`
+
+	ReadTimeout  time.Duration `yaml:"read_timeout" toml:"read_timeout"`
+	WriteTimeout time.Duration `yaml:"write_timeout" toml:"write_timeout"`
+	IdleTimeout  time.Duration `yaml:"idle_timeout" toml:"idle_timeout"`
+	// DrainDelay is how long the server keeps serving, while reporting
+	// itself not ready, after a shutdown signal so load balancers can stop
+	// routing to it. ShutdownTimeout then bounds how long in-flight requests
+	// may take to finish.
+	DrainDelay      time.Duration `yaml:"drain_delay" toml:"drain_delay"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout"`
+}
+
+// GRPCConfig sets up the gRPC BookService, served next to the HTTP API. An
+// empty Addr turns it off.
+type GRPCConfig struct {
+	Addr string `yaml:"addr" toml:"addr"`
+	// WatchInterval is how often WatchBooks streams poll for new changes.
+	WatchInterval time.Duration `yaml:"watch_interval" toml:"watch_interval"`
+}
+
+// Supported values for DatabaseConfig.Driver.
+const (
+	DriverMySQL  = "mysql"
+	DriverSQLite = "sqlite3"
+	DriverMemory = "memory"
+)
+
+type DatabaseConfig struct {
+	Driver string `yaml:"driver" toml:"driver"`
+	// Path is the SQLite database file; ":memory:" keeps it in RAM.
+	Path string `yaml:"path" toml:"path"`
+
+	User     string `yaml:"user" toml:"user"`
+	Password string `yaml:"password" toml:"password"`
+	Host     string `yaml:"host" toml:"host"`
+	Port     int    `yaml:"port" toml:"port"`
+	Name     string `yaml:"name" toml:"name"`
+	Params   string `yaml:"params" toml:"params"`
+
+	MaxOpenConns    int           `yaml:"max_open_conns" toml:"max_open_conns"`
+	MaxIdleConns    int           `yaml:"max_idle_conns" toml:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" toml:"conn_max_lifetime"`
+
+	ConnectTimeout time.Duration `yaml:"connect_timeout" toml:"connect_timeout"`
+	ReadTimeout    time.Duration `yaml:"read_timeout" toml:"read_timeout"`
+	WriteTimeout   time.Duration `yaml:"write_timeout" toml:"write_timeout"`
+}
+
+func Default() Config {
+	return Config{
+		Server: ServerConfig{
+			Addr:            ":9010",
+			AdminAddr:       "127.0.0.1:9011",
+			ReadTimeout:     30 * time.Second,
+			WriteTimeout:    60 * time.Second,
+			IdleTimeout:     2 * time.Minute,
+			ShutdownTimeout: 30 * time.Second,
+		},
+		GRPC: GRPCConfig{
+			Addr:          ":9090",
+			WatchInterval: time.Second,
+		},
+		Database: DatabaseConfig{
+			Driver:          DriverMySQL,
+			Path:            "bookstore.db",
+			User:            "root",
+			Password:        "root",
+			Host:            "127.0.0.1",
+			Port:            3306,
+			Name:            "bookstore",
+			Params:          "charset=utf8&parseTime=True&loc=Local",
+			MaxOpenConns:    10,
+			MaxIdleConns:    5,
+			ConnMaxLifetime: time.Hour,
+			ConnectTimeout:  5 * time.Second,
+			ReadTimeout:     30 * time.Second,
+			WriteTimeout:    30 * time.Second,
+		},
+		Trash: TrashConfig{
+			Retention:     30 * 24 * time.Hour,
+			PurgeInterval: time.Hour,
+		},
+		Inventory: InventoryConfig{
+			LowStockThreshold: 5,
+		},
+		Payment: PaymentConfig{
+			Provider: "fake",
+			Currency: "USD",
+		},
+		Storage: StorageConfig{
+			Driver: StorageLocal,
+			Dir:    "data",
+			S3: S3Config{
+				Region:    "us-east-1",
+				PathStyle: true,
+			},
+		},
+		Covers: CoverConfig{
+			MaxBytes: 5 << 20,
+		},
+		Auth: AuthConfig{
+			Algorithm: "HS256",
+			Leeway:    time.Minute,
+		},
+		Cache: CacheConfig{
+			Driver:     CacheMemory,
+			TTL:        30 * time.Second,
+			MaxEntries: 10000,
+			Redis: RedisConfig{
+				Addr:     "127.0.0.1:6379",
+				Prefix:   "bookstore:",
+				Timeout:  time.Second,
+				PoolSize: 10,
+			},
+		},
+		Log: LogConfig{
+			Format: LogJSON,
+			Level:  "info",
+		},
+	}
+}
+
+// Load builds a Config from the defaults, the file at path (skipped when
+// path is empty) and the environment, and validates the result. A path
+// ending in .toml is read as TOML, anything else as YAML.
+func Load(path string) (Config, error) {
+	cfg := Default()
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return cfg, fmt.Errorf("config: reading %s: %w", path, err)
+		}
+		if strings.EqualFold(filepath.Ext(path), ".toml") {
+			err = toml.Unmarshal(data, &cfg)
+		} else {
+			err = yaml.Unmarshal(data, &cfg)
+		}
+		if err != nil {
+			return cfg, fmt.Errorf("config: parsing %s: %w", path, err)
+		}
+	}
+	if err := cfg.applyEnv(); err != nil {
+		return cfg, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return cfg, err
+	}
+	return cfg, nil
+}
+
+func (c *Config) applyEnv() error {
+	var errs []error
+	str := func(key string, dst *string) {
+		if v, ok := os.LookupEnv(key); ok {
+			*dst = v
+		}
+	}
+	num := func(key string, dst *int) {
+		if v, ok := os.LookupEnv(key); ok {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("config: %s: %q is not an integer", key, v))
+				return
+			}
+			*dst = n
+		}
+	}
+	boolean := func(key string, dst *bool) {
+		if v, ok := os.LookupEnv(key); ok {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("config: %s: %q is not a boolean", key, v))
+				return
+			}
+			*dst = b
+		}
+	}
+	dur := func(key string, dst *time.Duration) {
+		if v, ok := os.LookupEnv(key); ok {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("config: %s: %q is not a duration", key, v))
+				return
+			}
+			*dst = d
+		}
+	}
+
+	str("BOOKSTORE_ADDR", &c.Server.Addr)
+	str("BOOKSTORE_ADMIN_ADDR", &c.Server.AdminAddr)
+	dur("BOOKSTORE_READ_TIMEOUT", &c.Server.ReadTimeout)
+	dur("BOOKSTORE_WRITE_TIMEOUT", &c.Server.WriteTimeout)
+	dur("BOOKSTORE_IDLE_TIMEOUT", &c.Server.IdleTimeout)
+	dur("BOOKSTORE_DRAIN_DELAY", &c.Server.DrainDelay)
+	dur("BOOKSTORE_SHUTDOWN_TIMEOUT", &c.Server.ShutdownTimeout)
+
+	str("BOOKSTORE_GRPC_ADDR", &c.GRPC.Addr)
+	dur("BOOKSTORE_GRPC_WATCH_INTERVAL", &c.GRPC.WatchInterval)
+
+	str("BOOKSTORE_DB_DRIVER", &c.Database.Driver)
+	str("BOOKSTORE_DB_PATH", &c.Database.Path)
+	str("BOOKSTORE_DB_USER", &c.Database.User)
+	str("BOOKSTORE_DB_PASSWORD", &c.Database.Password)
+	str("BOOKSTORE_DB_HOST", &c.Database.Host)
+	num("BOOKSTORE_DB_PORT", &c.Database.Port)
+	str("BOOKSTORE_DB_NAME", &c.Database.Name)
+	str("BOOKSTORE_DB_PARAMS", &c.Database.Params)
+	num("BOOKSTORE_DB_MAX_OPEN_CONNS", &c.Database.MaxOpenConns)
+	num("BOOKSTORE_DB_MAX_IDLE_CONNS", &c.Database.MaxIdleConns)
+	dur("BOOKSTORE_DB_CONN_MAX_LIFETIME", &c.Database.ConnMaxLifetime)
+	dur("BOOKSTORE_DB_CONNECT_TIMEOUT", &c.Database.ConnectTimeout)
+	dur("BOOKSTORE_DB_READ_TIMEOUT", &c.Database.ReadTimeout)
+	dur("BOOKSTORE_DB_WRITE_TIMEOUT", &c.Database.WriteTimeout)
+
+	dur("BOOKSTORE_TRASH_RETENTION", &c.Trash.Retention)
+	dur("BOOKSTORE_TRASH_PURGE_INTERVAL", &c.Trash.PurgeInterval)
+
+	num("BOOKSTORE_LOW_STOCK_THRESHOLD", &c.Inventory.LowStockThreshold)
+
+	str("BOOKSTORE_PAYMENT_PROVIDER", &c.Payment.Provider)
+	str("BOOKSTORE_CURRENCY", &c.Payment.Currency)
+
+	str("BOOKSTORE_STORAGE_DRIVER", &c.Storage.Driver)
+	str("BOOKSTORE_STORAGE_DIR", &c.Storage.Dir)
+	str("BOOKSTORE_S3_ENDPOINT", &c.Storage.S3.Endpoint)
+	str("BOOKSTORE_S3_REGION", &c.Storage.S3.Region)
+	str("BOOKSTORE_S3_BUCKET", &c.Storage.S3.Bucket)
+	str("BOOKSTORE_S3_ACCESS_KEY", &c.Storage.S3.AccessKey)
+	str("BOOKSTORE_S3_SECRET_KEY", &c.Storage.S3.SecretKey)
+	boolean("BOOKSTORE_S3_PATH_STYLE", &c.Storage.S3.PathStyle)
+
+	num("BOOKSTORE_COVER_MAX_BYTES", &c.Covers.MaxBytes)
+
+	str("BOOKSTORE_AUTH_ALGORITHM", &c.Auth.Algorithm)
+	str("BOOKSTORE_AUTH_SECRET", &c.Auth.Secret)
+	str("BOOKSTORE_AUTH_PUBLIC_KEY_FILE", &c.Auth.PublicKeyFile)
+	str("BOOKSTORE_AUTH_ISSUER", &c.Auth.Issuer)
+	str("BOOKSTORE_AUTH_AUDIENCE", &c.Auth.Audience)
+	dur("BOOKSTORE_AUTH_LEEWAY", &c.Auth.Leeway)
+
+	str("BOOKSTORE_CACHE_DRIVER", &c.Cache.Driver)
+	dur("BOOKSTORE_CACHE_TTL", &c.Cache.TTL)
+	num("BOOKSTORE_CACHE_MAX_ENTRIES", &c.Cache.MaxEntries)
+	dur("BOOKSTORE_CACHE_MAX_AGE", &c.Cache.MaxAge)
+	str("BOOKSTORE_REDIS_ADDR", &c.Cache.Redis.Addr)
+	str("BOOKSTORE_REDIS_PASSWORD", &c.Cache.Redis.Password)
+	num("BOOKSTORE_REDIS_DB", &c.Cache.Redis.DB)
+	str("BOOKSTORE_REDIS_PREFIX", &c.Cache.Redis.Prefix)
+	dur("BOOKSTORE_REDIS_TIMEOUT", &c.Cache.Redis.Timeout)
+	num("BOOKSTORE_REDIS_POOL_SIZE", &c.Cache.Redis.PoolSize)
+
+	boolean("BOOKSTORE_REVIEWS_AUTO_APPROVE", &c.Reviews.AutoApprove)
+
+	str("BOOKSTORE_LOG_FORMAT", &c.Log.Format)
+	str("BOOKSTORE_LOG_LEVEL", &c.Log.Level)
+
+	return errors.Join(errs...)
+}
+
+var currencyPattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// Validate reports every invalid field at once rather than stopping at the first.
+func (c Config) Validate() error {
+	var errs []error
+	bad := func(field, format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf("config: %s: %s", field, fmt.Sprintf(format, args...)))
+	}
+
+	if _, _, err := net.SplitHostPort(c.Server.Addr); err != nil {
+		bad("server.addr", "%q is not a host:port address", c.Server.Addr)
+	}
+	if c.Server.AdminAddr != "" {
+		if _, _, err := net.SplitHostPort(c.Server.AdminAddr); err != nil {
+			bad("server.admin_addr", "%q is not a host:port address", c.Server.AdminAddr)
+		} else if c.Server.AdminAddr == c.Server.Addr {
+			bad("server.admin_addr", "must differ from server.addr")
+		}
+	}
+	if c.Server.ReadTimeout < 0 || c.Server.WriteTimeout < 0 || c.Server.IdleTimeout < 0 {
+		bad("server timeouts", "must not be negative")
+	}
+	if c.Server.DrainDelay < 0 {
+		bad("server.drain_delay", "must not be negative")
+	}
+	if c.Server.ShutdownTimeout <= 0 {
+		bad("server.shutdown_timeout", "must be positive")
+	}
+
+	if c.GRPC.Addr != "" {
+		if _, _, err := net.SplitHostPort(c.GRPC.Addr); err != nil {
+			bad("grpc.addr", "%q is not a host:port address", c.GRPC.Addr)
+		}
+		if c.GRPC.WatchInterval <= 0 {
+			bad("grpc.watch_interval", "must be positive")
+		}
+	}
+
+	d := c.Database
+	switch d.Driver {
+	case DriverMySQL:
+		if d.User == "" {
+			bad("database.user", "must not be empty")
+		}
+		if d.Host == "" {
+			bad("database.host", "must not be empty")
+		}
+		if d.Port <= 0 || d.Port > 65535 {
+			bad("database.port", "%d is out of range", d.Port)
+		}
+		if d.Name == "" {
+			bad("database.name", "must not be empty")
+		}
+		if strings.ContainsAny(d.Params, "?/") {
+			bad("database.params", "%q must be a bare query string like charset=utf8", d.Params)
+		}
+	case DriverSQLite:
+		if d.Path == "" {
+			bad("database.path", "must not be empty")
+		}
+	case DriverMemory:
+	default:
+		bad("database.driver", "%q is not one of %s, %s, %s", d.Driver, DriverMySQL, DriverSQLite, DriverMemory)
+	}
+	if d.MaxOpenConns < 0 {
+		bad("database.max_open_conns", "must not be negative")
+	}
+	if d.MaxIdleConns < 0 {
+		bad("database.max_idle_conns", "must not be negative")
+	}
+	if d.MaxOpenConns > 0 && d.MaxIdleConns > d.MaxOpenConns {
+		bad("database.max_idle_conns", "%d exceeds max_open_conns %d", d.MaxIdleConns, d.MaxOpenConns)
+	}
+	if d.ConnMaxLifetime < 0 {
+		bad("database.conn_max_lifetime", "must not be negative")
+	}
+	if d.ConnectTimeout < 0 || d.ReadTimeout < 0 || d.WriteTimeout < 0 {
+		bad("database timeouts", "must not be negative")
+	}
+
+	if c.Trash.Retention < 0 {
+		bad("trash.retention", "must not be negative")
+	}
+	if c.Trash.Retention > 0 && c.Trash.PurgeInterval <= 0 {
+		bad("trash.purge_interval", "must be positive when retention is set")
+	}
+
+	if c.Inventory.LowStockThreshold < 0 {
+		bad("inventory.low_stock_threshold", "must not be negative")
+	}
+
+	if c.Payment.Provider == "" {
+		bad("payment.provider", "must not be empty")
+	}
+	if !currencyPattern.MatchString(c.Payment.Currency) {
+		bad("payment.currency", "%q is not a three-letter ISO 4217 code", c.Payment.Currency)
+	}
+
+	switch s := c.Storage; s.Driver {
+	case StorageLocal:
+		if s.Dir == "" {
+			bad("storage.dir", "must not be empty")
+		}
+	case StorageS3:
+		if u, err := url.Parse(s.S3.Endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			bad("storage.s3.endpoint", "%q is not an http(s) URL", s.S3.Endpoint)
+		}
+		if s.S3.Region == "" {
+			bad("storage.s3.region", "must not be empty")
+		}
+		if s.S3.Bucket == "" {
+			bad("storage.s3.bucket", "must not be empty")
+		}
+		if s.S3.AccessKey == "" || s.S3.SecretKey == "" {
+			bad("storage.s3", "access_key and secret_key must be set")
+		}
+	default:
+		bad("storage.driver", "%q is not one of %s, %s", s.Driver, StorageLocal, StorageS3)
+	}
+
+	if c.Covers.MaxBytes <= 0 {
+		bad("covers.max_bytes", "must be positive")
+	}
+
+	switch a := c.Auth; a.Algorithm {
+	case AuthNone:
+	case "HS256", "HS384", "HS512":
+		if len(a.Secret) < 32 {
+			bad("auth.secret", "must be at least 32 bytes for %s", a.Algorithm)
+		}
+	case "RS256", "RS384", "RS512":
+		if a.PublicKeyFile == "" {
+			bad("auth.public_key_file", "must be set for %s", a.Algorithm)
+		}
+	default:
+		bad("auth.algorithm", "%q is not one of HS256, HS384, HS512, RS256, RS384, RS512, %s", a.Algorithm, AuthNone)
+	}
+	if c.Auth.Leeway < 0 {
+		bad("auth.leeway", "must not be negative")
+	}
+
+	switch ch := c.Cache; ch.Driver {
+	case CacheNone:
+	case CacheMemory:
+		if ch.MaxEntries <= 0 {
+			bad("cache.max_entries", "must be positive")
+		}
+	case CacheRedis:
+		if _, _, err := net.SplitHostPort(ch.Redis.Addr); err != nil {
+			bad("cache.redis.addr", "%q is not a host:port address", ch.Redis.Addr)
+		}
+		if ch.Redis.DB < 0 {
+			bad("cache.redis.db", "must not be negative")
+		}
+		if ch.Redis.Timeout <= 0 {
+			bad("cache.redis.timeout", "must be positive")
+		}
+		if ch.Redis.PoolSize <= 0 {
+			bad("cache.redis.pool_size", "must be positive")
+		}
+	default:
+		bad("cache.driver", "%q is not one of %s, %s, %s", ch.Driver, CacheMemory, CacheRedis, CacheNone)
+	}
+	if c.Cache.Driver != CacheNone && c.Cache.TTL <= 0 {
+		bad("cache.ttl", "must be positive")
+	}
+	if c.Cache.MaxAge < 0 {
+		bad("cache.max_age", "must not be negative")
+	}
+
+	if c.Log.Format != LogJSON && c.Log.Format != LogText {
+		bad("log.format", "%q is not one of %s, %s", c.Log.Format, LogJSON, LogText)
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.Log.Level)); err != nil {
+		bad("log.level", "%q is not one of debug, info, warn, error", c.Log.Level)
+	}
+
+	return errors.Join(errs...)
+}
+
+// DSN renders the go-sql-driver/mysql connection string.
+func (d DatabaseConfig) DSN() string {
+	params := d.Params
+	add := func(key string, v time.Duration) {
+		if v <= 0 {
+			return
+		}
+		if params != "" {
+			params += "&"
+		}
+		params += key + "=" + v.String()
+	}
+	add("timeout", d.ConnectTimeout)
+	add("readTimeout", d.ReadTimeout)
+	add("writeTimeout", d.WriteTimeout)
+
+	dsn := fmt.Sprintf("%s:%s@tcp(%s)/%s", d.User, d.Password, net.JoinHostPort(d.Host, strconv.Itoa(d.Port)), d.Name)
+	if params != "" {
+		dsn += "?" + params
+	}
+	return dsn
+}
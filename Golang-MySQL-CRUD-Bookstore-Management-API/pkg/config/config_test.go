@@ -0,0 +1,206 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+// validConfig is Default with the one setting it cannot default: the HS256
+// secret.
+func validConfig() Config {
+	c := Default()
+	c.Auth.Secret = testSecret
+	return c
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestExampleFilesAgree(t *testing.T) {
+	t.Setenv("BOOKSTORE_AUTH_SECRET", testSecret)
+	fromYAML, err := Load("../../config.example.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromTOML, err := Load("../../config.example.toml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(fromYAML, fromTOML) {
+		t.Fatalf("config.example.toml differs from config.example.yaml:\n%+v\n%+v", fromTOML, fromYAML)
+	}
+	if fromTOML.Trash.Retention != 720*time.Hour || fromTOML.Storage.S3.Bucket != "bookstore-covers" {
+		t.Fatalf("TOML durations or nested tables were not read: %+v", fromTOML)
+	}
+}
+
+func TestLoadLayersFileThenEnvironment(t *testing.T) {
+	for name, content := range map[string]string{
+		"bookstore.toml": "[server]\naddr = \":8080\"\nread_timeout = \"5s\"\n\n[database]\ndriver = \"sqlite3\"\nport = 3307\n",
+		"bookstore.yaml": "server:\n  addr: \":8080\"\n  read_timeout: 5s\ndatabase:\n  driver: sqlite3\n  port: 3307\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv("BOOKSTORE_AUTH_SECRET", testSecret)
+			t.Setenv("BOOKSTORE_DB_PORT", "3308")
+			cfg, err := Load(writeFile(t, name, content))
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := validConfig()
+			want.Server.Addr, want.Server.ReadTimeout = ":8080", 5*time.Second
+			want.Database.Driver, want.Database.Port = DriverSQLite, 3308
+			if !reflect.DeepEqual(cfg, want) {
+				t.Fatalf("Load = %+v\nwant %+v", cfg, want)
+			}
+		})
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{"missing file", filepath.Join(t.TempDir(), "nope.yaml"), "config: reading"},
+		{"bad TOML", writeFile(t, "bad.toml", "[server\naddr = 1"), "config: parsing"},
+		{"TOML read as YAML", writeFile(t, "bad.yml", "[server]\naddr = \":1\"\n"), "config: parsing"},
+		{"bad duration", writeFile(t, "bad-duration.toml", "[server]\nread_timeout = \"soon\"\n"), "config: parsing"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Load(tt.path); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("Load = %v, want an error containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyEnv(t *testing.T) {
+	env := map[string]string{
+		"BOOKSTORE_ADDR":                 "127.0.0.1:9999",
+		"BOOKSTORE_ADMIN_ADDR":           "",
+		"BOOKSTORE_GRPC_ADDR":            "",
+		"BOOKSTORE_DB_DRIVER":            DriverMemory,
+		"BOOKSTORE_DB_MAX_OPEN_CONNS":    "30",
+		"BOOKSTORE_DB_CONN_MAX_LIFETIME": "90s",
+		"BOOKSTORE_S3_PATH_STYLE":        "false",
+		"BOOKSTORE_AUTH_ALGORITHM":       "RS256",
+		"BOOKSTORE_AUTH_PUBLIC_KEY_FILE": "/etc/bookstore/jwt.pem",
+		"BOOKSTORE_CACHE_DRIVER":         CacheNone,
+		"BOOKSTORE_REVIEWS_AUTO_APPROVE": "true",
+		"BOOKSTORE_LOG_LEVEL":            "debug",
+	}
+	for k, v := range env {
+		t.Setenv(k, v)
+	}
+	cfg := Default()
+	if err := cfg.applyEnv(); err != nil {
+		t.Fatal(err)
+	}
+	want := Default()
+	want.Server.Addr, want.Server.AdminAddr, want.GRPC.Addr = "127.0.0.1:9999", "", ""
+	want.Database.Driver, want.Database.MaxOpenConns, want.Database.ConnMaxLifetime = DriverMemory, 30, 90*time.Second
+	want.Storage.S3.PathStyle = false
+	want.Auth.Algorithm, want.Auth.PublicKeyFile = "RS256", "/etc/bookstore/jwt.pem"
+	want.Cache.Driver = CacheNone
+	want.Reviews.AutoApprove = true
+	want.Log.Level = "debug"
+	if !reflect.DeepEqual(cfg, want) {
+		t.Fatalf("applyEnv = %+v\nwant %+v", cfg, want)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+}
+
+func TestApplyEnvReportsEveryBadValue(t *testing.T) {
+	t.Setenv("BOOKSTORE_DB_PORT", "mysql")
+	t.Setenv("BOOKSTORE_READ_TIMEOUT", "30")
+	t.Setenv("BOOKSTORE_S3_PATH_STYLE", "sometimes")
+	cfg := Default()
+	err := cfg.applyEnv()
+	if err == nil {
+		t.Fatal("applyEnv accepted bad values")
+	}
+	for _, want := range []string{
+		`BOOKSTORE_DB_PORT: "mysql" is not an integer`,
+		`BOOKSTORE_READ_TIMEOUT: "30" is not a duration`,
+		`BOOKSTORE_S3_PATH_STYLE: "sometimes" is not a boolean`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(c *Config)
+		want   []string
+	}{
+		{"valid", func(c *Config) {}, nil},
+		// The default algorithm is HS256, which has no default secret:
+		// BOOKSTORE_AUTH_SECRET (or auth.secret) must be set.
+		{"default auth secret", func(c *Config) { c.Auth.Secret = "" }, []string{"auth.secret: must be at least 32 bytes for HS256"}},
+		{"short secret", func(c *Config) { c.Auth.Algorithm, c.Auth.Secret = "HS512", "short" }, []string{"auth.secret"}},
+		{"auth off", func(c *Config) { c.Auth.Algorithm, c.Auth.Secret = AuthNone, "" }, nil},
+		{"RSA without a key", func(c *Config) { c.Auth.Algorithm = "RS256" }, []string{"auth.public_key_file"}},
+		{"unknown algorithm", func(c *Config) { c.Auth.Algorithm = "ES256" }, []string{"auth.algorithm"}},
+		{"bad addresses", func(c *Config) { c.Server.Addr, c.GRPC.Addr = "9010", "localhost" },
+			[]string{"server.addr", "grpc.addr"}},
+		{"admin on the API port", func(c *Config) { c.Server.AdminAddr = c.Server.Addr }, []string{"server.admin_addr: must differ"}},
+		{"admin off", func(c *Config) { c.Server.AdminAddr = "" }, nil},
+		{"shutdown timeout", func(c *Config) { c.Server.ShutdownTimeout = 0 }, []string{"server.shutdown_timeout"}},
+		{"mysql settings", func(c *Config) { c.Database.Port, c.Database.Params = 70000, "?charset=utf8" },
+			[]string{"database.port", "database.params"}},
+		{"mysql settings ignored for sqlite", func(c *Config) { c.Database.Driver, c.Database.Port = DriverSQLite, 0 }, nil},
+		{"sqlite path", func(c *Config) { c.Database.Driver, c.Database.Path = DriverSQLite, "" }, []string{"database.path"}},
+		{"unknown driver", func(c *Config) { c.Database.Driver = "postgres" }, []string{"database.driver"}},
+		{"pool sizes", func(c *Config) { c.Database.MaxOpenConns, c.Database.MaxIdleConns = 2, 5 }, []string{"database.max_idle_conns"}},
+		{"trash", func(c *Config) { c.Trash.PurgeInterval = 0 }, []string{"trash.purge_interval"}},
+		{"currency", func(c *Config) { c.Payment.Currency = "usd" }, []string{"payment.currency"}},
+		{"s3", func(c *Config) { c.Storage.Driver = StorageS3 },
+			[]string{"storage.s3.endpoint", "storage.s3.bucket", "storage.s3: access_key"}},
+		{"cache", func(c *Config) { c.Cache.Driver, c.Cache.Redis.Addr = CacheRedis, "redis" }, []string{"cache.redis.addr"}},
+		{"log", func(c *Config) { c.Log.Format, c.Log.Level = "xml", "loud" }, []string{"log.format", "log.level"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := validConfig()
+			tt.change(&c)
+			err := c.Validate()
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Fatalf("Validate: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Validate passed, want errors about %v", tt.want)
+			}
+			// Every problem is reported, one per line.
+			if got := len(strings.Split(err.Error(), "\n")); got < len(tt.want) {
+				t.Errorf("Validate reported %d problems, want at least %d: %v", got, len(tt.want), err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), "config: "+want) {
+					t.Errorf("Validate = %v, want it to mention %q", err, want)
+				}
+			}
+		})
+	}
+}
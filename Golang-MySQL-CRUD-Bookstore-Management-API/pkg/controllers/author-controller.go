@@ -0,0 +1,111 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/yoloxsta/go-bookstore/pkg/models"
+	"github.com/yoloxsta/go-bookstore/pkg/utils"
+)
+
+type AuthorController struct {
+	Authors models.AuthorRepository
+}
+
+func NewAuthorController(authors models.AuthorRepository) *AuthorController {
+	return &AuthorController{Authors: authors}
+}
+
+func (c *AuthorController) GetAuthors(w http.ResponseWriter, r *http.Request) {
+	q, err := parseNameQuery(r)
+	if err != nil {
+		utils.RespondError(w, r, http.StatusBadRequest, utils.CodeBadRequest, err.Error())
+		return
+	}
+	authors, total, err := c.Authors.List(q)
+	if err != nil {
+		utils.RespondInternalError(w, r, err)
+		return
+	}
+	w.Header().Set("X-Total-Count", strconv.FormatInt(total, 10))
+	utils.RespondJSON(w, http.StatusOK, authors)
+}
+
+func (c *AuthorController) GetAuthorById(w http.ResponseWriter, r *http.Request) {
+	ID, ok := pathID(w, r, "authorId", "author")
+	if !ok {
+		return
+	}
+	author, err := c.Authors.Get(ID)
+	if err != nil {
+		respondRepoErrorFor(w, r, "author", err)
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, author)
+}
+
+func (c *AuthorController) CreateAuthor(w http.ResponseWriter, r *http.Request) {
+	author := &models.Author{}
+	if err := utils.ParseBody(r, author); err != nil {
+		utils.RespondBodyError(w, r, err)
+		return
+	}
+	author.ID = 0
+	if err := author.Validate(); err != nil {
+		utils.RespondBodyError(w, r, err)
+		return
+	}
+	if err := c.Authors.Create(author); err != nil {
+		utils.RespondInternalError(w, r, err)
+		return
+	}
+	utils.RespondJSON(w, http.StatusCreated, author)
+}
+
+func (c *AuthorController) UpdateAuthor(w http.ResponseWriter, r *http.Request) {
+	ID, ok := pathID(w, r, "authorId", "author")
+	if !ok {
+		return
+	}
+	author := &models.Author{}
+	if err := utils.ParseBody(r, author); err != nil {
+		utils.RespondBodyError(w, r, err)
+		return
+	}
+	if err := author.Validate(); err != nil {
+		utils.RespondBodyError(w, r, err)
+		return
+	}
+	author.ID = uint(ID)
+	if err := c.Authors.Update(author); err != nil {
+		respondRepoErrorFor(w, r, "author", err)
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, author)
+}
+
+// DeleteAuthor refuses with 409 while any book, including one in the trash,
+// still credits the author.
+func (c *AuthorController) DeleteAuthor(w http.ResponseWriter, r *http.Request) {
+	ID, ok := pathID(w, r, "authorId", "author")
+	if !ok {
+		return
+	}
+	if err := c.Authors.Delete(ID); err != nil {
+		respondRepoErrorFor(w, r, "author", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// pathID parses a positive integer route variable, writing a 400 naming the
+// resource when it is not one.
+func pathID(w http.ResponseWriter, r *http.Request, key, resource string) (int64, bool) {
+	ID, err := strconv.ParseInt(mux.Vars(r)[key], 10, 64)
+	if err != nil || ID <= 0 {
+		utils.RespondError(w, r, http.StatusBadRequest, utils.CodeBadRequest, resource+" id must be a positive integer")
+		return 0, false
+	}
+	return ID, true
+}
@@ -2,84 +2,388 @@ package controllers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"mime"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/yoloxsta/go-bookstore/pkg/isbn"
 	"github.com/yoloxsta/go-bookstore/pkg/models"
+	"github.com/yoloxsta/go-bookstore/pkg/patch"
 	"github.com/yoloxsta/go-bookstore/pkg/utils"
+	"github.com/yoloxsta/go-bookstore/pkg/validation"
 )
 
-var NewBook models.Book
+type BookController struct {
+	Books      models.BookRepository
+	Authors    models.AuthorRepository
+	Publishers models.PublisherRepository
+	Inventory  models.InventoryRepository
+	Covers     models.CoverRepository
+	Audit      models.AuditRepository
+	// MaxAge is sent in the Cache-Control header of book reads.
+	MaxAge time.Duration
+}
+
+func NewBookController(store *models.Store, maxAge time.Duration) *BookController {
+	return &BookController{
+		MaxAge:     maxAge,
+		Books:      store.Books,
+		Authors:    store.Authors,
+		Publishers: store.Publishers,
+		Inventory:  store.Inventory,
+		Covers:     store.Covers,
+		Audit:      store.Audit,
+	}
+}
+
+func (c *BookController) GetBook(w http.ResponseWriter, r *http.Request) {
+	c.listBooks(w, r, false)
+}
+
+// GetTrash lists soft-deleted books with the same paging and filters as GetBook.
+func (c *BookController) GetTrash(w http.ResponseWriter, r *http.Request) {
+	c.listBooks(w, r, true)
+}
+
+func (c *BookController) listBooks(w http.ResponseWriter, r *http.Request, deleted bool) {
+	q, err := parseBookQuery(r)
+	q.Deleted = deleted
+	if err != nil {
+		utils.RespondError(w, r, http.StatusBadRequest, utils.CodeBadRequest, err.Error())
+		return
+	}
+	inc, err := parseIncludes(r)
+	if err != nil {
+		utils.RespondError(w, r, http.StatusBadRequest, utils.CodeBadRequest, err.Error())
+		return
+	}
+	page, err := c.Books.List(q)
+	if errors.Is(err, models.ErrInvalidQuery) {
+		utils.RespondError(w, r, http.StatusBadRequest, utils.CodeBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		utils.RespondInternalError(w, r, err)
+		return
+	}
+	books := make([]*models.Book, len(page.Books))
+	for i := range page.Books {
+		books[i] = &page.Books[i]
+	}
+	if err := c.expand(inc, books); err != nil {
+		utils.RespondInternalError(w, r, err)
+		return
+	}
+	setPageHeaders(w, r, q, page)
+	setCacheControl(w, c.MaxAge)
+	utils.RespondJSON(w, http.StatusOK, page.Books)
+}
+
+func (c *BookController) SearchBooks(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query().Get("q")
+	if strings.TrimSpace(query) == "" {
+		utils.RespondError(w, r, http.StatusBadRequest, utils.CodeBadRequest, "q is required")
+		return
+	}
+	limit := models.DefaultPageSize
+	if s := r.URL.Query().Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n <= 0 {
+			utils.RespondError(w, r, http.StatusBadRequest, utils.CodeBadRequest, "limit must be a positive integer")
+			return
+		}
+		limit = min(n, models.MaxPageSize)
+	}
+	inc, err := parseIncludes(r)
+	if err != nil {
+		utils.RespondError(w, r, http.StatusBadRequest, utils.CodeBadRequest, err.Error())
+		return
+	}
+	results, err := c.Books.Search(query, limit)
+	if err != nil {
+		utils.RespondInternalError(w, r, err)
+		return
+	}
+	books := make([]*models.Book, len(results))
+	for i := range results {
+		books[i] = &results[i].Book
+	}
+	if err := c.expand(inc, books); err != nil {
+		utils.RespondInternalError(w, r, err)
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, results)
+}
+
+func (c *BookController) GetBookById(w http.ResponseWriter, r *http.Request) {
+	ID, ok := bookID(w, r)
+	if !ok {
+		return
+	}
+	c.respondBook(w, r, func() (*models.Book, error) { return c.Books.Get(ID) })
+}
 
-func GetBook(w http.ResponseWriter, r *http.Request) {
-	newBooks := models.GetAllBooks()
-	res, _ := json.Marshal(newBooks)
-	w.Header().Set("Content-Type", "pkglication/json")
-	w.WriteHeader(http.StatusOK)
-	w.Write(res)
+// GetBookByISBN looks a book up by either ISBN form, with or without hyphens.
+func (c *BookController) GetBookByISBN(w http.ResponseWriter, r *http.Request) {
+	raw := mux.Vars(r)["isbn"]
+	n, err := isbn.Normalize(raw)
+	if err != nil {
+		utils.RespondError(w, r, http.StatusBadRequest, utils.CodeBadRequest, fmt.Sprintf("isbn %q %s", raw, err))
+		return
+	}
+	c.respondBook(w, r, func() (*models.Book, error) { return c.Books.GetByISBN(n) })
 }
 
-func GetBookById(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	bookId := vars["bookId"]
-	ID, err := strconv.ParseInt(bookId, 0, 0)
+// respondBook writes the book returned by get, honouring ?include and
+// conditional GETs.
+func (c *BookController) respondBook(w http.ResponseWriter, r *http.Request, get func() (*models.Book, error)) {
+	inc, err := parseIncludes(r)
+	if err != nil {
+		utils.RespondError(w, r, http.StatusBadRequest, utils.CodeBadRequest, err.Error())
+		return
+	}
+	bookDetails, err := get()
 	if err != nil {
-		fmt.Println("error while parsing")
+		respondRepoError(w, r, err)
+		return
+	}
+	// Every cover change bumps the version, so the version stands for the
+	// cover. Included resources change on their own and are expanded first,
+	// so the ETag can follow them.
+	if err := c.expand(inc, []*models.Book{bookDetails}); err != nil {
+		utils.RespondInternalError(w, r, err)
+		return
+	}
+	etag := bookETag(bookDetails)
+	if inc != (includes{}) {
+		if etag, err = expandedETag(bookDetails); err != nil {
+			utils.RespondInternalError(w, r, err)
+			return
+		}
+	}
+	w.Header().Set("ETag", etag)
+	setCacheControl(w, c.MaxAge)
+	if notModified(w, r, etag) {
+		return
+	}
+	bookCover, err := c.Covers.Get(int64(bookDetails.ID))
+	switch {
+	case err == nil:
+		bookDetails.Cover = withCoverURLs(bookCover)
+	case !errors.Is(err, models.ErrNotFound):
+		utils.RespondInternalError(w, r, err)
+		return
 	}
-	bookDetails, _ := models.GetBookById(ID)
-	res, _ := json.Marshal(bookDetails)
-	w.Header().Set("Content-Type", "pkglication/json")
-	w.WriteHeader(http.StatusOK)
-	w.Write(res)
+	utils.RespondJSON(w, http.StatusOK, bookDetails)
 }
 
-func CreateBook(w http.ResponseWriter, r *http.Request) {
+func (c *BookController) CreateBook(w http.ResponseWriter, r *http.Request) {
 	CreateBook := &models.Book{}
-	utils.ParseBody(r, CreateBook)
-	b := CreateBook.CreateBook()
-	res, _ := json.Marshal(b)
-	w.WriteHeader(http.StatusOK)
-	w.Write(res)
+	if err := utils.ParseBody(r, CreateBook); err != nil {
+		utils.RespondBodyError(w, r, err)
+		return
+	}
+	CreateBook.ID = 0
+	if err := CreateBook.Validate(); err != nil {
+		utils.RespondBodyError(w, r, err)
+		return
+	}
+	CreateBook.Authors, CreateBook.Publisher, CreateBook.Stock, CreateBook.Cover = nil, nil, nil, nil
+	if err := c.booksFor(r, "").Create(CreateBook); err != nil {
+		respondRepoError(w, r, err)
+		return
+	}
+	w.Header().Set("ETag", bookETag(CreateBook))
+	utils.RespondJSON(w, http.StatusCreated, CreateBook)
+}
+
+// DeleteBook moves the book to the trash and returns it. With ?purge=true
+// the book, live or trashed, is removed permanently instead.
+func (c *BookController) DeleteBook(w http.ResponseWriter, r *http.Request) {
+	ID, ok := bookID(w, r)
+	if !ok {
+		return
+	}
+	purge, err := strconv.ParseBool(r.URL.Query().Get("purge"))
+	if err != nil && r.URL.Query().Has("purge") {
+		utils.RespondError(w, r, http.StatusBadRequest, utils.CodeBadRequest, "purge must be true or false")
+		return
+	}
+	bookDetails, err := c.Books.Get(ID)
+	if purge && errors.Is(err, models.ErrNotFound) {
+		bookDetails, err = c.Books.GetDeleted(ID)
+	}
+	if err != nil {
+		respondRepoError(w, r, err)
+		return
+	}
+	if !checkIfMatch(w, r, bookDetails) {
+		return
+	}
+	if purge {
+		err = c.booksFor(r, "").Purge(bookDetails)
+	} else {
+		err = c.booksFor(r, "").Delete(bookDetails)
+	}
+	if err != nil {
+		respondRepoError(w, r, err)
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, bookDetails)
+}
+
+// RestoreBook takes a book back out of the trash.
+func (c *BookController) RestoreBook(w http.ResponseWriter, r *http.Request) {
+	ID, ok := bookID(w, r)
+	if !ok {
+		return
+	}
+	bookDetails, err := c.booksFor(r, "").Restore(ID)
+	if err != nil {
+		respondRepoError(w, r, err)
+		return
+	}
+	w.Header().Set("ETag", bookETag(bookDetails))
+	utils.RespondJSON(w, http.StatusOK, bookDetails)
 }
 
-func DeleteBook(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	bookId := vars["bookId"]
-	ID, err := strconv.ParseInt(bookId, 0, 0)
+// UpdateBook replaces every client-writable field of the book; fields left
+// out of the body are cleared.
+func (c *BookController) UpdateBook(w http.ResponseWriter, r *http.Request) {
+	ID, ok := bookID(w, r)
+	if !ok {
+		return
+	}
+	replacement := &models.Book{}
+	if err := utils.ParseBody(r, replacement); err != nil {
+		utils.RespondBodyError(w, r, err)
+		return
+	}
+	bookDetails, err := c.Books.Get(ID)
 	if err != nil {
-		fmt.Println("error while parsing")
+		respondRepoError(w, r, err)
+		return
+	}
+	if !checkIfMatch(w, r, bookDetails) {
+		return
 	}
-	book := models.DeleteBook(ID)
-	res, _ := json.Marshal(book)
-	w.Header().Set("Content-Type", "pkglication/json")
-	w.WriteHeader(http.StatusOK)
-	w.Write(res)
+	c.saveReplacement(w, r, bookDetails, replacement, "")
 }
 
-func UpdateBook(w http.ResponseWriter, r *http.Request) {
-	var updateBook = &models.Book{}
-	utils.ParseBody(r, updateBook)
-	vars := mux.Vars(r)
-	bookId := vars["bookId"]
-	ID, err := strconv.ParseInt(bookId, 0, 0)
+// PatchBook applies an RFC 7396 merge patch (application/merge-patch+json or
+// plain application/json) or an RFC 6902 JSON Patch
+// (application/json-patch+json) to the book's JSON representation.
+func (c *BookController) PatchBook(w http.ResponseWriter, r *http.Request) {
+	ID, ok := bookID(w, r)
+	if !ok {
+		return
+	}
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	var apply func(doc, patch []byte) ([]byte, error)
+	switch mediaType {
+	case "application/merge-patch+json", "application/json":
+		apply = patch.MergePatch
+	case "application/json-patch+json":
+		apply = patch.JSONPatch
+	default:
+		w.Header().Set("Accept-Patch", acceptPatch)
+		utils.RespondError(w, r, http.StatusUnsupportedMediaType, utils.CodeUnsupportedMedia,
+			"Content-Type must be one of "+acceptPatch)
+		return
+	}
+	body, err := utils.ReadBody(r)
+	if err != nil {
+		utils.RespondBodyError(w, r, err)
+		return
+	}
+	bookDetails, err := c.Books.Get(ID)
 	if err != nil {
-		fmt.Println("error while parsing")
+		respondRepoError(w, r, err)
+		return
+	}
+	if !checkIfMatch(w, r, bookDetails) {
+		return
+	}
+	doc, err := json.Marshal(bookDetails)
+	if err != nil {
+		utils.RespondInternalError(w, r, err)
+		return
+	}
+	patched, err := apply(doc, body)
+	switch {
+	case errors.Is(err, patch.ErrMalformed):
+		utils.RespondError(w, r, http.StatusBadRequest, utils.CodeBadRequest, err.Error())
+		return
+	case errors.Is(err, patch.ErrTestFailed):
+		utils.RespondError(w, r, http.StatusConflict, utils.CodeConflict, err.Error())
+		return
+	case err != nil:
+		utils.RespondError(w, r, http.StatusUnprocessableEntity, utils.CodePatchFailed, err.Error())
+		return
 	}
-	bookDetails, db := models.GetBookById(ID)
-	if updateBook.Name != "" {
-		bookDetails.Name = updateBook.Name
+	replacement := &models.Book{}
+	if err := utils.DecodeJSON(patched, replacement); err != nil {
+		utils.RespondBodyError(w, r, err)
+		return
 	}
-	if updateBook.Author != "" {
-		bookDetails.Author = updateBook.Author
+	c.saveReplacement(w, r, bookDetails, replacement, "")
+}
+
+const acceptPatch = "application/merge-patch+json, application/json-patch+json"
+
+// saveReplacement stores replacement in place of current. Server-managed
+// fields (ID, timestamps and version) always come from current. note is
+// recorded in the audit log.
+func (c *BookController) saveReplacement(w http.ResponseWriter, r *http.Request, current, replacement *models.Book, note string) {
+	replacement.Model = current.Model
+	replacement.Version = current.Version
+	replacement.Authors, replacement.Publisher, replacement.Stock, replacement.Cover = nil, nil, nil, nil
+	if err := replacement.Validate(); err != nil {
+		utils.RespondBodyError(w, r, err)
+		return
 	}
-	if updateBook.Publication != "" {
-		bookDetails.Publication = updateBook.Publication
+	if err := c.booksFor(r, note).Update(replacement); err != nil {
+		respondRepoError(w, r, err)
+		return
+	}
+	w.Header().Set("ETag", bookETag(replacement))
+	utils.RespondJSON(w, http.StatusOK, replacement)
+}
+
+// bookID parses the {bookId} route variable, writing a 400 when it is not a
+// positive integer.
+func bookID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	return pathID(w, r, "bookId", "book")
+}
+
+func respondRepoError(w http.ResponseWriter, r *http.Request, err error) {
+	respondRepoErrorFor(w, r, "book", err)
+}
+
+// respondRepoErrorFor maps repository errors about the named resource to
+// responses. Validation errors found by the repository, such as links to
+// missing authors, become a 422.
+func respondRepoErrorFor(w http.ResponseWriter, r *http.Request, resource string, err error) {
+	var fieldErrs validation.Errors
+	switch {
+	case errors.As(err, &fieldErrs):
+		utils.RespondValidationError(w, r, fieldErrs)
+	case errors.Is(err, models.ErrNotFound):
+		utils.RespondError(w, r, http.StatusNotFound, utils.CodeNotFound, resource+" not found")
+	case errors.Is(err, models.ErrInUse):
+		utils.RespondError(w, r, http.StatusConflict, utils.CodeConflict, resource+" is still referenced by books")
+	case errors.Is(err, models.ErrConflict) && r.Header.Get("If-Match") != "":
+		utils.RespondError(w, r, http.StatusPreconditionFailed, utils.CodePreconditionFailed,
+			resource+" has changed since it was fetched; reload and retry")
+	case errors.Is(err, models.ErrConflict):
+		utils.RespondError(w, r, http.StatusConflict, utils.CodeConflict,
+			resource+" was changed by another request; reload and retry")
+	default:
+		utils.RespondInternalError(w, r, err)
 	}
-	db.Save(&bookDetails)
-	res, _ := json.Marshal(bookDetails)
-	w.Header().Set("Content-Type", "pkglication/json")
-	w.WriteHeader(http.StatusOK)
-	w.Write(res)
 }
@@ -0,0 +1,105 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/yoloxsta/go-bookstore/pkg/auth"
+	"github.com/yoloxsta/go-bookstore/pkg/middleware"
+	"github.com/yoloxsta/go-bookstore/pkg/models"
+	"github.com/yoloxsta/go-bookstore/pkg/utils"
+	"github.com/yoloxsta/go-bookstore/pkg/validation"
+)
+
+// booksFor returns the book repository that records the caller of r, and
+// note, in the audit log.
+func (c *BookController) booksFor(r *http.Request, note string) models.BookRepository {
+	return c.Books.WithAudit(auditInfo(r, note))
+}
+
+func auditInfo(r *http.Request, note string) models.AuditInfo {
+	return models.AuditInfo{Actor: auth.Actor(r.Context()), RequestID: middleware.GetRequestID(r.Context()), Note: note}
+}
+
+// GetBookHistory lists the audit log of a book, newest first. The log is
+// kept after the book is purged.
+func (c *BookController) GetBookHistory(w http.ResponseWriter, r *http.Request) {
+	ID, ok := bookID(w, r)
+	if !ok {
+		return
+	}
+	nq, err := parseNameQuery(r)
+	if err != nil {
+		utils.RespondError(w, r, http.StatusBadRequest, utils.CodeBadRequest, err.Error())
+		return
+	}
+	entries, total, err := c.Audit.History(ID, models.AuditQuery{Limit: nq.Limit, Offset: nq.Offset})
+	if err != nil {
+		utils.RespondInternalError(w, r, err)
+		return
+	}
+	if total == 0 && !c.bookExists(w, r, ID) {
+		return
+	}
+	w.Header().Set("X-Total-Count", strconv.FormatInt(total, 10))
+	utils.RespondJSON(w, http.StatusOK, entries)
+}
+
+// bookExists reports whether a live or trashed book has the ID, writing a
+// 404 or 500 when it does not.
+func (c *BookController) bookExists(w http.ResponseWriter, r *http.Request, ID int64) bool {
+	_, err := c.Books.Get(ID)
+	if errors.Is(err, models.ErrNotFound) {
+		_, err = c.Books.GetDeleted(ID)
+	}
+	if err != nil {
+		respondRepoError(w, r, err)
+		return false
+	}
+	return true
+}
+
+type revertRequest struct {
+	Revision uint `json:"revision"`
+}
+
+// RevertBook restores the fields a live book had at an earlier revision. The
+// revert is itself an update, so it gets a new revision and history entry.
+func (c *BookController) RevertBook(w http.ResponseWriter, r *http.Request) {
+	ID, ok := bookID(w, r)
+	if !ok {
+		return
+	}
+	var req revertRequest
+	if err := utils.ParseBody(r, &req); err != nil {
+		utils.RespondBodyError(w, r, err)
+		return
+	}
+	if req.Revision == 0 {
+		var errs validation.Errors
+		errs.Add("revision", "is required")
+		utils.RespondValidationError(w, r, errs)
+		return
+	}
+	bookDetails, err := c.Books.Get(ID)
+	if err != nil {
+		respondRepoError(w, r, err)
+		return
+	}
+	if !checkIfMatch(w, r, bookDetails) {
+		return
+	}
+	entry, err := c.Audit.Revision(ID, req.Revision)
+	if err != nil {
+		respondRepoErrorFor(w, r, "revision", err)
+		return
+	}
+	target, err := entry.Book()
+	if err != nil {
+		utils.RespondInternalError(w, r, err)
+		return
+	}
+	c.saveReplacement(w, r, bookDetails, target, fmt.Sprintf("revert to revision %d", req.Revision))
+}
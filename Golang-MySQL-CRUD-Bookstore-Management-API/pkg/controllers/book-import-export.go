@@ -0,0 +1,288 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/yoloxsta/go-bookstore/pkg/bulk"
+	"github.com/yoloxsta/go-bookstore/pkg/models"
+	"github.com/yoloxsta/go-bookstore/pkg/utils"
+	"github.com/yoloxsta/go-bookstore/pkg/validation"
+)
+
+const (
+	// maxImportBytes caps one import request; larger catalogs are split.
+	maxImportBytes = 64 << 20
+	// maxImportErrors bounds the errors listed in a report. Failed still
+	// counts every failed row.
+	maxImportErrors = 100
+)
+
+type importReport struct {
+	DryRun   bool          `json:"dry_run"`
+	Rows     int           `json:"rows"`
+	Imported int           `json:"imported"`
+	Failed   int           `json:"failed"`
+	Errors   []importError `json:"errors"`
+	// Aborted is set when the stream broke off part-way; rows before it
+	// were still imported.
+	Aborted string `json:"aborted,omitempty"`
+}
+
+type importError struct {
+	Line    int               `json:"line"`
+	Message string            `json:"message"`
+	Fields  validation.Errors `json:"fields,omitempty"`
+}
+
+func (rep *importReport) fail(line int, err error) {
+	rep.Failed++
+	if len(rep.Errors) >= maxImportErrors {
+		return
+	}
+	e := importError{Line: line, Message: err.Error()}
+	if errors.As(err, &e.Fields) {
+		e.Message = "row failed validation"
+	}
+	rep.Errors = append(rep.Errors, e)
+}
+
+// ImportBooks creates one book per CSV or NDJSON row, chosen by
+// ?format or the Content-Type. Rows are independent: a bad row is reported
+// by line and the rest are still imported. With ?dry_run=true every row is
+// checked but nothing is written.
+func (c *BookController) ImportBooks(w http.ResponseWriter, r *http.Request) {
+	dryRun, err := strconv.ParseBool(r.URL.Query().Get("dry_run"))
+	if err != nil && r.URL.Query().Has("dry_run") {
+		utils.RespondError(w, r, http.StatusBadRequest, utils.CodeBadRequest, "dry_run must be true or false")
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		if format, err = bulk.FormatForMediaType(r.Header.Get("Content-Type")); err != nil {
+			utils.RespondError(w, r, http.StatusUnsupportedMediaType, utils.CodeUnsupportedMedia,
+				"Content-Type must be text/csv or application/x-ndjson")
+			return
+		}
+	}
+
+	books := c.booksFor(r, "import")
+	body := http.MaxBytesReader(w, r.Body, maxImportBytes)
+	var dec bulk.Decoder
+	switch format {
+	case bulk.FormatCSV:
+		dec, err = bulk.NewCSVDecoder(body)
+	case bulk.FormatNDJSON:
+		dec = bulk.NewNDJSONDecoder(body)
+	default:
+		err = bulk.ErrUnknownFormat
+	}
+	if err != nil {
+		respondImportError(w, r, err)
+		return
+	}
+
+	rep := importReport{DryRun: dryRun, Errors: []importError{}}
+	var taken map[string]int
+	if dryRun {
+		taken = map[string]int{}
+	}
+	for {
+		var b models.Book
+		line, err := dec.Next(&b)
+		if err == io.EOF {
+			break
+		}
+		var rowErr *bulk.RowError
+		if errors.As(err, &rowErr) {
+			rep.Rows++
+			rep.fail(line, rowErr.Err)
+			continue
+		}
+		if err != nil {
+			if rep.Rows == 0 {
+				respondImportError(w, r, err)
+				return
+			}
+			rep.Aborted = fmt.Sprintf("line %d: %v", line, err)
+			break
+		}
+		rep.Rows++
+		if err := c.importBook(books, &b, line, taken); err != nil {
+			var fieldErrs validation.Errors
+			if !errors.As(err, &fieldErrs) {
+				slog.ErrorContext(r.Context(), "importing books", "line", line, "err", err)
+				rep.Aborted = fmt.Sprintf("line %d: internal server error", line)
+				break
+			}
+			rep.fail(line, err)
+			continue
+		}
+		rep.Imported++
+	}
+	utils.RespondJSON(w, http.StatusOK, rep)
+}
+
+// importBook treats b, from the given line, like the body of POST /book/,
+// creating it through books. taken is nil except in a dry run, which
+// instead runs the checks Create would: SKU and ISBN uniqueness, also
+// against the earlier rows recorded in taken, and the author and publisher
+// links.
+func (c *BookController) importBook(books models.BookRepository, b *models.Book, line int, taken map[string]int) error {
+	b.Model = gorm.Model{}
+	b.Version = 0
+	b.Authors, b.Publisher, b.Stock, b.Cover = nil, nil, nil, nil
+	if err := b.Validate(); err != nil {
+		return err
+	}
+	if taken == nil {
+		return books.Create(b)
+	}
+	var errs validation.Errors
+	if err := books.CheckUnique(b); err != nil && !errors.As(err, &errs) {
+		return err
+	}
+	keys := []struct {
+		field string
+		value *string
+	}{{"sku", b.SKU}, {"isbn13", b.ISBN13}}
+	for _, k := range keys {
+		if k.value == nil {
+			continue
+		}
+		if earlier, ok := taken[k.field+":"+*k.value]; ok {
+			errs.Add(k.field, "is already used by line %d", earlier)
+		}
+	}
+	authors, err := c.Authors.GetMany(b.AuthorIDs)
+	if err != nil {
+		return err
+	}
+	found := make(map[uint]bool, len(authors))
+	for _, a := range authors {
+		found[a.ID] = true
+	}
+	for _, id := range b.AuthorIDs {
+		if !found[id] {
+			errs.Add("author_ids", "author %d does not exist", id)
+		}
+	}
+	if b.PublisherID != nil {
+		publishers, err := c.Publishers.GetMany([]uint{*b.PublisherID})
+		if err != nil {
+			return err
+		}
+		if len(publishers) == 0 {
+			errs.Add("publisher_id", "publisher %d does not exist", *b.PublisherID)
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	for _, k := range keys {
+		if k.value != nil {
+			taken[k.field+":"+*k.value] = line
+		}
+	}
+	return nil
+}
+
+func respondImportError(w http.ResponseWriter, r *http.Request, err error) {
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		utils.RespondError(w, r, http.StatusRequestEntityTooLarge, utils.CodeTooLarge,
+			fmt.Sprintf("import exceeds %d bytes; split it into smaller files", maxImportBytes))
+		return
+	}
+	utils.RespondError(w, r, http.StatusBadRequest, utils.CodeBadRequest, err.Error())
+}
+
+// ExportBooks streams every live book matching the list filters as CSV
+// (the default) or NDJSON, reading the table one page at a time past the
+// book cache.
+func (c *BookController) ExportBooks(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = bulk.FormatCSV
+	}
+	if format != bulk.FormatCSV && format != bulk.FormatNDJSON {
+		utils.RespondError(w, r, http.StatusBadRequest, utils.CodeBadRequest, bulk.ErrUnknownFormat.Error())
+		return
+	}
+	q, err := parseBookQuery(r)
+	if err != nil {
+		utils.RespondError(w, r, http.StatusBadRequest, utils.CodeBadRequest, err.Error())
+		return
+	}
+	if q.Limit != 0 || q.Offset != 0 || q.Cursor != "" {
+		utils.RespondError(w, r, http.StatusBadRequest, utils.CodeBadRequest, "export does not take limit, offset or cursor")
+		return
+	}
+	q.Limit = models.MaxPageSize
+	q.SkipTotal, q.NoCache = true, true
+
+	// The first page is fetched before anything is written so that a bad
+	// query still gets a proper error response.
+	page, err := c.Books.List(q)
+	if errors.Is(err, models.ErrInvalidQuery) {
+		utils.RespondError(w, r, http.StatusBadRequest, utils.CodeBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		utils.RespondInternalError(w, r, err)
+		return
+	}
+
+	// A large catalog takes longer to stream than the server's write
+	// timeout allows; the export is bounded by the client reading it instead.
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+		slog.WarnContext(r.Context(), "export is subject to the write timeout", "err", err)
+	}
+	w.Header().Set("Content-Type", bulk.MediaType(format))
+	w.Header().Set("Content-Disposition", `attachment; filename="books.`+format+`"`)
+	var enc bulk.Encoder
+	if format == bulk.FormatCSV {
+		enc, err = bulk.NewCSVEncoder(w)
+	} else {
+		enc = bulk.NewNDJSONEncoder(w)
+	}
+	// Once the body has started, errors can only be logged; the client sees
+	// a truncated stream.
+	logErr := func(err error) {
+		slog.ErrorContext(r.Context(), "exporting books", "err", err)
+	}
+	if err != nil {
+		logErr(err)
+		return
+	}
+	flusher, _ := w.(http.Flusher)
+	for {
+		for _, b := range page.Books {
+			if err := enc.Encode(b); err != nil {
+				logErr(err)
+				return
+			}
+		}
+		if err := enc.Flush(); err != nil {
+			logErr(err)
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		if page.NextCursor == "" {
+			return
+		}
+		q.Cursor = page.NextCursor
+		if page, err = c.Books.List(q); err != nil {
+			logErr(err)
+			return
+		}
+	}
+}
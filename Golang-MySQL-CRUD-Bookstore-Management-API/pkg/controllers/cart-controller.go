@@ -0,0 +1,163 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/yoloxsta/go-bookstore/pkg/auth"
+	"github.com/yoloxsta/go-bookstore/pkg/models"
+	"github.com/yoloxsta/go-bookstore/pkg/utils"
+)
+
+type CartController struct {
+	Carts models.CartRepository
+}
+
+func NewCartController(carts models.CartRepository) *CartController {
+	return &CartController{Carts: carts}
+}
+
+// Carts and orders belong to the customer who created them, named by the
+// token's subject. Their routes only need the reader role, so they are
+// exempt from auth.ByMethod; editors can reach every customer's.
+
+// requireCustomer writes a 403 unless the caller holds the reader role.
+func requireCustomer(w http.ResponseWriter, r *http.Request) bool {
+	if !auth.Allowed(r.Context(), auth.RoleReader) {
+		utils.RespondError(w, r, http.StatusForbidden, utils.CodeForbidden, "shopping requires the reader role")
+		return false
+	}
+	return true
+}
+
+// owns reports whether the caller may see and change what customer owns.
+func owns(r *http.Request, customer string) bool {
+	return customer == auth.Actor(r.Context()) || auth.Allowed(r.Context(), auth.RoleEditor)
+}
+
+// loadCart returns the {cartId} cart. Another customer's cart is reported
+// as missing.
+func loadCart(w http.ResponseWriter, r *http.Request, carts models.CartRepository, ID int64) (*models.Cart, bool) {
+	cart, err := carts.Get(ID)
+	if err == nil && !owns(r, cart.Customer) {
+		err = models.ErrNotFound
+	}
+	if err != nil {
+		respondCartError(w, r, err)
+		return nil, false
+	}
+	return cart, true
+}
+
+// cartID parses {cartId} and checks that the caller owns the cart.
+func (c *CartController) cartID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	if !requireCustomer(w, r) {
+		return 0, false
+	}
+	ID, ok := pathID(w, r, "cartId", "cart")
+	if !ok {
+		return 0, false
+	}
+	if _, ok := loadCart(w, r, c.Carts, ID); !ok {
+		return 0, false
+	}
+	return ID, true
+}
+
+func (c *CartController) CreateCart(w http.ResponseWriter, r *http.Request) {
+	if !requireCustomer(w, r) {
+		return
+	}
+	cart := &models.Cart{Customer: auth.Actor(r.Context())}
+	if err := c.Carts.Create(cart); err != nil {
+		utils.RespondInternalError(w, r, err)
+		return
+	}
+	utils.RespondJSON(w, http.StatusCreated, cart)
+}
+
+func (c *CartController) GetCart(w http.ResponseWriter, r *http.Request) {
+	if !requireCustomer(w, r) {
+		return
+	}
+	ID, ok := pathID(w, r, "cartId", "cart")
+	if !ok {
+		return
+	}
+	cart, ok := loadCart(w, r, c.Carts, ID)
+	if !ok {
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, cart)
+}
+
+// cartItemBody is the body of PUT /cart/{cartId}/items/{bookId}.
+type cartItemBody struct {
+	Quantity int `json:"quantity"`
+}
+
+// SetCartItem sets how many copies of a book are in the cart; a quantity of
+// zero removes the book.
+func (c *CartController) SetCartItem(w http.ResponseWriter, r *http.Request) {
+	ID, ok := c.cartID(w, r)
+	if !ok {
+		return
+	}
+	bookID, ok := bookID(w, r)
+	if !ok {
+		return
+	}
+	var body cartItemBody
+	if err := utils.ParseBody(r, &body); err != nil {
+		utils.RespondBodyError(w, r, err)
+		return
+	}
+	item := models.CartItem{BookID: uint(bookID), Quantity: body.Quantity}
+	if err := item.Validate(); err != nil {
+		utils.RespondBodyError(w, r, err)
+		return
+	}
+	cart, err := c.Carts.SetItem(ID, item)
+	if err != nil {
+		respondCartError(w, r, err)
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, cart)
+}
+
+func (c *CartController) DeleteCartItem(w http.ResponseWriter, r *http.Request) {
+	ID, ok := c.cartID(w, r)
+	if !ok {
+		return
+	}
+	bookID, ok := bookID(w, r)
+	if !ok {
+		return
+	}
+	cart, err := c.Carts.SetItem(ID, models.CartItem{BookID: uint(bookID)})
+	if err != nil {
+		respondCartError(w, r, err)
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, cart)
+}
+
+func (c *CartController) DeleteCart(w http.ResponseWriter, r *http.Request) {
+	ID, ok := c.cartID(w, r)
+	if !ok {
+		return
+	}
+	if err := c.Carts.Delete(ID); err != nil {
+		respondCartError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func respondCartError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, models.ErrCheckedOut) {
+		utils.RespondError(w, r, http.StatusConflict, utils.CodeConflict, "cart has already been checked out")
+		return
+	}
+	respondRepoErrorFor(w, r, "cart", err)
+}
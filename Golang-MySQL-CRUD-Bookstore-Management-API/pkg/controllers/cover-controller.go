@@ -0,0 +1,259 @@
+package controllers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/yoloxsta/go-bookstore/pkg/cover"
+	"github.com/yoloxsta/go-bookstore/pkg/models"
+	"github.com/yoloxsta/go-bookstore/pkg/storage"
+	"github.com/yoloxsta/go-bookstore/pkg/utils"
+	"github.com/yoloxsta/go-bookstore/pkg/validation"
+)
+
+// coverFormField is the multipart field that carries the image.
+const coverFormField = "cover"
+
+type CoverController struct {
+	Books  models.BookRepository
+	Covers models.CoverRepository
+	Blobs  storage.Storage
+	// MaxBytes caps the size of an uploaded image.
+	MaxBytes int
+}
+
+func NewCoverController(store *models.Store, blobs storage.Storage, maxBytes int) *CoverController {
+	return &CoverController{Books: store.Books, Covers: store.Covers, Blobs: blobs, MaxBytes: maxBytes}
+}
+
+// withCoverURLs fills in the URLs a cover is served from. The digest in the
+// query string changes with every upload, so the URLs can be cached forever.
+func withCoverURLs(c *models.Cover) *models.Cover {
+	version := c.Digest[:12]
+	c.URL = fmt.Sprintf("/book/%d/cover?v=%s", c.BookID, version)
+	c.Thumbnails = make(map[string]string, len(cover.Sizes))
+	for _, size := range cover.Sizes {
+		c.Thumbnails[size.Name] = fmt.Sprintf("/book/%d/cover/%s?v=%s", c.BookID, size.Name, version)
+	}
+	return c
+}
+
+// UploadCover replaces a book's cover with the image in the "cover" field of
+// a multipart/form-data body and renders its thumbnails.
+func (c *CoverController) UploadCover(w http.ResponseWriter, r *http.Request) {
+	ID, ok := bookID(w, r)
+	if !ok {
+		return
+	}
+	data, ok := c.readUpload(w, r)
+	if !ok {
+		return
+	}
+	original, thumbs, err := cover.Process(data)
+	switch {
+	case errors.Is(err, cover.ErrUnsupportedType):
+		utils.RespondError(w, r, http.StatusUnsupportedMediaType, utils.CodeUnsupportedMedia, err.Error())
+		return
+	case errors.Is(err, cover.ErrInvalid):
+		var errs validation.Errors
+		errs.Add(coverFormField, "%s", err)
+		utils.RespondValidationError(w, r, errs)
+		return
+	case err != nil:
+		utils.RespondInternalError(w, r, err)
+		return
+	}
+
+	bookDetails, err := c.Books.Get(ID)
+	if err != nil {
+		respondRepoError(w, r, err)
+		return
+	}
+	if !checkIfMatch(w, r, bookDetails) {
+		return
+	}
+
+	sum := sha256.Sum256(data)
+	digest := hex.EncodeToString(sum[:])
+	renditions := map[string]cover.Image{"original": original}
+	for name, img := range thumbs {
+		renditions[name] = img
+	}
+	for name, img := range renditions {
+		if err := c.Blobs.Put(r.Context(), cover.Key(bookDetails.ID, digest, name), img.Data, img.ContentType); err != nil {
+			c.deleteBlobs(r, bookDetails.ID, digest)
+			utils.RespondInternalError(w, r, err)
+			return
+		}
+	}
+
+	saved := &models.Cover{
+		Digest:      digest,
+		ContentType: original.ContentType,
+		Size:        int64(len(data)),
+		Width:       original.Width,
+		Height:      original.Height,
+	}
+	previous, err := c.Covers.Put(bookDetails, saved)
+	if err != nil {
+		if current, getErr := c.Covers.Get(ID); getErr != nil || current.Digest != digest {
+			c.deleteBlobs(r, bookDetails.ID, digest)
+		}
+		respondRepoError(w, r, err)
+		return
+	}
+	if previous != nil && previous.Digest != digest {
+		c.deleteBlobs(r, bookDetails.ID, previous.Digest)
+	}
+	w.Header().Set("ETag", bookETag(bookDetails))
+	utils.RespondJSON(w, http.StatusOK, withCoverURLs(saved))
+}
+
+// readUpload returns the contents of the cover field, writing an error
+// response when the body is not a usable upload.
+func (c *CoverController) readUpload(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	tooLarge := func() ([]byte, bool) {
+		utils.RespondError(w, r, http.StatusRequestEntityTooLarge, utils.CodeTooLarge,
+			"cover image exceeds "+strconv.Itoa(c.MaxBytes)+" bytes")
+		return nil, false
+	}
+	// The allowance on top of MaxBytes covers the multipart framing.
+	r.Body = http.MaxBytesReader(w, r.Body, int64(c.MaxBytes)+64<<10)
+	mr, err := r.MultipartReader()
+	if err != nil {
+		utils.RespondError(w, r, http.StatusUnsupportedMediaType, utils.CodeUnsupportedMedia,
+			"Content-Type must be multipart/form-data")
+		return nil, false
+	}
+	var maxErr *http.MaxBytesError
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if errors.As(err, &maxErr) {
+			return tooLarge()
+		}
+		if err != nil {
+			utils.RespondError(w, r, http.StatusBadRequest, utils.CodeBadRequest, "invalid multipart body: "+err.Error())
+			return nil, false
+		}
+		if part.FormName() != coverFormField {
+			continue
+		}
+		data, err := io.ReadAll(io.LimitReader(part, int64(c.MaxBytes)+1))
+		if errors.As(err, &maxErr) || len(data) > c.MaxBytes {
+			return tooLarge()
+		}
+		if err != nil {
+			utils.RespondError(w, r, http.StatusBadRequest, utils.CodeBadRequest, "invalid multipart body: "+err.Error())
+			return nil, false
+		}
+		return data, true
+	}
+	var errs validation.Errors
+	errs.Add(coverFormField, "is required")
+	utils.RespondValidationError(w, r, errs)
+	return nil, false
+}
+
+// deleteBlobs removes every rendition of one upload. Failures only leave
+// unreferenced blobs behind, so they are logged rather than returned.
+func (c *CoverController) deleteBlobs(r *http.Request, bookID uint, digest string) {
+	for _, name := range cover.Renditions() {
+		if err := c.Blobs.Delete(r.Context(), cover.Key(bookID, digest, name)); err != nil {
+			slog.ErrorContext(r.Context(), "deleting cover blob", "err", err)
+		}
+	}
+}
+
+// GetCover serves the original image or, with a {size} in the path, one of
+// its thumbnails.
+func (c *CoverController) GetCover(w http.ResponseWriter, r *http.Request) {
+	ID, ok := bookID(w, r)
+	if !ok {
+		return
+	}
+	rendition := "original"
+	if size, ok := mux.Vars(r)["size"]; ok {
+		if !knownSize(size) {
+			utils.RespondError(w, r, http.StatusNotFound, utils.CodeNotFound, "unknown cover size "+strconv.Quote(size))
+			return
+		}
+		rendition = size
+	}
+	bookCover, err := c.Covers.Get(ID)
+	if err != nil {
+		respondRepoErrorFor(w, r, "cover", err)
+		return
+	}
+
+	etag := `"` + bookCover.Digest[:16] + "-" + rendition + `"`
+	w.Header().Set("ETag", etag)
+	if r.URL.Query().Get("v") == bookCover.Digest[:12] {
+		w.Header().Set("Cache-Control", "private, max-age=31536000, immutable")
+	} else {
+		w.Header().Set("Cache-Control", "no-cache")
+	}
+	if header := r.Header.Get("If-None-Match"); header != "" && etagListMatches(header, etag, true) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	blob, err := c.Blobs.Get(r.Context(), cover.Key(bookCover.BookID, bookCover.Digest, rendition))
+	if err != nil {
+		utils.RespondInternalError(w, r, err)
+		return
+	}
+	defer blob.Close()
+	w.Header().Set("Content-Type", cover.ContentType(bookCover.ContentType, rendition))
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, blob); err != nil {
+		slog.ErrorContext(r.Context(), "serving cover", "err", err)
+	}
+}
+
+func knownSize(name string) bool {
+	for _, s := range cover.Sizes {
+		if s.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// DeleteCover removes a book's cover and its thumbnails.
+func (c *CoverController) DeleteCover(w http.ResponseWriter, r *http.Request) {
+	ID, ok := bookID(w, r)
+	if !ok {
+		return
+	}
+	bookDetails, err := c.Books.Get(ID)
+	if err != nil {
+		respondRepoError(w, r, err)
+		return
+	}
+	if !checkIfMatch(w, r, bookDetails) {
+		return
+	}
+	removed, err := c.Covers.Delete(bookDetails)
+	if errors.Is(err, models.ErrNotFound) {
+		respondRepoErrorFor(w, r, "cover", err)
+		return
+	}
+	if err != nil {
+		// A conflict is on the book, whose version guards its cover.
+		respondRepoError(w, r, err)
+		return
+	}
+	c.deleteBlobs(r, removed.BookID, removed.Digest)
+	w.Header().Set("ETag", bookETag(bookDetails))
+	w.WriteHeader(http.StatusNoContent)
+}
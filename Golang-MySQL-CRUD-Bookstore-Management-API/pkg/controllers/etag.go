@@ -0,0 +1,91 @@
+package controllers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yoloxsta/go-bookstore/pkg/models"
+	"github.com/yoloxsta/go-bookstore/pkg/utils"
+)
+
+// bookETag is a strong validator derived from the book's version, which
+// changes on every update.
+func bookETag(b *models.Book) string {
+	return `"` + strconv.FormatUint(uint64(b.ID), 10) + "." + strconv.FormatUint(uint64(b.Version), 10) + `"`
+}
+
+// expandedETag is the validator of a book sent with ?include. The embedded
+// authors, publisher and stock change without the book's version moving, so
+// a digest of them is appended to the version.
+func expandedETag(b *models.Book) (string, error) {
+	data, err := json.Marshal([]interface{}{b.Authors, b.Publisher, b.Stock})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return strings.TrimSuffix(bookETag(b), `"`) + "-" + hex.EncodeToString(sum[:8]) + `"`, nil
+}
+
+// includeDigest matches the digest expandedETag appends.
+var includeDigest = regexp.MustCompile(`-[0-9a-f]{16}"`)
+
+// etagListMatches reports whether header, a comma-separated If-Match or
+// If-None-Match value, lists etag or "*". weak allows W/ tags to match.
+func etagListMatches(header, etag string, weak bool) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if weak {
+			candidate = strings.TrimPrefix(candidate, "W/")
+		}
+		if candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// checkIfMatch enforces If-Match against the current book, writing 412 when
+// the client's copy is stale. Requests without the header pass. A tag from
+// a read with ?include matches on its version alone, since that is all a
+// write to the book depends on.
+func checkIfMatch(w http.ResponseWriter, r *http.Request, b *models.Book) bool {
+	header := includeDigest.ReplaceAllString(r.Header.Get("If-Match"), `"`)
+	if header == "" || etagListMatches(header, bookETag(b), false) {
+		return true
+	}
+	w.Header().Set("ETag", bookETag(b))
+	utils.RespondError(w, r, http.StatusPreconditionFailed, utils.CodePreconditionFailed,
+		"book has changed since it was fetched; reload and retry")
+	return false
+}
+
+// notModified answers 304 when If-None-Match already names etag, the
+// current representation.
+func notModified(w http.ResponseWriter, r *http.Request, etag string) bool {
+	header := r.Header.Get("If-None-Match")
+	if header == "" || !etagListMatches(header, etag, true) {
+		return false
+	}
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// setCacheControl lets clients and private caches reuse a book read for
+// maxAge, and revalidate it with the ETag after that. Responses may depend
+// on the caller's token, so shared caches must not keep them.
+func setCacheControl(w http.ResponseWriter, maxAge time.Duration) {
+	if maxAge <= 0 {
+		w.Header().Set("Cache-Control", "private, no-cache")
+		return
+	}
+	w.Header().Set("Cache-Control", "private, max-age="+strconv.Itoa(int(maxAge.Seconds())))
+}
@@ -0,0 +1,106 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/yoloxsta/go-bookstore/pkg/models"
+)
+
+// includes lists the related resources a request asked to embed with
+// ?include=authors,publisher,stock.
+type includes struct {
+	Authors   bool
+	Publisher bool
+	Stock     bool
+}
+
+func parseIncludes(r *http.Request) (includes, error) {
+	var inc includes
+	for _, part := range strings.Split(r.URL.Query().Get("include"), ",") {
+		switch strings.TrimSpace(part) {
+		case "":
+		case "authors":
+			inc.Authors = true
+		case "publisher":
+			inc.Publisher = true
+		case "stock":
+			inc.Stock = true
+		default:
+			return inc, fmt.Errorf("cannot include %q; use authors, publisher or stock", part)
+		}
+	}
+	return inc, nil
+}
+
+// expand embeds the requested related resources into books, fetching each
+// kind with one query for the whole slice.
+func (c *BookController) expand(inc includes, books []*models.Book) error {
+	if inc.Authors {
+		var ids []uint
+		for _, b := range books {
+			ids = append(ids, b.AuthorIDs...)
+		}
+		authors, err := c.Authors.GetMany(ids)
+		if err != nil {
+			return err
+		}
+		byID := make(map[uint]models.Author, len(authors))
+		for _, a := range authors {
+			byID[a.ID] = a
+		}
+		for _, b := range books {
+			b.Authors = []models.Author{}
+			for _, id := range b.AuthorIDs {
+				if a, ok := byID[id]; ok {
+					b.Authors = append(b.Authors, a)
+				}
+			}
+		}
+	}
+	if inc.Publisher {
+		var ids []uint
+		for _, b := range books {
+			if b.PublisherID != nil {
+				ids = append(ids, *b.PublisherID)
+			}
+		}
+		publishers, err := c.Publishers.GetMany(ids)
+		if err != nil {
+			return err
+		}
+		byID := make(map[uint]models.Publisher, len(publishers))
+		for _, p := range publishers {
+			byID[p.ID] = p
+		}
+		for _, b := range books {
+			if b.PublisherID == nil {
+				continue
+			}
+			if p, ok := byID[*b.PublisherID]; ok {
+				b.Publisher = &p
+			}
+		}
+	}
+	if inc.Stock {
+		ids := make([]uint, len(books))
+		for i, b := range books {
+			ids[i] = b.ID
+		}
+		levels, err := c.Inventory.GetMany(ids)
+		if err != nil {
+			return err
+		}
+		byID := make(map[uint]models.StockLevel, len(levels))
+		for _, s := range levels {
+			byID[s.BookID] = s
+		}
+		for _, b := range books {
+			if s, ok := byID[b.ID]; ok {
+				b.Stock = &s
+			}
+		}
+	}
+	return nil
+}
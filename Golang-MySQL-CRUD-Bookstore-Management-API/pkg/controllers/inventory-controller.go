@@ -0,0 +1,126 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/yoloxsta/go-bookstore/pkg/models"
+	"github.com/yoloxsta/go-bookstore/pkg/utils"
+	"github.com/yoloxsta/go-bookstore/pkg/validation"
+)
+
+type InventoryController struct {
+	Inventory models.InventoryRepository
+}
+
+func NewInventoryController(inventory models.InventoryRepository) *InventoryController {
+	return &InventoryController{Inventory: inventory}
+}
+
+func (c *InventoryController) GetStock(w http.ResponseWriter, r *http.Request) {
+	ID, ok := bookID(w, r)
+	if !ok {
+		return
+	}
+	stock, err := c.Inventory.Get(ID)
+	if err != nil {
+		respondRepoError(w, r, err)
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, stock)
+}
+
+// stockSettings is the body of PUT /inventory/{bookId}. Quantity is not
+// writable; it only changes through movements.
+type stockSettings struct {
+	LowStockThreshold *int `json:"low_stock_threshold"`
+}
+
+// UpdateStock sets the book's low-stock threshold; null falls back to the
+// configured default.
+func (c *InventoryController) UpdateStock(w http.ResponseWriter, r *http.Request) {
+	ID, ok := bookID(w, r)
+	if !ok {
+		return
+	}
+	var settings stockSettings
+	if err := utils.ParseBody(r, &settings); err != nil {
+		utils.RespondBodyError(w, r, err)
+		return
+	}
+	if settings.LowStockThreshold != nil && *settings.LowStockThreshold < 0 {
+		var errs validation.Errors
+		errs.Add("low_stock_threshold", "must not be negative")
+		utils.RespondValidationError(w, r, errs)
+		return
+	}
+	stock, err := c.Inventory.SetThreshold(ID, settings.LowStockThreshold)
+	if err != nil {
+		respondRepoError(w, r, err)
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, stock)
+}
+
+// CreateMovement records a receipt, sale, adjustment or return and applies
+// it to the stock level. A movement that would leave stock negative is
+// refused with 409.
+func (c *InventoryController) CreateMovement(w http.ResponseWriter, r *http.Request) {
+	ID, ok := bookID(w, r)
+	if !ok {
+		return
+	}
+	movement := &models.StockMovement{}
+	if err := utils.ParseBody(r, movement); err != nil {
+		utils.RespondBodyError(w, r, err)
+		return
+	}
+	if err := movement.Validate(); err != nil {
+		utils.RespondBodyError(w, r, err)
+		return
+	}
+	movement.BookID = uint(ID)
+	err := c.Inventory.Move(movement)
+	if errors.Is(err, models.ErrInsufficientStock) {
+		utils.RespondError(w, r, http.StatusConflict, utils.CodeInsufficientStock,
+			"not enough stock for this movement")
+		return
+	}
+	if err != nil {
+		respondRepoError(w, r, err)
+		return
+	}
+	utils.RespondJSON(w, http.StatusCreated, movement)
+}
+
+// GetMovements pages through the book's ledger, newest first, optionally
+// filtered by ?kind.
+func (c *InventoryController) GetMovements(w http.ResponseWriter, r *http.Request) {
+	ID, ok := bookID(w, r)
+	if !ok {
+		return
+	}
+	nq, err := parseNameQuery(r)
+	if err != nil {
+		utils.RespondError(w, r, http.StatusBadRequest, utils.CodeBadRequest, err.Error())
+		return
+	}
+	q := models.MovementQuery{Limit: nq.Limit, Offset: nq.Offset, Kind: r.URL.Query().Get("kind")}
+	movements, total, err := c.Inventory.Movements(ID, q)
+	if err != nil {
+		respondRepoError(w, r, err)
+		return
+	}
+	w.Header().Set("X-Total-Count", strconv.FormatInt(total, 10))
+	utils.RespondJSON(w, http.StatusOK, movements)
+}
+
+func (c *InventoryController) GetReport(w http.ResponseWriter, r *http.Request) {
+	report, err := c.Inventory.Report()
+	if err != nil {
+		utils.RespondInternalError(w, r, err)
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, report)
+}
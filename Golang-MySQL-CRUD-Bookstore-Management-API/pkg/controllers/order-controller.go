@@ -0,0 +1,270 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/yoloxsta/go-bookstore/pkg/auth"
+	"github.com/yoloxsta/go-bookstore/pkg/models"
+	"github.com/yoloxsta/go-bookstore/pkg/payment"
+	"github.com/yoloxsta/go-bookstore/pkg/utils"
+)
+
+type OrderController struct {
+	Carts    models.CartRepository
+	Orders   models.OrderRepository
+	Payments payment.Provider
+	Currency string
+}
+
+func NewOrderController(store *models.Store, payments payment.Provider, currency string) *OrderController {
+	return &OrderController{Carts: store.Carts, Orders: store.Orders, Payments: payments, Currency: currency}
+}
+
+// CreateOrder checks out a cart: the order is placed as pending and stock
+// for every item is reserved, or nothing happens at all.
+func (c *OrderController) CreateOrder(w http.ResponseWriter, r *http.Request) {
+	if !requireCustomer(w, r) {
+		return
+	}
+	checkout := &models.Checkout{}
+	if err := utils.ParseBody(r, checkout); err != nil {
+		utils.RespondBodyError(w, r, err)
+		return
+	}
+	checkout.Customer = auth.Actor(r.Context())
+	if err := checkout.Validate(); err != nil {
+		utils.RespondBodyError(w, r, err)
+		return
+	}
+	cart, ok := loadCart(w, r, c.Carts, int64(checkout.CartID))
+	if !ok {
+		return
+	}
+	// An editor checking out someone else's cart places the order for them.
+	if cart.Customer != "" {
+		checkout.Customer = cart.Customer
+	}
+	order, err := c.Orders.Checkout(*checkout, c.Currency)
+	var short *models.InsufficientStockError
+	switch {
+	case errors.As(err, &short):
+		utils.RespondError(w, r, http.StatusConflict, utils.CodeInsufficientStock,
+			fmt.Sprintf("not enough stock for book %d", short.BookID))
+	case errors.Is(err, models.ErrEmptyCart):
+		utils.RespondError(w, r, http.StatusUnprocessableEntity, utils.CodeValidation, "cart is empty")
+	case err != nil:
+		respondCartError(w, r, err)
+	default:
+		utils.RespondJSON(w, http.StatusCreated, order)
+	}
+}
+
+// GetOrders is the order history, newest first, filtered by ?status and,
+// for editors, ?customer. Other callers only see their own orders.
+func (c *OrderController) GetOrders(w http.ResponseWriter, r *http.Request) {
+	if !requireCustomer(w, r) {
+		return
+	}
+	nq, err := parseNameQuery(r)
+	if err != nil {
+		utils.RespondError(w, r, http.StatusBadRequest, utils.CodeBadRequest, err.Error())
+		return
+	}
+	v := r.URL.Query()
+	q := models.OrderQuery{Limit: nq.Limit, Offset: nq.Offset, Customer: v.Get("customer"), Status: v.Get("status")}
+	if !auth.Allowed(r.Context(), auth.RoleEditor) {
+		q.Customer = auth.Actor(r.Context())
+	}
+	orders, total, err := c.Orders.List(q)
+	if err != nil {
+		utils.RespondInternalError(w, r, err)
+		return
+	}
+	w.Header().Set("X-Total-Count", strconv.FormatInt(total, 10))
+	utils.RespondJSON(w, http.StatusOK, orders)
+}
+
+func (c *OrderController) GetOrderById(w http.ResponseWriter, r *http.Request) {
+	order, ok := c.order(w, r)
+	if !ok {
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, order)
+}
+
+// GetOrderEvents lists the order's status changes, oldest first.
+func (c *OrderController) GetOrderEvents(w http.ResponseWriter, r *http.Request) {
+	order, ok := c.order(w, r)
+	if !ok {
+		return
+	}
+	events, err := c.Orders.Events(int64(order.ID))
+	if err != nil {
+		respondOrderError(w, r, err)
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, events)
+}
+
+// paymentBody is the body of POST /order/{orderId}/pay.
+type paymentBody struct {
+	PaymentToken string `json:"payment_token"`
+}
+
+// PayOrder charges a pending order. The charge uses the order ID as its
+// idempotency key, so a retried request is not charged twice.
+func (c *OrderController) PayOrder(w http.ResponseWriter, r *http.Request) {
+	order, ok := c.orderInStatus(w, r, models.OrderPending)
+	if !ok {
+		return
+	}
+	var body paymentBody
+	if err := utils.ParseBody(r, &body); err != nil {
+		utils.RespondBodyError(w, r, err)
+		return
+	}
+	charge, err := c.Payments.Charge(r.Context(), payment.ChargeRequest{
+		AmountCents:    order.TotalCents,
+		Currency:       order.Currency,
+		Token:          body.PaymentToken,
+		IdempotencyKey: fmt.Sprintf("order-%d", order.ID),
+	})
+	if errors.Is(err, payment.ErrDeclined) {
+		utils.RespondError(w, r, http.StatusPaymentRequired, utils.CodePaymentDeclined, "payment was declined")
+		return
+	}
+	if err != nil {
+		utils.RespondInternalError(w, r, err)
+		return
+	}
+	paid, err := c.Orders.Transition(int64(order.ID), models.OrderTransition{
+		From: models.OrderPending, To: models.OrderPaid, PaymentID: charge.ID,
+	})
+	if errors.Is(err, models.ErrConflict) {
+		// A concurrent request for the same order got the same charge from
+		// the idempotency key and paid the order with it; this request
+		// succeeded too.
+		current, getErr := c.Orders.Get(int64(order.ID))
+		if getErr != nil {
+			// Without knowing who holds the charge it is not refunded; the
+			// charge is idempotent, so paying again settles it.
+			respondOrderError(w, r, getErr)
+			return
+		}
+		if current.PaymentID == charge.ID {
+			utils.RespondJSON(w, http.StatusOK, current)
+			return
+		}
+	}
+	if err != nil {
+		// The order changed while the charge was in flight, e.g. it was
+		// cancelled; give the money back.
+		if refundErr := c.Payments.Refund(r.Context(), charge.ID); refundErr != nil {
+			err = errors.Join(err, refundErr)
+		}
+		respondOrderError(w, r, err)
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, paid)
+}
+
+func (c *OrderController) ShipOrder(w http.ResponseWriter, r *http.Request) {
+	c.transition(w, r, models.OrderShipped, models.OrderPaid)
+}
+
+// CancelOrder cancels an unpaid order and releases its stock. Paid orders
+// are refunded instead.
+func (c *OrderController) CancelOrder(w http.ResponseWriter, r *http.Request) {
+	c.transition(w, r, models.OrderCancelled, models.OrderPending)
+}
+
+// RefundOrder refunds the payment of a paid or shipped order. Stock is only
+// released when the order had not shipped; returned copies are booked
+// through the inventory ledger.
+//
+// The order is marked refunded before the provider is asked for the money,
+// so a refund is never paid out for an order that stays paid. Provider
+// refunds are idempotent, so if that call fails, refunding the order again
+// retries it.
+func (c *OrderController) RefundOrder(w http.ResponseWriter, r *http.Request) {
+	order, ok := c.orderInStatus(w, r, models.OrderPaid, models.OrderShipped, models.OrderRefunded)
+	if !ok {
+		return
+	}
+	if order.Status != models.OrderRefunded {
+		var err error
+		order, err = c.Orders.Transition(int64(order.ID), models.OrderTransition{From: order.Status, To: models.OrderRefunded})
+		if err != nil {
+			respondOrderError(w, r, err)
+			return
+		}
+	}
+	if order.PaymentID != "" {
+		if err := c.Payments.Refund(r.Context(), order.PaymentID); err != nil {
+			utils.RespondInternalError(w, r, fmt.Errorf("order %d is refunded but its payment is not: %w", order.ID, err))
+			return
+		}
+	}
+	utils.RespondJSON(w, http.StatusOK, order)
+}
+
+// transition moves the order to status to, provided it is currently in one
+// of from.
+func (c *OrderController) transition(w http.ResponseWriter, r *http.Request, to string, from ...string) {
+	order, ok := c.orderInStatus(w, r, from...)
+	if !ok {
+		return
+	}
+	order, err := c.Orders.Transition(int64(order.ID), models.OrderTransition{From: order.Status, To: to})
+	if err != nil {
+		respondOrderError(w, r, err)
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, order)
+}
+
+// order loads the {orderId} order. Another customer's order is reported as
+// missing.
+func (c *OrderController) order(w http.ResponseWriter, r *http.Request) (*models.Order, bool) {
+	if !requireCustomer(w, r) {
+		return nil, false
+	}
+	ID, ok := pathID(w, r, "orderId", "order")
+	if !ok {
+		return nil, false
+	}
+	order, err := c.Orders.Get(ID)
+	if err == nil && !owns(r, order.Customer) {
+		err = models.ErrNotFound
+	}
+	if err != nil {
+		respondOrderError(w, r, err)
+		return nil, false
+	}
+	return order, true
+}
+
+// orderInStatus loads the {orderId} order and checks that it is in one of
+// statuses, writing a 409 when it is not.
+func (c *OrderController) orderInStatus(w http.ResponseWriter, r *http.Request, statuses ...string) (*models.Order, bool) {
+	order, ok := c.order(w, r)
+	if !ok {
+		return nil, false
+	}
+	for _, s := range statuses {
+		if order.Status == s {
+			return order, true
+		}
+	}
+	utils.RespondError(w, r, http.StatusConflict, utils.CodeConflict,
+		fmt.Sprintf("order is %s; this action needs it to be %s", order.Status, strings.Join(statuses, " or ")))
+	return nil, false
+}
+
+func respondOrderError(w http.ResponseWriter, r *http.Request, err error) {
+	respondRepoErrorFor(w, r, "order", err)
+}
@@ -0,0 +1,112 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/yoloxsta/go-bookstore/pkg/models"
+)
+
+// parseBookQuery reads ?limit, offset, cursor, name, author, publication,
+// author_id, publisher_id and sort from the request.
+func parseBookQuery(r *http.Request) (models.BookQuery, error) {
+	v := r.URL.Query()
+	q := models.BookQuery{
+		Cursor:      v.Get("cursor"),
+		Name:        v.Get("name"),
+		Author:      v.Get("author"),
+		Publication: v.Get("publication"),
+	}
+	var err error
+	if s := v.Get("limit"); s != "" {
+		if q.Limit, err = strconv.Atoi(s); err != nil || q.Limit <= 0 {
+			return q, fmt.Errorf("limit must be a positive integer")
+		}
+	}
+	if s := v.Get("offset"); s != "" {
+		if q.Offset, err = strconv.Atoi(s); err != nil || q.Offset < 0 {
+			return q, fmt.Errorf("offset must be a non-negative integer")
+		}
+	}
+	for key, dst := range map[string]*uint{"author_id": &q.AuthorID, "publisher_id": &q.PublisherID} {
+		if s := v.Get(key); s != "" {
+			id, err := strconv.ParseUint(s, 10, 32)
+			if err != nil || id == 0 {
+				return q, fmt.Errorf("%s must be a positive integer", key)
+			}
+			*dst = uint(id)
+		}
+	}
+	if q.Sort, err = models.ParseSort(v.Get("sort")); err != nil {
+		return q, err
+	}
+	return q, nil
+}
+
+// setPageHeaders writes X-Total-Count and an RFC 8288 Link header. Requests
+// that pass ?offset get offset-based prev/next links; all others get a cursor
+// next link.
+func setPageHeaders(w http.ResponseWriter, r *http.Request, q models.BookQuery, page models.BookPage) {
+	w.Header().Set("X-Total-Count", strconv.FormatInt(page.Total, 10))
+
+	limit := q.PageSize()
+	link := func(rel string, set map[string]string) string {
+		u := *r.URL
+		params := u.Query()
+		params.Set("limit", strconv.Itoa(limit))
+		params.Del("offset")
+		params.Del("cursor")
+		for k, v := range set {
+			params.Set(k, v)
+		}
+		u.RawQuery = params.Encode()
+		return fmt.Sprintf("<%s>; rel=%q", requestURL(r, &u), rel)
+	}
+
+	links := []string{link("first", nil)}
+	if r.URL.Query().Has("offset") {
+		if q.Offset > 0 {
+			prev := q.Offset - limit
+			if prev < 0 {
+				prev = 0
+			}
+			links = append(links, link("prev", map[string]string{"offset": strconv.Itoa(prev)}))
+		}
+		if int64(q.Offset+limit) < page.Total {
+			links = append(links, link("next", map[string]string{"offset": strconv.Itoa(q.Offset + limit)}))
+		}
+	} else if page.NextCursor != "" {
+		links = append(links, link("next", map[string]string{"cursor": page.NextCursor}))
+	}
+	w.Header().Set("Link", strings.Join(links, ", "))
+}
+
+func requestURL(r *http.Request, u *url.URL) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host + u.RequestURI()
+}
+
+// parseNameQuery reads ?limit, offset and name for the author and publisher
+// listings.
+func parseNameQuery(r *http.Request) (models.NameQuery, error) {
+	v := r.URL.Query()
+	q := models.NameQuery{Name: v.Get("name")}
+	var err error
+	if s := v.Get("limit"); s != "" {
+		if q.Limit, err = strconv.Atoi(s); err != nil || q.Limit <= 0 {
+			return q, fmt.Errorf("limit must be a positive integer")
+		}
+	}
+	if s := v.Get("offset"); s != "" {
+		if q.Offset, err = strconv.Atoi(s); err != nil || q.Offset < 0 {
+			return q, fmt.Errorf("offset must be a non-negative integer")
+		}
+	}
+	return q, nil
+}
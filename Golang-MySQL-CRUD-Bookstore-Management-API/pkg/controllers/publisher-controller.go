@@ -0,0 +1,99 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/yoloxsta/go-bookstore/pkg/models"
+	"github.com/yoloxsta/go-bookstore/pkg/utils"
+)
+
+type PublisherController struct {
+	Publishers models.PublisherRepository
+}
+
+func NewPublisherController(publishers models.PublisherRepository) *PublisherController {
+	return &PublisherController{Publishers: publishers}
+}
+
+func (c *PublisherController) GetPublishers(w http.ResponseWriter, r *http.Request) {
+	q, err := parseNameQuery(r)
+	if err != nil {
+		utils.RespondError(w, r, http.StatusBadRequest, utils.CodeBadRequest, err.Error())
+		return
+	}
+	publishers, total, err := c.Publishers.List(q)
+	if err != nil {
+		utils.RespondInternalError(w, r, err)
+		return
+	}
+	w.Header().Set("X-Total-Count", strconv.FormatInt(total, 10))
+	utils.RespondJSON(w, http.StatusOK, publishers)
+}
+
+func (c *PublisherController) GetPublisherById(w http.ResponseWriter, r *http.Request) {
+	ID, ok := pathID(w, r, "publisherId", "publisher")
+	if !ok {
+		return
+	}
+	publisher, err := c.Publishers.Get(ID)
+	if err != nil {
+		respondRepoErrorFor(w, r, "publisher", err)
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, publisher)
+}
+
+func (c *PublisherController) CreatePublisher(w http.ResponseWriter, r *http.Request) {
+	publisher := &models.Publisher{}
+	if err := utils.ParseBody(r, publisher); err != nil {
+		utils.RespondBodyError(w, r, err)
+		return
+	}
+	publisher.ID = 0
+	if err := publisher.Validate(); err != nil {
+		utils.RespondBodyError(w, r, err)
+		return
+	}
+	if err := c.Publishers.Create(publisher); err != nil {
+		utils.RespondInternalError(w, r, err)
+		return
+	}
+	utils.RespondJSON(w, http.StatusCreated, publisher)
+}
+
+func (c *PublisherController) UpdatePublisher(w http.ResponseWriter, r *http.Request) {
+	ID, ok := pathID(w, r, "publisherId", "publisher")
+	if !ok {
+		return
+	}
+	publisher := &models.Publisher{}
+	if err := utils.ParseBody(r, publisher); err != nil {
+		utils.RespondBodyError(w, r, err)
+		return
+	}
+	if err := publisher.Validate(); err != nil {
+		utils.RespondBodyError(w, r, err)
+		return
+	}
+	publisher.ID = uint(ID)
+	if err := c.Publishers.Update(publisher); err != nil {
+		respondRepoErrorFor(w, r, "publisher", err)
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, publisher)
+}
+
+// DeletePublisher refuses with 409 while any book, including one in the trash,
+// still names the publisher.
+func (c *PublisherController) DeletePublisher(w http.ResponseWriter, r *http.Request) {
+	ID, ok := pathID(w, r, "publisherId", "publisher")
+	if !ok {
+		return
+	}
+	if err := c.Publishers.Delete(ID); err != nil {
+		respondRepoErrorFor(w, r, "publisher", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,156 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/yoloxsta/go-bookstore/pkg/auth"
+	"github.com/yoloxsta/go-bookstore/pkg/models"
+	"github.com/yoloxsta/go-bookstore/pkg/utils"
+)
+
+type ReviewController struct {
+	Reviews models.ReviewRepository
+	Books   models.BookRepository
+	// AutoApprove publishes new reviews without waiting for a moderator.
+	AutoApprove bool
+}
+
+func NewReviewController(reviews models.ReviewRepository, books models.BookRepository, autoApprove bool) *ReviewController {
+	return &ReviewController{Reviews: reviews, Books: books, AutoApprove: autoApprove}
+}
+
+// CreateReview posts the caller's review of a book. Unlike other POSTs it
+// only needs the reader role; the reviewer is the token's subject, and a
+// second review of the same book gets 409.
+func (c *ReviewController) CreateReview(w http.ResponseWriter, r *http.Request) {
+	if !auth.Allowed(r.Context(), auth.RoleReader) {
+		utils.RespondError(w, r, http.StatusForbidden, utils.CodeForbidden, "reviewing requires the reader role")
+		return
+	}
+	ID, ok := bookID(w, r)
+	if !ok {
+		return
+	}
+	review := &models.Review{}
+	if err := utils.ParseBody(r, review); err != nil {
+		utils.RespondBodyError(w, r, err)
+		return
+	}
+	if err := review.Validate(); err != nil {
+		utils.RespondBodyError(w, r, err)
+		return
+	}
+	review.BookID = uint(ID)
+	review.Reviewer = auth.Actor(r.Context())
+	review.Status = models.ReviewPending
+	if c.AutoApprove {
+		review.Status = models.ReviewApproved
+	}
+	if err := c.Reviews.WithAudit(auditInfo(r, "")).Create(review); err != nil {
+		respondReviewError(w, r, "book", err)
+		return
+	}
+	utils.RespondJSON(w, http.StatusCreated, review)
+}
+
+// GetReviews lists a book's approved reviews, newest first. Editors may
+// pass ?status=pending or rejected to work through the moderation queue.
+func (c *ReviewController) GetReviews(w http.ResponseWriter, r *http.Request) {
+	ID, ok := bookID(w, r)
+	if !ok {
+		return
+	}
+	nq, err := parseNameQuery(r)
+	if err != nil {
+		utils.RespondError(w, r, http.StatusBadRequest, utils.CodeBadRequest, err.Error())
+		return
+	}
+	status := r.URL.Query().Get("status")
+	switch {
+	case status == "":
+		status = models.ReviewApproved
+	case !models.IsReviewStatus(status):
+		utils.RespondError(w, r, http.StatusBadRequest, utils.CodeBadRequest,
+			"status must be one of pending, approved or rejected")
+		return
+	case status != models.ReviewApproved && !auth.Allowed(r.Context(), auth.RoleEditor):
+		utils.RespondError(w, r, http.StatusForbidden, utils.CodeForbidden,
+			"listing "+status+" reviews requires the editor role")
+		return
+	}
+	if _, err := c.Books.Get(ID); err != nil {
+		respondRepoError(w, r, err)
+		return
+	}
+	reviews, total, err := c.Reviews.List(models.ReviewQuery{BookID: uint(ID), Status: status, Limit: nq.Limit, Offset: nq.Offset})
+	if err != nil {
+		utils.RespondInternalError(w, r, err)
+		return
+	}
+	w.Header().Set("X-Total-Count", strconv.FormatInt(total, 10))
+	utils.RespondJSON(w, http.StatusOK, reviews)
+}
+
+// GetReview returns one review. Until it is approved only its reviewer and
+// editors can see it.
+func (c *ReviewController) GetReview(w http.ResponseWriter, r *http.Request) {
+	bookID, reviewID, ok := reviewIDs(w, r)
+	if !ok {
+		return
+	}
+	review, err := c.Reviews.Get(bookID, reviewID)
+	if err == nil && review.Status != models.ReviewApproved &&
+		review.Reviewer != auth.Actor(r.Context()) && !auth.Allowed(r.Context(), auth.RoleEditor) {
+		err = models.ErrNotFound
+	}
+	if err != nil {
+		respondReviewError(w, r, "review", err)
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, review)
+}
+
+// ApproveReview publishes a review and counts it toward the book's rating.
+func (c *ReviewController) ApproveReview(w http.ResponseWriter, r *http.Request) {
+	c.moderate(w, r, models.ReviewApproved)
+}
+
+// RejectReview hides a review, taking it out of the book's rating if it was
+// approved.
+func (c *ReviewController) RejectReview(w http.ResponseWriter, r *http.Request) {
+	c.moderate(w, r, models.ReviewRejected)
+}
+
+func (c *ReviewController) moderate(w http.ResponseWriter, r *http.Request, status string) {
+	bookID, reviewID, ok := reviewIDs(w, r)
+	if !ok {
+		return
+	}
+	review, err := c.Reviews.WithAudit(auditInfo(r, "")).Moderate(bookID, reviewID, status)
+	if err != nil {
+		respondReviewError(w, r, "review", err)
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, review)
+}
+
+func reviewIDs(w http.ResponseWriter, r *http.Request) (int64, int64, bool) {
+	bookID, ok := bookID(w, r)
+	if !ok {
+		return 0, 0, false
+	}
+	reviewID, ok := pathID(w, r, "reviewId", "review")
+	return bookID, reviewID, ok
+}
+
+// respondReviewError reports ErrNotFound against resource, the book or the
+// review.
+func respondReviewError(w http.ResponseWriter, r *http.Request, resource string, err error) {
+	if errors.Is(err, models.ErrDuplicateReview) {
+		utils.RespondError(w, r, http.StatusConflict, utils.CodeConflict, "you have already reviewed this book")
+		return
+	}
+	respondRepoErrorFor(w, r, resource, err)
+}
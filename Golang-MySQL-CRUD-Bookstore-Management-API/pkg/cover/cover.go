@@ -0,0 +1,131 @@
+// Package cover checks uploaded cover images and renders their thumbnails.
+package cover
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// MaxPixels bounds the decoded size of an upload, so a small file cannot
+// expand into gigabytes of pixels.
+const MaxPixels = 40_000_000
+
+var (
+	ErrUnsupportedType = errors.New("cover must be a JPEG, PNG, GIF or WebP image")
+	ErrInvalid         = errors.New("cover image is corrupt or too large")
+)
+
+// Size is one thumbnail width; heights keep the aspect ratio.
+type Size struct {
+	Name  string
+	Width int
+}
+
+// Sizes are rendered for every cover. Images narrower than a size are not
+// enlarged.
+var Sizes = []Size{
+	{Name: "small", Width: 160},
+	{Name: "medium", Width: 480},
+}
+
+// Image is an encoded image ready to be stored.
+type Image struct {
+	ContentType   string
+	Width, Height int
+	Data          []byte
+}
+
+// Process checks that data is a supported image and renders one thumbnail
+// per entry of Sizes, keyed by name. Thumbnails of images that may be
+// transparent are PNGs, the rest JPEGs.
+func Process(data []byte) (Image, map[string]Image, error) {
+	contentType := http.DetectContentType(data)
+	switch contentType {
+	case "image/jpeg", "image/png", "image/gif", "image/webp":
+	default:
+		return Image{}, nil, ErrUnsupportedType
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > MaxPixels {
+		return Image{}, nil, ErrInvalid
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return Image{}, nil, ErrInvalid
+	}
+	original := Image{ContentType: contentType, Width: cfg.Width, Height: cfg.Height, Data: data}
+
+	opaque := contentType == "image/jpeg"
+	thumbs := make(map[string]Image, len(Sizes))
+	for _, size := range Sizes {
+		thumb, err := render(src, size.Width, opaque)
+		if err != nil {
+			return Image{}, nil, fmt.Errorf("cover: rendering %s thumbnail: %w", size.Name, err)
+		}
+		thumbs[size.Name] = thumb
+	}
+	return original, thumbs, nil
+}
+
+func render(src image.Image, width int, opaque bool) (Image, error) {
+	b := src.Bounds()
+	if width > b.Dx() {
+		width = b.Dx()
+	}
+	height := max(1, b.Dy()*width/b.Dx())
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	if opaque {
+		draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	}
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, b, draw.Over, nil)
+
+	var buf bytes.Buffer
+	img := Image{Width: width, Height: height}
+	var err error
+	if opaque {
+		img.ContentType = "image/jpeg"
+		err = jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 85})
+	} else {
+		img.ContentType = "image/png"
+		err = png.Encode(&buf, dst)
+	}
+	img.Data = buf.Bytes()
+	return img, err
+}
+
+// Key is the storage key of one rendition of a cover: "original" or the name
+// of a Size.
+func Key(bookID uint, digest, rendition string) string {
+	return fmt.Sprintf("covers/%d/%s/%s", bookID, digest, rendition)
+}
+
+// Renditions lists "original" followed by every thumbnail name.
+func Renditions() []string {
+	names := []string{"original"}
+	for _, s := range Sizes {
+		names = append(names, s.Name)
+	}
+	return names
+}
+
+// ContentType is the media type a rendition of a cover with the given
+// original type is stored as.
+func ContentType(original, rendition string) string {
+	switch {
+	case rendition == "original":
+		return original
+	case original == "image/jpeg":
+		return "image/jpeg"
+	}
+	return "image/png"
+}
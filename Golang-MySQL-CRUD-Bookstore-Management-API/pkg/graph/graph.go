@@ -0,0 +1,113 @@
+// Package graph serves the catalog over GraphQL at /graphql, on top of the
+// same repositories as the REST controllers.
+package graph
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/yoloxsta/go-bookstore/pkg/auth"
+	"github.com/yoloxsta/go-bookstore/pkg/models"
+	"github.com/yoloxsta/go-bookstore/pkg/utils"
+	"github.com/yoloxsta/go-bookstore/pkg/validation"
+)
+
+//go:embed schema.graphql
+var schemaSource string
+
+// maxDepth bounds how deeply a query may nest selections.
+const maxDepth = 10
+
+// Handler executes GraphQL requests. Queries need the reader role; each
+// mutation checks the role its REST counterpart needs.
+type Handler struct {
+	store  *models.Store
+	schema *graphql.Schema
+}
+
+func NewHandler(store *models.Store) *Handler {
+	return &Handler{
+		store:  store,
+		schema: graphql.MustParseSchema(schemaSource, &resolver{store: store}, graphql.MaxDepth(maxDepth)),
+	}
+}
+
+type request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// ServeHTTP accepts a POST with a JSON body of query, operationName and
+// variables. Errors while executing the query come back with status 200 in
+// the GraphQL errors list; only a request that cannot be read gets one of
+// the usual error responses.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !auth.Allowed(r.Context(), auth.RoleReader) {
+		utils.RespondError(w, r, http.StatusForbidden, utils.CodeForbidden, "GraphQL requires the reader role")
+		return
+	}
+	var req request
+	if err := utils.ParseBody(r, &req); err != nil {
+		utils.RespondBodyError(w, r, err)
+		return
+	}
+	if req.Query == "" {
+		var errs validation.Errors
+		errs.Add("query", "is required")
+		utils.RespondValidationError(w, r, errs)
+		return
+	}
+	ctx := context.WithValue(r.Context(), loadersKey{}, newLoaders(h.store))
+	res := h.schema.Exec(ctx, req.Query, req.OperationName, req.Variables)
+	utils.RespondJSON(w, http.StatusOK, res)
+}
+
+// gqlError carries one of the REST error codes, and any field errors, in
+// the GraphQL error's extensions.
+type gqlError struct {
+	code    string
+	message string
+	fields  validation.Errors
+}
+
+func (e *gqlError) Error() string { return e.message }
+
+func (e *gqlError) Extensions() map[string]interface{} {
+	ext := map[string]interface{}{"code": e.code}
+	if len(e.fields) > 0 {
+		ext["fields"] = e.fields
+	}
+	return ext
+}
+
+// resolveError maps repository errors the way respondRepoErrorFor does for
+// REST, logging and hiding anything unexpected.
+func resolveError(ctx context.Context, resource string, err error) error {
+	var fieldErrs validation.Errors
+	switch {
+	case errors.As(err, &fieldErrs):
+		return &gqlError{utils.CodeValidation, "input failed validation", fieldErrs}
+	case errors.Is(err, models.ErrNotFound):
+		return &gqlError{code: utils.CodeNotFound, message: resource + " not found"}
+	case errors.Is(err, models.ErrInUse):
+		return &gqlError{code: utils.CodeConflict, message: resource + " is still referenced by books"}
+	case errors.Is(err, models.ErrConflict):
+		return &gqlError{code: utils.CodeConflict, message: resource + " was changed by another request; reload and retry"}
+	case errors.Is(err, models.ErrInvalidQuery):
+		return &gqlError{code: utils.CodeBadRequest, message: err.Error()}
+	}
+	slog.ErrorContext(ctx, "internal error", "err", err)
+	return &gqlError{code: utils.CodeInternal, message: "internal server error"}
+}
+
+func requireRole(ctx context.Context, need auth.Role) error {
+	if !auth.Allowed(ctx, need) {
+		return &gqlError{code: utils.CodeForbidden, message: "this mutation requires the " + need.String() + " role"}
+	}
+	return nil
+}
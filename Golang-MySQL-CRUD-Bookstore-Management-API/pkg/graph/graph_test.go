@@ -0,0 +1,272 @@
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/yoloxsta/go-bookstore/pkg/auth"
+	"github.com/yoloxsta/go-bookstore/pkg/config"
+	"github.com/yoloxsta/go-bookstore/pkg/models"
+)
+
+// calls records the keys of every batched repository call by name.
+type calls struct {
+	mu      sync.Mutex
+	batches map[string][][]uint
+}
+
+func (c *calls) record(name string, ids []uint) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	sorted := append([]uint(nil), ids...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	c.batches[name] = append(c.batches[name], sorted)
+}
+
+func (c *calls) get(name string) [][]uint {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.batches[name]
+}
+
+type countingReviews struct {
+	models.ReviewRepository
+	calls *calls
+}
+
+func (r countingReviews) ListMany(ids []uint, q models.ReviewQuery) (map[uint]models.ReviewPage, error) {
+	r.calls.record("reviews", ids)
+	return r.ReviewRepository.ListMany(ids, q)
+}
+
+type countingAuthors struct {
+	models.AuthorRepository
+	calls *calls
+}
+
+func (r countingAuthors) GetMany(ids []uint) ([]models.Author, error) {
+	r.calls.record("authors", ids)
+	return r.AuthorRepository.GetMany(ids)
+}
+
+type countingPublishers struct {
+	models.PublisherRepository
+	calls *calls
+}
+
+func (r countingPublishers) GetMany(ids []uint) ([]models.Publisher, error) {
+	r.calls.record("publishers", ids)
+	return r.PublisherRepository.GetMany(ids)
+}
+
+type countingInventory struct {
+	models.InventoryRepository
+	calls *calls
+}
+
+func (r countingInventory) GetMany(ids []uint) ([]models.StockLevel, error) {
+	r.calls.record("stock", ids)
+	return r.InventoryRepository.GetMany(ids)
+}
+
+// newTestStore is a memory store whose batched lookups are recorded.
+func newTestStore(t *testing.T) (*models.Store, *calls) {
+	t.Helper()
+	store := models.NewMemoryStore(config.Default().Inventory)
+	c := &calls{batches: map[string][][]uint{}}
+	store.Authors = countingAuthors{store.Authors, c}
+	store.Publishers = countingPublishers{store.Publishers, c}
+	store.Inventory = countingInventory{store.Inventory, c}
+	store.Reviews = countingReviews{store.Reviews, c}
+	return store, c
+}
+
+type gqlResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message    string                 `json:"message"`
+		Extensions map[string]interface{} `json:"extensions"`
+	} `json:"errors"`
+}
+
+// exec runs query as an anonymous admin and decodes the data into v.
+func exec(t *testing.T, store *models.Store, query string, v interface{}) gqlResponse {
+	t.Helper()
+	body, _ := json.Marshal(map[string]string{"query": query})
+	req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(string(body)))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	auth.Anonymous(NewHandler(store)).ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d; body %s", rec.Code, rec.Body)
+	}
+	var res gqlResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	if v != nil && len(res.Errors) == 0 {
+		if err := json.Unmarshal(res.Data, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return res
+}
+
+func createBooks(t *testing.T, store *models.Store, n int) {
+	t.Helper()
+	for i := 1; i <= n; i++ {
+		b := &models.Book{Name: fmt.Sprintf("Book %d", i), Author: fmt.Sprintf("Author %d and Shared Author", i), Publication: "Press"}
+		if err := store.Books.Create(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestBookReviewsAreBatched(t *testing.T) {
+	store, c := newTestStore(t)
+	createBooks(t, store, 3)
+	for book := uint(1); book <= 3; book++ {
+		for i := uint(0); i < book; i++ {
+			rv := &models.Review{BookID: book, Reviewer: fmt.Sprintf("r%d", i), Rating: 4, Status: models.ReviewApproved, Title: "Good"}
+			if err := store.Reviews.Create(rv); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	pending := &models.Review{BookID: 3, Reviewer: "late", Rating: 1, Status: models.ReviewPending}
+	if err := store.Reviews.Create(pending); err != nil {
+		t.Fatal(err)
+	}
+
+	var data struct {
+		Books struct {
+			Books []struct {
+				ID      string
+				Reviews struct {
+					Total   int
+					Reviews []struct{ Reviewer string }
+				}
+			}
+		}
+	}
+	res := exec(t, store, `{ books(sort: "id") { books { id reviews(limit: 2) { total reviews { reviewer } } } } }`, &data)
+	if len(res.Errors) > 0 {
+		t.Fatalf("errors: %+v", res.Errors)
+	}
+	var got []string
+	for _, b := range data.Books.Books {
+		var names []string
+		for _, rv := range b.Reviews.Reviews {
+			names = append(names, rv.Reviewer)
+		}
+		got = append(got, fmt.Sprintf("%s:%d:%s", b.ID, b.Reviews.Total, strings.Join(names, ",")))
+	}
+	if want := "1:1:r0 2:2:r1,r0 3:3:r2,r1"; strings.Join(got, " ") != want {
+		t.Errorf("reviews = %v, want %s", got, want)
+	}
+	if batches := c.get("reviews"); fmt.Sprint(batches) != "[[1 2 3]]" {
+		t.Errorf("review lookups = %v, want one batch of every book", batches)
+	}
+
+	res = exec(t, store, `{ book(id: 1) { reviews(limit: 0) { total } } }`, nil)
+	if len(res.Errors) != 1 || res.Errors[0].Extensions["code"] != "bad_request" {
+		t.Errorf("limit 0: errors %+v, want bad_request", res.Errors)
+	}
+}
+
+func TestNestedQueryBatchesLookups(t *testing.T) {
+	store, c := newTestStore(t)
+	createBooks(t, store, 5)
+	for id := int64(1); id <= 5; id++ {
+		err := store.Inventory.Move(&models.StockMovement{BookID: uint(id), Kind: models.MovementReceive, Quantity: int(id)})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var data struct {
+		Books struct {
+			Total int
+			Books []struct {
+				Name      string
+				Authors   []struct{ Name string }
+				Publisher *struct{ Name string }
+				Stock     *struct{ Quantity int }
+			}
+		}
+	}
+	res := exec(t, store, `{ books(limit: 4, sort: "id") { total books {
+		name authors { name } publisher { name } stock { quantity } } } }`, &data)
+	if len(res.Errors) > 0 {
+		t.Fatalf("errors: %+v", res.Errors)
+	}
+	if data.Books.Total != 5 || len(data.Books.Books) != 4 {
+		t.Fatalf("books = %+v", data.Books)
+	}
+	for i, b := range data.Books.Books {
+		if len(b.Authors) != 2 || b.Authors[0].Name != fmt.Sprintf("Author %d", i+1) || b.Authors[1].Name != "Shared Author" ||
+			b.Publisher == nil || b.Publisher.Name != "Press" || b.Stock == nil || b.Stock.Quantity != i+1 {
+			t.Errorf("book %d = %+v", i+1, b)
+		}
+	}
+
+	// Authors get IDs as they are first credited: Author 1 is 1, Shared
+	// Author 2, and Author 2 to 4 are 3 to 5. The fifth book is not on the
+	// page, so its author, 6, is not looked up.
+	for name, want := range map[string]string{
+		"authors":    "[[1 2 3 4 5]]",
+		"publishers": "[[1]]",
+		"stock":      "[[1 2 3 4]]",
+	} {
+		if got := fmt.Sprint(c.get(name)); got != want {
+			t.Errorf("%s lookups = %s, want one batch %s", name, got, want)
+		}
+	}
+}
+
+func TestCreateBookMutation(t *testing.T) {
+	store, _ := newTestStore(t)
+	var data struct {
+		CreateBook struct {
+			ID      string
+			Version int
+			Authors []struct{ Name string }
+		}
+	}
+	res := exec(t, store, `mutation { createBook(input: {name: "Dune", author: "Frank Herbert", isbn13: "978-0-441-01359-3", priceCents: 999}) {
+		id version authors { name } } }`, &data)
+	if len(res.Errors) > 0 {
+		t.Fatalf("errors: %+v", res.Errors)
+	}
+	if data.CreateBook.ID != "1" || data.CreateBook.Version != 1 || len(data.CreateBook.Authors) != 1 {
+		t.Fatalf("createBook = %+v", data.CreateBook)
+	}
+
+	// The book went through the repository: it is stored with its ISBN
+	// normalized, and audited.
+	b, err := store.Books.GetByISBN("9780441013593")
+	if err != nil || b.Name != "Dune" || b.PriceCents != 999 {
+		t.Fatalf("stored book = %+v, %v", b, err)
+	}
+	history, total, err := store.Audit.History(1, models.AuditQuery{})
+	if err != nil || total != 1 || history[0].Action != models.AuditCreate || history[0].Actor != auth.AnonymousActor {
+		t.Errorf("history = %+v (%d), %v", history, total, err)
+	}
+
+	// Model validation and uniqueness apply as they do over REST.
+	res = exec(t, store, `mutation { createBook(input: {name: "", isbn13: "9780441013593"}) { id } }`, nil)
+	if len(res.Errors) != 1 || res.Errors[0].Extensions["code"] != "validation_failed" {
+		t.Fatalf("invalid input: errors %+v", res.Errors)
+	}
+	res = exec(t, store, `mutation { createBook(input: {name: "Dune again", author: "X", isbn13: "9780441013593"}) { id } }`, nil)
+	if len(res.Errors) != 1 || res.Errors[0].Extensions["code"] != "validation_failed" ||
+		!strings.Contains(fmt.Sprint(res.Errors[0].Extensions["fields"]), "isbn13") {
+		t.Errorf("duplicate ISBN: errors %+v", res.Errors)
+	}
+}
@@ -0,0 +1,133 @@
+package graph
+
+import (
+	"context"
+	"sync"
+
+	"github.com/yoloxsta/go-bookstore/pkg/models"
+)
+
+// loader batches lookups by key for one request. Resolvers prime it with
+// every key a page of results may need; the first load then fetches all
+// pending keys in one call, and later loads are served from what it found.
+type loader[K comparable, V any] struct {
+	fetch func(keys []K) (map[K]V, error)
+
+	mu      sync.Mutex
+	pending map[K]bool
+	done    map[K]result[V]
+}
+
+type result[V any] struct {
+	value V
+	found bool
+	err   error
+}
+
+func newLoader[K comparable, V any](fetch func(keys []K) (map[K]V, error)) *loader[K, V] {
+	return &loader[K, V]{fetch: fetch, pending: map[K]bool{}, done: map[K]result[V]{}}
+}
+
+// prime queues keys for the next fetch.
+func (l *loader[K, V]) prime(keys ...K) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, k := range keys {
+		if _, ok := l.done[k]; !ok {
+			l.pending[k] = true
+		}
+	}
+}
+
+// load returns the value for k, and false when the fetch did not find it.
+func (l *loader[K, V]) load(k K) (V, bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if res, ok := l.done[k]; ok {
+		return res.value, res.found, res.err
+	}
+	l.pending[k] = true
+	keys := make([]K, 0, len(l.pending))
+	for key := range l.pending {
+		keys = append(keys, key)
+	}
+	clear(l.pending)
+	found, err := l.fetch(keys)
+	for _, key := range keys {
+		v, ok := found[key]
+		l.done[key] = result[V]{v, ok, err}
+	}
+	res := l.done[k]
+	return res.value, res.found, res.err
+}
+
+// reviewsKey names one page of a book's approved reviews.
+type reviewsKey struct {
+	bookID        uint
+	limit, offset int
+}
+
+// loaders holds the per-request loaders for everything a book links to.
+type loaders struct {
+	authors    *loader[uint, models.Author]
+	publishers *loader[uint, models.Publisher]
+	stock      *loader[uint, models.StockLevel]
+	reviews    *loader[reviewsKey, models.ReviewPage]
+}
+
+func newLoaders(store *models.Store) *loaders {
+	return &loaders{
+		authors: newLoader(func(ids []uint) (map[uint]models.Author, error) {
+			authors, err := store.Authors.GetMany(ids)
+			byID := make(map[uint]models.Author, len(authors))
+			for _, a := range authors {
+				byID[a.ID] = a
+			}
+			return byID, err
+		}),
+		publishers: newLoader(func(ids []uint) (map[uint]models.Publisher, error) {
+			publishers, err := store.Publishers.GetMany(ids)
+			byID := make(map[uint]models.Publisher, len(publishers))
+			for _, p := range publishers {
+				byID[p.ID] = p
+			}
+			return byID, err
+		}),
+		stock: newLoader(func(ids []uint) (map[uint]models.StockLevel, error) {
+			levels, err := store.Inventory.GetMany(ids)
+			byID := make(map[uint]models.StockLevel, len(levels))
+			for _, s := range levels {
+				byID[s.BookID] = s
+			}
+			return byID, err
+		}),
+		reviews: newLoader(func(keys []reviewsKey) (map[reviewsKey]models.ReviewPage, error) {
+			// Keys normally share one page size; each distinct one is a
+			// query of its own.
+			byPage := map[reviewsKey][]uint{}
+			for _, k := range keys {
+				page := reviewsKey{limit: k.limit, offset: k.offset}
+				byPage[page] = append(byPage[page], k.bookID)
+			}
+			found := make(map[reviewsKey]models.ReviewPage, len(keys))
+			for page, ids := range byPage {
+				pages, err := store.Reviews.ListMany(ids, models.ReviewQuery{
+					Status: models.ReviewApproved, Limit: page.limit, Offset: page.offset,
+				})
+				if err != nil {
+					return found, err
+				}
+				for id, p := range pages {
+					found[reviewsKey{id, page.limit, page.offset}] = p
+				}
+			}
+			return found, nil
+		}),
+	}
+}
+
+type loadersKey struct{}
+
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
@@ -0,0 +1,345 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/yoloxsta/go-bookstore/pkg/auth"
+	"github.com/yoloxsta/go-bookstore/pkg/isbn"
+	"github.com/yoloxsta/go-bookstore/pkg/middleware"
+	"github.com/yoloxsta/go-bookstore/pkg/models"
+	"github.com/yoloxsta/go-bookstore/pkg/utils"
+	"github.com/yoloxsta/go-bookstore/pkg/validation"
+)
+
+// resolver is the root of both queries and mutations.
+type resolver struct {
+	store *models.Store
+}
+
+func (r *resolver) Book(ctx context.Context, args struct{ ID graphql.ID }) (*bookResolver, error) {
+	id, err := parseID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+	return r.book(ctx, func() (*models.Book, error) { return r.store.Books.Get(id) })
+}
+
+func (r *resolver) BookByIsbn(ctx context.Context, args struct{ ISBN string }) (*bookResolver, error) {
+	n, err := isbn.Normalize(args.ISBN)
+	if err != nil {
+		return nil, &gqlError{code: utils.CodeBadRequest, message: err.Error()}
+	}
+	return r.book(ctx, func() (*models.Book, error) { return r.store.Books.GetByISBN(n) })
+}
+
+// book resolves a single book, or null when it does not exist.
+func (r *resolver) book(ctx context.Context, get func() (*models.Book, error)) (*bookResolver, error) {
+	b, err := get()
+	if errors.Is(err, models.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, resolveError(ctx, "book", err)
+	}
+	return newBookResolvers(ctx, []models.Book{*b})[0], nil
+}
+
+type booksArgs struct {
+	Limit       *int32
+	Offset      *int32
+	Cursor      *string
+	Sort        *string
+	Name        *string
+	Author      *string
+	Publication *string
+	AuthorID    *graphql.ID
+	PublisherID *graphql.ID
+}
+
+func (r *resolver) Books(ctx context.Context, args booksArgs) (*bookPageResolver, error) {
+	q := models.BookQuery{
+		Limit:       intOr(args.Limit),
+		Offset:      intOr(args.Offset),
+		Cursor:      stringOr(args.Cursor),
+		Name:        stringOr(args.Name),
+		Author:      stringOr(args.Author),
+		Publication: stringOr(args.Publication),
+	}
+	var err error
+	if q.Sort, err = models.ParseSort(stringOr(args.Sort)); err != nil {
+		return nil, resolveError(ctx, "book", err)
+	}
+	for _, link := range []struct {
+		arg *graphql.ID
+		dst *uint
+	}{{args.AuthorID, &q.AuthorID}, {args.PublisherID, &q.PublisherID}} {
+		if link.arg == nil {
+			continue
+		}
+		id, err := parseID(*link.arg)
+		if err != nil {
+			return nil, err
+		}
+		*link.dst = uint(id)
+	}
+	page, err := r.store.Books.List(q)
+	if err != nil {
+		return nil, resolveError(ctx, "book", err)
+	}
+	return &bookPageResolver{books: newBookResolvers(ctx, page.Books), page: page}, nil
+}
+
+func (r *resolver) Search(ctx context.Context, args struct {
+	Query string
+	Limit *int32
+}) ([]*searchResultResolver, error) {
+	if strings.TrimSpace(args.Query) == "" {
+		return nil, &gqlError{code: utils.CodeBadRequest, message: "query is required"}
+	}
+	limit := models.DefaultPageSize
+	if args.Limit != nil {
+		if *args.Limit <= 0 {
+			return nil, &gqlError{code: utils.CodeBadRequest, message: "limit must be a positive integer"}
+		}
+		limit = min(int(*args.Limit), models.MaxPageSize)
+	}
+	results, err := r.store.Books.Search(args.Query, limit)
+	if err != nil {
+		return nil, resolveError(ctx, "book", err)
+	}
+	books := make([]models.Book, len(results))
+	for i := range results {
+		books[i] = results[i].Book
+	}
+	res := make([]*searchResultResolver, len(results))
+	for i, b := range newBookResolvers(ctx, books) {
+		res[i] = &searchResultResolver{book: b, score: results[i].Score}
+	}
+	return res, nil
+}
+
+func (r *resolver) Author(ctx context.Context, args struct{ ID graphql.ID }) (*authorResolver, error) {
+	id, err := parseID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+	a, err := r.store.Authors.Get(id)
+	if errors.Is(err, models.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, resolveError(ctx, "author", err)
+	}
+	return &authorResolver{*a}, nil
+}
+
+type namesArgs struct {
+	Limit  *int32
+	Offset *int32
+	Name   *string
+}
+
+func (a namesArgs) query() models.NameQuery {
+	return models.NameQuery{Limit: intOr(a.Limit), Offset: intOr(a.Offset), Name: stringOr(a.Name)}
+}
+
+func (r *resolver) Authors(ctx context.Context, args namesArgs) (*authorPageResolver, error) {
+	authors, total, err := r.store.Authors.List(args.query())
+	if err != nil {
+		return nil, resolveError(ctx, "author", err)
+	}
+	res := &authorPageResolver{authors: make([]*authorResolver, len(authors)), total: total}
+	for i, a := range authors {
+		res.authors[i] = &authorResolver{a}
+	}
+	return res, nil
+}
+
+func (r *resolver) Publisher(ctx context.Context, args struct{ ID graphql.ID }) (*publisherResolver, error) {
+	id, err := parseID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+	p, err := r.store.Publishers.Get(id)
+	if errors.Is(err, models.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, resolveError(ctx, "publisher", err)
+	}
+	return &publisherResolver{*p}, nil
+}
+
+func (r *resolver) Publishers(ctx context.Context, args namesArgs) (*publisherPageResolver, error) {
+	publishers, total, err := r.store.Publishers.List(args.query())
+	if err != nil {
+		return nil, resolveError(ctx, "publisher", err)
+	}
+	res := &publisherPageResolver{publishers: make([]*publisherResolver, len(publishers)), total: total}
+	for i, p := range publishers {
+		res.publishers[i] = &publisherResolver{p}
+	}
+	return res, nil
+}
+
+type bookInput struct {
+	Name        string
+	Author      *string
+	Publication *string
+	SKU         *string
+	ISBN13      *string
+	ISBN10      *string
+	PriceCents  *int32
+	AuthorIDs   *[]graphql.ID
+	PublisherID *graphql.ID
+}
+
+// book builds the book the input describes, validated like a REST body.
+func (in bookInput) book() (*models.Book, error) {
+	b := &models.Book{
+		Name:        in.Name,
+		Author:      stringOr(in.Author),
+		Publication: stringOr(in.Publication),
+		SKU:         in.SKU,
+		ISBN13:      in.ISBN13,
+		ISBN10:      in.ISBN10,
+		PriceCents:  int64(intOr(in.PriceCents)),
+	}
+	var errs validation.Errors
+	if in.AuthorIDs != nil {
+		for _, id := range *in.AuthorIDs {
+			n, err := parseID(id)
+			if err != nil {
+				errs.Add("author_ids", "%q is not a positive integer", id)
+				continue
+			}
+			b.AuthorIDs = append(b.AuthorIDs, uint(n))
+		}
+	}
+	if in.PublisherID != nil {
+		n, err := parseID(*in.PublisherID)
+		if err != nil {
+			errs.Add("publisher_id", "%q is not a positive integer", *in.PublisherID)
+		}
+		p := uint(n)
+		b.PublisherID = &p
+	}
+	var invalid validation.Errors
+	if errors.As(b.Validate(), &invalid) {
+		errs = append(errs, invalid...)
+	}
+	if len(errs) > 0 {
+		return nil, &gqlError{utils.CodeValidation, "input failed validation", errs}
+	}
+	return b, nil
+}
+
+// books returns the book repository that records the caller in the audit
+// log, as the REST controllers do.
+func (r *resolver) books(ctx context.Context) models.BookRepository {
+	return r.store.Books.WithAudit(models.AuditInfo{Actor: auth.Actor(ctx), RequestID: middleware.GetRequestID(ctx)})
+}
+
+func (r *resolver) CreateBook(ctx context.Context, args struct{ Input bookInput }) (*bookResolver, error) {
+	if err := requireRole(ctx, auth.RoleEditor); err != nil {
+		return nil, err
+	}
+	b, err := args.Input.book()
+	if err != nil {
+		return nil, err
+	}
+	if err := r.books(ctx).Create(b); err != nil {
+		return nil, resolveError(ctx, "book", err)
+	}
+	return newBookResolvers(ctx, []models.Book{*b})[0], nil
+}
+
+func (r *resolver) UpdateBook(ctx context.Context, args struct {
+	ID      graphql.ID
+	Version *int32
+	Input   bookInput
+}) (*bookResolver, error) {
+	if err := requireRole(ctx, auth.RoleEditor); err != nil {
+		return nil, err
+	}
+	current, err := r.current(ctx, args.ID, args.Version)
+	if err != nil {
+		return nil, err
+	}
+	replacement, err := args.Input.book()
+	if err != nil {
+		return nil, err
+	}
+	replacement.Model = current.Model
+	replacement.Version = current.Version
+	if err := r.books(ctx).Update(replacement); err != nil {
+		return nil, resolveError(ctx, "book", err)
+	}
+	return newBookResolvers(ctx, []models.Book{*replacement})[0], nil
+}
+
+func (r *resolver) DeleteBook(ctx context.Context, args struct {
+	ID      graphql.ID
+	Version *int32
+}) (*bookResolver, error) {
+	if err := requireRole(ctx, auth.RoleAdmin); err != nil {
+		return nil, err
+	}
+	b, err := r.current(ctx, args.ID, args.Version)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.books(ctx).Delete(b); err != nil {
+		return nil, resolveError(ctx, "book", err)
+	}
+	return newBookResolvers(ctx, []models.Book{*b})[0], nil
+}
+
+func (r *resolver) RestoreBook(ctx context.Context, args struct{ ID graphql.ID }) (*bookResolver, error) {
+	if err := requireRole(ctx, auth.RoleEditor); err != nil {
+		return nil, err
+	}
+	id, err := parseID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+	b, err := r.books(ctx).Restore(id)
+	if err != nil {
+		return nil, resolveError(ctx, "book", err)
+	}
+	return newBookResolvers(ctx, []models.Book{*b})[0], nil
+}
+
+// current loads the live book a mutation changes, checking version when the
+// caller sent one, as If-Match does.
+func (r *resolver) current(ctx context.Context, id graphql.ID, version *int32) (*models.Book, error) {
+	n, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+	b, err := r.store.Books.Get(n)
+	if err != nil {
+		return nil, resolveError(ctx, "book", err)
+	}
+	if version != nil && uint(*version) != b.Version {
+		return nil, &gqlError{code: utils.CodePreconditionFailed, message: "book has changed since it was fetched; reload and retry"}
+	}
+	return b, nil
+}
+
+func intOr(n *int32) int {
+	if n == nil {
+		return 0
+	}
+	return int(*n)
+}
+
+func stringOr(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,234 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/yoloxsta/go-bookstore/pkg/models"
+	"github.com/yoloxsta/go-bookstore/pkg/utils"
+)
+
+func idOf(id uint) graphql.ID {
+	return graphql.ID(strconv.FormatUint(uint64(id), 10))
+}
+
+// parseID reads a positive integer ID argument.
+func parseID(id graphql.ID) (int64, error) {
+	n, err := strconv.ParseInt(string(id), 10, 64)
+	if err != nil || n <= 0 {
+		return 0, &gqlError{code: utils.CodeBadRequest, message: fmt.Sprintf("id %q must be a positive integer", id)}
+	}
+	return n, nil
+}
+
+// int32Of narrows a count or amount to GraphQL's 32-bit Int.
+func int32Of(field string, n int64) (int32, error) {
+	if n > math.MaxInt32 || n < math.MinInt32 {
+		return 0, &gqlError{code: utils.CodeInternal, message: field + " does not fit in a GraphQL Int"}
+	}
+	return int32(n), nil
+}
+
+type bookResolver struct {
+	b models.Book
+	// siblings are the IDs of every book in the same result, so a field
+	// whose arguments are only known when it is resolved, such as reviews,
+	// can still prime its loader for all of them.
+	siblings []uint
+}
+
+// newBookResolvers wraps books and primes the request's loaders with every
+// author, publisher and stock level they link to, so resolving those fields
+// for the whole list takes one query per kind.
+func newBookResolvers(ctx context.Context, books []models.Book) []*bookResolver {
+	l := loadersFrom(ctx)
+	res := make([]*bookResolver, len(books))
+	ids := make([]uint, len(books))
+	for i, b := range books {
+		l.authors.prime(b.AuthorIDs...)
+		if b.PublisherID != nil {
+			l.publishers.prime(*b.PublisherID)
+		}
+		l.stock.prime(b.ID)
+		ids[i] = b.ID
+		res[i] = &bookResolver{b: b, siblings: ids}
+	}
+	return res
+}
+
+func (r *bookResolver) ID() graphql.ID      { return idOf(r.b.ID) }
+func (r *bookResolver) Name() string        { return r.b.Name }
+func (r *bookResolver) Author() string      { return r.b.Author }
+func (r *bookResolver) Publication() string { return r.b.Publication }
+func (r *bookResolver) Version() int32      { return int32(r.b.Version) }
+func (r *bookResolver) SKU() *string        { return r.b.SKU }
+func (r *bookResolver) ISBN13() *string     { return r.b.ISBN13 }
+func (r *bookResolver) ISBN10() *string     { return r.b.ISBN10 }
+
+func (r *bookResolver) RatingAverage() float64 { return r.b.RatingAverage }
+func (r *bookResolver) RatingCount() int32     { return int32(r.b.RatingCount) }
+
+func (r *bookResolver) PriceCents() (int32, error) {
+	return int32Of("priceCents", r.b.PriceCents)
+}
+
+func (r *bookResolver) CreatedAt() graphql.Time { return graphql.Time{Time: r.b.CreatedAt} }
+func (r *bookResolver) UpdatedAt() graphql.Time { return graphql.Time{Time: r.b.UpdatedAt} }
+
+func (r *bookResolver) Authors(ctx context.Context) ([]*authorResolver, error) {
+	res := []*authorResolver{}
+	for _, id := range r.b.AuthorIDs {
+		a, ok, err := loadersFrom(ctx).authors.load(id)
+		if err != nil {
+			return nil, resolveError(ctx, "author", err)
+		}
+		if ok {
+			res = append(res, &authorResolver{a})
+		}
+	}
+	return res, nil
+}
+
+func (r *bookResolver) Publisher(ctx context.Context) (*publisherResolver, error) {
+	if r.b.PublisherID == nil {
+		return nil, nil
+	}
+	p, ok, err := loadersFrom(ctx).publishers.load(*r.b.PublisherID)
+	if err != nil {
+		return nil, resolveError(ctx, "publisher", err)
+	}
+	if !ok {
+		return nil, nil
+	}
+	return &publisherResolver{p}, nil
+}
+
+func (r *bookResolver) Stock(ctx context.Context) (*stockResolver, error) {
+	s, ok, err := loadersFrom(ctx).stock.load(r.b.ID)
+	if err != nil {
+		return nil, resolveError(ctx, "stock", err)
+	}
+	if !ok {
+		return nil, nil
+	}
+	return &stockResolver{s}, nil
+}
+
+func (r *bookResolver) Reviews(ctx context.Context, args struct {
+	Limit  *int32
+	Offset *int32
+}) (*reviewPageResolver, error) {
+	if args.Limit != nil && *args.Limit <= 0 {
+		return nil, &gqlError{code: utils.CodeBadRequest, message: "limit must be a positive integer"}
+	}
+	if args.Offset != nil && *args.Offset < 0 {
+		return nil, &gqlError{code: utils.CodeBadRequest, message: "offset must be a non-negative integer"}
+	}
+	key := reviewsKey{limit: models.BookQuery{Limit: intOr(args.Limit)}.PageSize(), offset: intOr(args.Offset)}
+	l := loadersFrom(ctx).reviews
+	for _, id := range r.siblings {
+		l.prime(reviewsKey{id, key.limit, key.offset})
+	}
+	key.bookID = r.b.ID
+	page, _, err := l.load(key)
+	if err != nil {
+		return nil, resolveError(ctx, "review", err)
+	}
+	res := &reviewPageResolver{reviews: make([]*reviewResolver, len(page.Reviews)), total: page.Total}
+	for i, rv := range page.Reviews {
+		res.reviews[i] = &reviewResolver{rv}
+	}
+	return res, nil
+}
+
+type bookPageResolver struct {
+	books []*bookResolver
+	page  models.BookPage
+}
+
+func (r *bookPageResolver) Books() []*bookResolver { return r.books }
+func (r *bookPageResolver) Total() (int32, error)  { return int32Of("total", r.page.Total) }
+
+func (r *bookPageResolver) NextCursor() *string {
+	if r.page.NextCursor == "" {
+		return nil
+	}
+	return &r.page.NextCursor
+}
+
+type searchResultResolver struct {
+	book  *bookResolver
+	score float64
+}
+
+func (r *searchResultResolver) Book() *bookResolver { return r.book }
+func (r *searchResultResolver) Score() float64      { return r.score }
+
+type reviewResolver struct {
+	rv models.Review
+}
+
+func (r *reviewResolver) ID() graphql.ID          { return idOf(r.rv.ID) }
+func (r *reviewResolver) Reviewer() string        { return r.rv.Reviewer }
+func (r *reviewResolver) Rating() int32           { return int32(r.rv.Rating) }
+func (r *reviewResolver) Title() string           { return r.rv.Title }
+func (r *reviewResolver) Body() string            { return r.rv.Body }
+func (r *reviewResolver) CreatedAt() graphql.Time { return graphql.Time{Time: r.rv.CreatedAt} }
+
+type reviewPageResolver struct {
+	reviews []*reviewResolver
+	total   int64
+}
+
+func (r *reviewPageResolver) Reviews() []*reviewResolver { return r.reviews }
+func (r *reviewPageResolver) Total() (int32, error)      { return int32Of("total", r.total) }
+
+type authorResolver struct {
+	a models.Author
+}
+
+func (r *authorResolver) ID() graphql.ID { return idOf(r.a.ID) }
+func (r *authorResolver) Name() string   { return r.a.Name }
+
+type authorPageResolver struct {
+	authors []*authorResolver
+	total   int64
+}
+
+func (r *authorPageResolver) Authors() []*authorResolver { return r.authors }
+func (r *authorPageResolver) Total() (int32, error)      { return int32Of("total", r.total) }
+
+type publisherResolver struct {
+	p models.Publisher
+}
+
+func (r *publisherResolver) ID() graphql.ID { return idOf(r.p.ID) }
+func (r *publisherResolver) Name() string   { return r.p.Name }
+
+type publisherPageResolver struct {
+	publishers []*publisherResolver
+	total      int64
+}
+
+func (r *publisherPageResolver) Publishers() []*publisherResolver { return r.publishers }
+func (r *publisherPageResolver) Total() (int32, error)            { return int32Of("total", r.total) }
+
+type stockResolver struct {
+	s models.StockLevel
+}
+
+func (r *stockResolver) Quantity() int32 { return int32(r.s.Quantity) }
+
+func (r *stockResolver) LowStockThreshold() *int32 {
+	if r.s.LowStockThreshold == nil {
+		return nil
+	}
+	n := int32(*r.s.LowStockThreshold)
+	return &n
+}
+
+func (r *stockResolver) LowStock() bool          { return r.s.LowStock }
+func (r *stockResolver) UpdatedAt() graphql.Time { return graphql.Time{Time: r.s.UpdatedAt} }
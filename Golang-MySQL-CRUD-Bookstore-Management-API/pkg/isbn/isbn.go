@@ -0,0 +1,100 @@
+// Package isbn validates ISBN-10 and ISBN-13 identifiers and converts
+// between the two forms.
+package isbn
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	ErrLength   = errors.New("must have 10 or 13 digits")
+	ErrPrefix   = errors.New("ISBN-13 must start with 978 or 979")
+	ErrChecksum = errors.New("has a wrong check digit")
+)
+
+// clean drops the hyphens and spaces ISBNs are usually printed with and
+// upper-cases a trailing x.
+func clean(s string) string {
+	s = strings.NewReplacer("-", "", " ", "").Replace(strings.TrimSpace(s))
+	return strings.ToUpper(s)
+}
+
+// Normalize checks s as an ISBN-10 or ISBN-13 and returns it as a bare
+// 13-digit ISBN.
+func Normalize(s string) (string, error) {
+	s = clean(s)
+	switch len(s) {
+	case 10:
+		if !allDigits(s[:9]) || (s[9] != 'X' && !allDigits(s[9:])) {
+			return "", ErrLength
+		}
+		if check10(s[:9]) != s[9] {
+			return "", ErrChecksum
+		}
+		body := "978" + s[:9]
+		return body + string(check13(body)), nil
+	case 13:
+		if !allDigits(s) {
+			return "", ErrLength
+		}
+		if !strings.HasPrefix(s, "978") && !strings.HasPrefix(s, "979") {
+			return "", ErrPrefix
+		}
+		if check13(s[:12]) != s[12] {
+			return "", ErrChecksum
+		}
+		return s, nil
+	}
+	return "", ErrLength
+}
+
+// Is10 reports whether s, after dropping hyphens and spaces, has the length
+// of an ISBN-10.
+func Is10(s string) bool {
+	return len(clean(s)) == 10
+}
+
+// To10 converts a normalized ISBN-13 to its ISBN-10 form. Only 978-prefixed
+// ISBNs have one.
+func To10(isbn13 string) (string, bool) {
+	if len(isbn13) != 13 || !strings.HasPrefix(isbn13, "978") {
+		return "", false
+	}
+	body := isbn13[3:12]
+	return body + string(check10(body)), true
+}
+
+func check10(body string) byte {
+	sum := 0
+	for i := 0; i < 9; i++ {
+		sum += int(body[i]-'0') * (10 - i)
+	}
+	switch c := (11 - sum%11) % 11; c {
+	case 10:
+		return 'X'
+	default:
+		return byte('0' + c)
+	}
+}
+
+func check13(body string) byte {
+	sum := 0
+	for i := 0; i < 12; i++ {
+		d := int(body[i] - '0')
+		if i%2 == 1 {
+			d *= 3
+		}
+		sum += d
+	}
+	return byte('0' + (10-sum%10)%10)
+}
+
+func allDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
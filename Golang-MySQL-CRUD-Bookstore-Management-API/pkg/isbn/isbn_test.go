@@ -0,0 +1,96 @@
+package isbn
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+		err  error
+	}{
+		{"0306406152", "9780306406157", nil},
+		{"0-306-40615-2", "9780306406157", nil},
+		{" 0 306 40615 2 ", "9780306406157", nil},
+		{"080442957X", "9780804429573", nil},
+		{"0-439-42089-x", "9780439420891", nil},
+		{"9780306406157", "9780306406157", nil},
+		{"978-0-306-40615-7", "9780306406157", nil},
+		{"978 0 306 40615 7", "9780306406157", nil},
+		{"979-10-90636-07-1", "9791090636071", nil},
+
+		{"0306406153", "", ErrChecksum},
+		{"0804429570", "", ErrChecksum},
+		{"0306406150X", "", ErrLength},
+		{"9780306406158", "", ErrChecksum},
+		{"9791090636072", "", ErrChecksum},
+		{"9770306406157", "", ErrPrefix},
+		{"X306406152", "", ErrLength},
+		{"03064061X2", "", ErrLength},
+		{"978030640615X", "", ErrLength},
+		{"030640615", "", ErrLength},
+		{"97803064061577", "", ErrLength},
+		{"0_306_40615_2", "", ErrLength},
+		{"", "", ErrLength},
+	}
+	for _, tt := range tests {
+		got, err := Normalize(tt.in)
+		if got != tt.want || !errors.Is(err, tt.err) {
+			t.Errorf("Normalize(%q) = %q, %v; want %q, %v", tt.in, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+func TestIs10(t *testing.T) {
+	tests := []struct {
+		in   string
+		want bool
+	}{
+		{"0306406152", true},
+		{"0-306-40615-2", true},
+		{"0 8044 2957 X", true},
+		{"9780306406157", false},
+		{"978-0-306-40615-7", false},
+	}
+	for _, tt := range tests {
+		if got := Is10(tt.in); got != tt.want {
+			t.Errorf("Is10(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestTo10(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+		ok   bool
+	}{
+		{"9780306406157", "0306406152", true},
+		{"9780804429573", "080442957X", true},
+		{"9781861972712", "1861972717", true},
+		// 979 ISBNs were never issued an ISBN-10.
+		{"9791090636071", "", false},
+		{"978-0-306-40615-7", "", false},
+		{"0306406152", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := To10(tt.in)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("To10(%q) = %q, %v; want %q, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, in := range []string{"0306406152", "080442957X", "043942089X", "1861972717"} {
+		n, err := Normalize(in)
+		if err != nil {
+			t.Fatalf("Normalize(%q): %v", in, err)
+		}
+		if back, ok := To10(n); !ok || back != in {
+			t.Errorf("To10(Normalize(%q)) = %q, %v", in, back, ok)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package jobs
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/yoloxsta/go-bookstore/pkg/config"
+	"github.com/yoloxsta/go-bookstore/pkg/models"
+)
+
+// PurgeTrash permanently deletes books that have been in the trash longer
+// than cfg.Retention, once immediately and then every cfg.PurgeInterval,
+// until ctx is cancelled. It returns at once when retention is disabled.
+func PurgeTrash(ctx context.Context, books models.BookRepository, cfg config.TrashConfig) {
+	if cfg.Retention <= 0 {
+		return
+	}
+	ticker := time.NewTicker(cfg.PurgeInterval)
+	defer ticker.Stop()
+	for {
+		n, err := books.PurgeDeletedBefore(time.Now().Add(-cfg.Retention))
+		if err != nil {
+			slog.Error("purging trash", "err", err)
+		} else if n > 0 {
+			slog.Info("purged trash", "books", n, "retention", cfg.Retention)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package metrics
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+const startKey = "metrics:start"
+
+// InstrumentDB times every statement db runs through gorm's callbacks into
+// DBDuration and DBErrors, and exports the connection pool statistics.
+// Statements run with Exec bypass the callbacks and are not timed.
+func InstrumentDB(db *gorm.DB, name string) {
+	cb := db.Callback()
+	// Each registration keeps its processor, so every one needs a fresh one.
+	for _, op := range []struct {
+		name      string
+		processor func() *gorm.CallbackProcessor
+		callback  string
+	}{
+		{"create", cb.Create, "gorm:create"},
+		{"query", cb.Query, "gorm:query"},
+		{"update", cb.Update, "gorm:update"},
+		{"delete", cb.Delete, "gorm:delete"},
+		{"row", cb.RowQuery, "gorm:row_query"},
+	} {
+		op.processor().Before(op.callback).Register("metrics:before_"+op.name, start)
+		op.processor().After(op.callback).Register("metrics:after_"+op.name, observe(op.name))
+	}
+	Register(collectors.NewDBStatsCollector(db.DB(), name))
+}
+
+func start(scope *gorm.Scope) {
+	scope.Set(startKey, time.Now())
+}
+
+func observe(op string) func(*gorm.Scope) {
+	return func(scope *gorm.Scope) {
+		v, ok := scope.Get(startKey)
+		if !ok {
+			return
+		}
+		table := scope.TableName()
+		if table == "" {
+			table = "unknown"
+		}
+		DBDuration.WithLabelValues(op, table).Observe(time.Since(v.(time.Time)).Seconds())
+		if err := scope.DB().Error; err != nil && err != sql.ErrNoRows && !gorm.IsRecordNotFoundError(err) {
+			DBErrors.WithLabelValues(op, table).Inc()
+		}
+	}
+}
@@ -0,0 +1,79 @@
+// Package metrics holds the bookstore's Prometheus collectors and serves
+// them in the text exposition format.
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Path is where Handler is served. It has no authentication, so the server
+// exposes it only on the admin listener.
+const Path = "/metrics"
+
+var registry = prometheus.NewRegistry()
+
+var (
+	// HTTPRequests and HTTPDuration are labelled by route template, such as
+	// /book/{bookId}, so IDs do not multiply the series. Requests matching
+	// no route are reported as "unmatched".
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "bookstore_http_requests_total",
+		Help: "HTTP requests served, by method, route template and status code.",
+	}, []string{"method", "route", "code"})
+	HTTPDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "bookstore_http_request_duration_seconds",
+		Help:    "Time to serve HTTP requests, by method and route template.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route"})
+	HTTPInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "bookstore_http_requests_in_flight",
+		Help: "HTTP requests being served.",
+	})
+
+	// DBDuration times the statements gorm runs through its callbacks, by
+	// operation (create, query, update, delete or row) and table.
+	DBDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "bookstore_db_query_duration_seconds",
+		Help:    "Time to run database statements, by operation and table.",
+		Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"operation", "table"})
+	DBErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "bookstore_db_errors_total",
+		Help: "Database statements that failed, by operation and table.",
+	}, []string{"operation", "table"})
+
+	// CacheLookups counts book cache lookups by kind and result, hit or
+	// miss; CacheErrors counts cache calls that failed.
+	CacheLookups = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "bookstore_cache_lookups_total",
+		Help: "Book cache lookups, by kind and result.",
+	}, []string{"kind", "result"})
+	CacheErrors = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "bookstore_cache_errors_total",
+		Help: "Book cache calls that failed and fell back to the database.",
+	})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequests, HTTPDuration, HTTPInFlight,
+		DBDuration, DBErrors,
+		CacheLookups, CacheErrors,
+	)
+}
+
+// Register adds collectors, such as one for the database connection pool.
+func Register(cs ...prometheus.Collector) {
+	registry.MustRegister(cs...)
+}
+
+// Handler serves every registered collector.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{Registry: registry})
+}
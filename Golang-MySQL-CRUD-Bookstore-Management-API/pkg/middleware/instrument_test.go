@@ -0,0 +1,108 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/yoloxsta/go-bookstore/pkg/metrics"
+)
+
+// scrape returns the lines of the metrics exposition that mention route.
+func scrape(t *testing.T, route string) []string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, metrics.Path, nil))
+	var lines []string
+	for _, line := range strings.Split(rec.Body.String(), "\n") {
+		if strings.HasPrefix(line, "bookstore_http_requests_total{") && strings.Contains(line, route) {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+func TestInstrumentLabelsByRouteTemplate(t *testing.T) {
+	router := mux.NewRouter()
+	router.HandleFunc("/instrumented/{itemId}", func(w http.ResponseWriter, r *http.Request) {
+		if mux.Vars(r)["itemId"] == "3" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}).Methods("GET")
+	h := Instrument(router)
+	for _, path := range []string{"/instrumented/1", "/instrumented/2", "/instrumented/3", "/instrumented-nowhere/4"} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	got := scrape(t, `route="/instrumented/{itemId}"`)
+	want := []string{
+		`bookstore_http_requests_total{code="200",method="GET",route="/instrumented/{itemId}"} 2`,
+		`bookstore_http_requests_total{code="404",method="GET",route="/instrumented/{itemId}"} 1`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("series:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if got := scrape(t, `route="/instrumented`); len(got) != len(want) {
+		t.Errorf("a path ID became a label:\n%s", strings.Join(got, "\n"))
+	}
+	if got := scrape(t, `route="unmatched"`); len(got) == 0 {
+		t.Error("the unmatched request was not counted as unmatched")
+	}
+}
+
+func TestRequestIDReachesTheLogs(t *testing.T) {
+	var buf bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(NewLogHandler(slog.NewJSONHandler(&buf, nil))))
+
+	router := mux.NewRouter()
+	router.HandleFunc("/logged", func(w http.ResponseWriter, r *http.Request) {
+		slog.InfoContext(r.Context(), "handling")
+		slog.Info("without a context")
+	})
+	h := RequestID(Instrument(router))
+	req := httptest.NewRequest(http.MethodGet, "/logged", nil)
+	req.Header.Set(RequestIDHeader, "req-42")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if got := rec.Header().Get(RequestIDHeader); got != "req-42" {
+		t.Fatalf("X-Request-ID = %q, want the client's req-42", got)
+	}
+
+	want := map[string]string{"handling": "req-42", "without a context": "", "request": "req-42"}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != len(want) {
+		t.Fatalf("logged %d lines, want %d:\n%s", len(lines), len(want), buf.String())
+	}
+	for _, line := range lines {
+		var rec struct {
+			Msg       string `json:"msg"`
+			RequestID string `json:"request_id"`
+			Route     string `json:"route"`
+		}
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Fatal(err)
+		}
+		if id, ok := want[rec.Msg]; !ok || rec.RequestID != id {
+			t.Errorf("%q logged with request_id %q, want %q", rec.Msg, rec.RequestID, id)
+		}
+		if rec.Msg == "request" && rec.Route != "/logged" {
+			t.Errorf("access log route = %q, want /logged", rec.Route)
+		}
+	}
+
+	// Without a client ID each request gets a fresh one, in its response and
+	// its logs alike.
+	buf.Reset()
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/logged", nil))
+	id := rec.Header().Get(RequestIDHeader)
+	if id == "" || id == "req-42" || !strings.Contains(buf.String(), `"request_id":"`+id+`"`) {
+		t.Fatalf("generated ID %q is missing from the logs:\n%s", id, buf.String())
+	}
+}
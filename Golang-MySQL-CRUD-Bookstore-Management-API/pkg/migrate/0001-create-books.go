@@ -0,0 +1,36 @@
+package migrate
+
+import (
+	"github.com/jinzhu/gorm"
+)
+
+type book0001 struct {
+	gorm.Model
+	Name        string
+	Author      string
+	Publication string
+	Version     uint `gorm:"not null;default:1"`
+}
+
+func (book0001) TableName() string { return "books" }
+
+// createBooks also serves as the baseline for databases that were set up by
+// AutoMigrate before migrations existed: an existing books table is kept, and
+// given the columns it lacks. Those tables have no version column, which
+// optimistic locking needs; it is added as NOT NULL DEFAULT 1, so every
+// existing book starts at version 1.
+var createBooks = Migration{
+	Version: 1,
+	Name:    "create_books",
+	Up: func(tx *gorm.DB) error {
+		if tx.HasTable("books") {
+			// AutoMigrate only ever adds missing columns and indexes.
+			return tx.AutoMigrate(&book0001{}).Error
+		}
+		return tx.CreateTable(&book0001{}).
+			AddIndex("idx_books_deleted_at", "deleted_at").Error
+	},
+	Down: func(tx *gorm.DB) error {
+		return tx.DropTableIfExists("books").Error
+	},
+}
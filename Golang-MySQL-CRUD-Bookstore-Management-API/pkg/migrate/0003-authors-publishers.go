@@ -0,0 +1,189 @@
+package migrate
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/jinzhu/gorm"
+)
+
+type author0003 struct {
+	gorm.Model
+	Name    string
+	NameKey string
+}
+
+func (author0003) TableName() string { return "authors" }
+
+type publisher0003 struct {
+	gorm.Model
+	Name    string
+	NameKey string
+}
+
+func (publisher0003) TableName() string { return "publishers" }
+
+type bookAuthor0003 struct {
+	BookID   uint `gorm:"primary_key;auto_increment:false"`
+	AuthorID uint `gorm:"primary_key;auto_increment:false"`
+	Position int
+}
+
+func (bookAuthor0003) TableName() string { return "book_authors" }
+
+// The backfill keeps its own copies of the credit splitting and name folding
+// rules so that later changes to pkg/models cannot change what this
+// migration did.
+var creditSeparators0003 = regexp.MustCompile(`(?i)\s*(?:;|&|\band\b)\s*`)
+
+func splitCredit0003(s string) []string {
+	var names []string
+	for _, n := range creditSeparators0003.Split(s, -1) {
+		if n = strings.Join(strings.Fields(n), " "); n != "" {
+			names = append(names, n)
+		}
+	}
+	return names
+}
+
+func nameKey0003(name string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
+
+// spellings counts how often each spelling of a name key was used, so the
+// most common one becomes the canonical name. Ties go to the spelling seen
+// first, that is on the oldest book.
+type spellings map[string][]spelling
+
+type spelling struct {
+	name  string
+	count int
+}
+
+func (s spellings) add(name string) string {
+	key := nameKey0003(name)
+	for i := range s[key] {
+		if s[key][i].name == name {
+			s[key][i].count++
+			return key
+		}
+	}
+	s[key] = append(s[key], spelling{name: name, count: 1})
+	return key
+}
+
+func (s spellings) canonical(key string) string {
+	var best spelling
+	for _, sp := range s[key] {
+		if sp.count > best.count {
+			best = sp
+		}
+	}
+	return best.name
+}
+
+// authorsPublishers turns the free-text author and publication columns into
+// linked authors and publishers, deduplicating names that differ only in
+// case, punctuation or spacing. Trashed books are linked as well.
+var authorsPublishers = Migration{
+	Version: 3,
+	Name:    "authors_publishers",
+	Up: func(tx *gorm.DB) error {
+		err := tx.CreateTable(&author0003{}).
+			AddIndex("idx_authors_deleted_at", "deleted_at").
+			AddIndex("idx_authors_name_key", "name_key").Error
+		if err != nil {
+			return err
+		}
+		err = tx.CreateTable(&publisher0003{}).
+			AddIndex("idx_publishers_deleted_at", "deleted_at").
+			AddIndex("idx_publishers_name_key", "name_key").Error
+		if err != nil {
+			return err
+		}
+		if err := tx.CreateTable(&bookAuthor0003{}).AddIndex("idx_book_authors_author_id", "author_id").Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("ALTER TABLE books ADD COLUMN publisher_id integer").Error; err != nil {
+			return err
+		}
+		if err := tx.Table("books").AddIndex("idx_books_publisher_id", "publisher_id").Error; err != nil {
+			return err
+		}
+		return backfillCredits0003(tx)
+	},
+	Down: func(tx *gorm.DB) error {
+		if err := tx.Table("books").RemoveIndex("idx_books_publisher_id").Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("ALTER TABLE books DROP COLUMN publisher_id").Error; err != nil {
+			return err
+		}
+		return tx.DropTableIfExists("book_authors", "publishers", "authors").Error
+	},
+}
+
+func backfillCredits0003(tx *gorm.DB) error {
+	type row struct {
+		ID          uint
+		Author      string
+		Publication string
+	}
+	var books []row
+	if err := tx.Table("books").Select("id, author, publication").Order("id").Scan(&books).Error; err != nil {
+		return err
+	}
+
+	authorNames, publisherNames := spellings{}, spellings{}
+	bookAuthorKeys := make(map[uint][]string, len(books))
+	bookPublisherKey := make(map[uint]string, len(books))
+	for _, b := range books {
+		seen := map[string]bool{}
+		for _, name := range splitCredit0003(b.Author) {
+			if key := authorNames.add(name); key != "" && !seen[key] {
+				seen[key] = true
+				bookAuthorKeys[b.ID] = append(bookAuthorKeys[b.ID], key)
+			}
+		}
+		if name := strings.Join(strings.Fields(b.Publication), " "); name != "" {
+			if key := publisherNames.add(name); key != "" {
+				bookPublisherKey[b.ID] = key
+			}
+		}
+	}
+
+	authorIDs := map[string]uint{}
+	publisherIDs := map[string]uint{}
+	for _, b := range books {
+		for i, key := range bookAuthorKeys[b.ID] {
+			if _, ok := authorIDs[key]; !ok {
+				a := author0003{Name: authorNames.canonical(key), NameKey: key}
+				if err := tx.Create(&a).Error; err != nil {
+					return err
+				}
+				authorIDs[key] = a.ID
+			}
+			if err := tx.Create(&bookAuthor0003{BookID: b.ID, AuthorID: authorIDs[key], Position: i}).Error; err != nil {
+				return err
+			}
+		}
+		key, ok := bookPublisherKey[b.ID]
+		if !ok {
+			continue
+		}
+		if _, ok := publisherIDs[key]; !ok {
+			p := publisher0003{Name: publisherNames.canonical(key), NameKey: key}
+			if err := tx.Create(&p).Error; err != nil {
+				return err
+			}
+			publisherIDs[key] = p.ID
+		}
+		if err := tx.Table("books").Where("id = ?", b.ID).UpdateColumn("publisher_id", publisherIDs[key]).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
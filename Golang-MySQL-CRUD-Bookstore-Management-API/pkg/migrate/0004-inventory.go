@@ -0,0 +1,76 @@
+package migrate
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+type stockLevel0004 struct {
+	BookID            uint `gorm:"primary_key;auto_increment:false"`
+	Quantity          int  `gorm:"not null;default:0"`
+	LowStockThreshold *int
+	UpdatedAt         time.Time
+}
+
+func (stockLevel0004) TableName() string { return "stock_levels" }
+
+type stockMovement0004 struct {
+	ID        uint `gorm:"primary_key"`
+	BookID    uint
+	Kind      string
+	Quantity  int
+	Change    int
+	Balance   int
+	Note      string
+	CreatedAt time.Time
+}
+
+func (stockMovement0004) TableName() string { return "stock_movements" }
+
+// inventory adds SKU and price to books and gives every existing book,
+// trashed ones included, a stock level of zero.
+var inventory = Migration{
+	Version: 4,
+	Name:    "inventory",
+	Up: func(tx *gorm.DB) error {
+		for _, stmt := range []string{
+			"ALTER TABLE books ADD COLUMN sku varchar(64)",
+			"ALTER TABLE books ADD COLUMN price_cents bigint NOT NULL DEFAULT 0",
+		} {
+			if err := tx.Exec(stmt).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Table("books").AddUniqueIndex("idx_books_sku", "sku").Error; err != nil {
+			return err
+		}
+		if err := tx.CreateTable(&stockLevel0004{}).Error; err != nil {
+			return err
+		}
+		err := tx.CreateTable(&stockMovement0004{}).
+			AddIndex("idx_stock_movements_book_id", "book_id").Error
+		if err != nil {
+			return err
+		}
+		return tx.Exec("INSERT INTO stock_levels (book_id, quantity, updated_at) SELECT id, 0, ? FROM books",
+			time.Now()).Error
+	},
+	Down: func(tx *gorm.DB) error {
+		if err := tx.DropTableIfExists("stock_movements", "stock_levels").Error; err != nil {
+			return err
+		}
+		if err := tx.Table("books").RemoveIndex("idx_books_sku").Error; err != nil {
+			return err
+		}
+		for _, stmt := range []string{
+			"ALTER TABLE books DROP COLUMN price_cents",
+			"ALTER TABLE books DROP COLUMN sku",
+		} {
+			if err := tx.Exec(stmt).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
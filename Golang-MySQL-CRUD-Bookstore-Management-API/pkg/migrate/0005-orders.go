@@ -0,0 +1,89 @@
+package migrate
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+type cart0005 struct {
+	ID        uint `gorm:"primary_key"`
+	OrderID   *uint
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (cart0005) TableName() string { return "carts" }
+
+type cartItem0005 struct {
+	CartID   uint `gorm:"primary_key;auto_increment:false"`
+	BookID   uint `gorm:"primary_key;auto_increment:false"`
+	Quantity int
+}
+
+func (cartItem0005) TableName() string { return "cart_items" }
+
+type order0005 struct {
+	ID         uint `gorm:"primary_key"`
+	Customer   string
+	Status     string
+	TotalCents int64
+	Currency   string
+	PaymentID  string
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+func (order0005) TableName() string { return "orders" }
+
+type orderItem0005 struct {
+	ID             uint `gorm:"primary_key"`
+	OrderID        uint
+	BookID         uint
+	Name           string
+	SKU            *string
+	Quantity       int
+	UnitPriceCents int64
+}
+
+func (orderItem0005) TableName() string { return "order_items" }
+
+type orderEvent0005 struct {
+	ID         uint `gorm:"primary_key"`
+	OrderID    uint
+	FromStatus string
+	ToStatus   string
+	Note       string
+	CreatedAt  time.Time
+}
+
+func (orderEvent0005) TableName() string { return "order_events" }
+
+var orders = Migration{
+	Version: 5,
+	Name:    "orders",
+	Up: func(tx *gorm.DB) error {
+		if err := tx.CreateTable(&cart0005{}, &cartItem0005{}).Error; err != nil {
+			return err
+		}
+		err := tx.CreateTable(&order0005{}).
+			AddIndex("idx_orders_customer", "customer").
+			AddIndex("idx_orders_status", "status").Error
+		if err != nil {
+			return err
+		}
+		if err := tx.CreateTable(&orderItem0005{}).AddIndex("idx_order_items_order_id", "order_id").Error; err != nil {
+			return err
+		}
+		if err := tx.CreateTable(&orderEvent0005{}).AddIndex("idx_order_events_order_id", "order_id").Error; err != nil {
+			return err
+		}
+		return tx.Exec("ALTER TABLE stock_movements ADD COLUMN order_id integer").Error
+	},
+	Down: func(tx *gorm.DB) error {
+		if err := tx.Exec("ALTER TABLE stock_movements DROP COLUMN order_id").Error; err != nil {
+			return err
+		}
+		return tx.DropTableIfExists("order_events", "order_items", "orders", "cart_items", "carts").Error
+	},
+}
@@ -0,0 +1,35 @@
+package migrate
+
+import "github.com/jinzhu/gorm"
+
+// isbn adds the ISBN-13 and ISBN-10 columns. Only ISBN-13 is unique; the
+// ISBN-10 is always derived from it.
+var isbn = Migration{
+	Version: 6,
+	Name:    "isbn",
+	Up: func(tx *gorm.DB) error {
+		for _, stmt := range []string{
+			"ALTER TABLE books ADD COLUMN isbn13 char(13)",
+			"ALTER TABLE books ADD COLUMN isbn10 char(10)",
+		} {
+			if err := tx.Exec(stmt).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Table("books").AddUniqueIndex("idx_books_isbn13", "isbn13").Error
+	},
+	Down: func(tx *gorm.DB) error {
+		if err := tx.Table("books").RemoveIndex("idx_books_isbn13").Error; err != nil {
+			return err
+		}
+		for _, stmt := range []string{
+			"ALTER TABLE books DROP COLUMN isbn10",
+			"ALTER TABLE books DROP COLUMN isbn13",
+		} {
+			if err := tx.Exec(stmt).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
@@ -0,0 +1,32 @@
+package migrate
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+type bookCover0007 struct {
+	BookID      uint   `gorm:"primary_key;auto_increment:false"`
+	Digest      string `gorm:"not null"`
+	ContentType string `gorm:"not null"`
+	Size        int64  `gorm:"not null"`
+	Width       int    `gorm:"not null"`
+	Height      int    `gorm:"not null"`
+	UpdatedAt   time.Time
+}
+
+func (bookCover0007) TableName() string { return "book_covers" }
+
+// bookCovers records the cover image of each book; the images themselves are
+// kept in blob storage.
+var bookCovers = Migration{
+	Version: 7,
+	Name:    "book_covers",
+	Up: func(tx *gorm.DB) error {
+		return tx.CreateTable(&bookCover0007{}).Error
+	},
+	Down: func(tx *gorm.DB) error {
+		return tx.DropTableIfExists("book_covers").Error
+	},
+}
@@ -0,0 +1,37 @@
+package migrate
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+type bookAudit0008 struct {
+	ID        uint   `gorm:"primary_key"`
+	BookID    uint   `gorm:"not null"`
+	Revision  uint   `gorm:"not null"`
+	Action    string `gorm:"type:varchar(16);not null"`
+	Actor     string `gorm:"not null"`
+	RequestID string
+	Note      string
+	Before    string `gorm:"column:before_json;type:text"`
+	After     string `gorm:"column:after_json;type:text"`
+	Changes   string `gorm:"type:text"`
+	CreatedAt time.Time
+}
+
+func (bookAudit0008) TableName() string { return "book_audits" }
+
+// bookAudits adds the append-only log of book changes. Existing books get no
+// entries; their history starts with the next change.
+var bookAudits = Migration{
+	Version: 8,
+	Name:    "book_audits",
+	Up: func(tx *gorm.DB) error {
+		return tx.CreateTable(&bookAudit0008{}).
+			AddIndex("idx_book_audits_book_id_revision", "book_id", "revision").Error
+	},
+	Down: func(tx *gorm.DB) error {
+		return tx.DropTableIfExists("book_audits").Error
+	},
+}
@@ -0,0 +1,56 @@
+package migrate
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+type bookReview0009 struct {
+	ID        uint   `gorm:"primary_key"`
+	BookID    uint   `gorm:"not null"`
+	Reviewer  string `gorm:"not null"`
+	Rating    int    `gorm:"not null"`
+	Title     string
+	Body      string `gorm:"type:text"`
+	Status    string `gorm:"type:varchar(16);not null"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (bookReview0009) TableName() string { return "book_reviews" }
+
+// bookReviews adds reviews, one per reviewer and book, and the rating
+// summary they keep on each book.
+var bookReviews = Migration{
+	Version: 9,
+	Name:    "book_reviews",
+	Up: func(tx *gorm.DB) error {
+		err := tx.CreateTable(&bookReview0009{}).
+			AddUniqueIndex("idx_book_reviews_book_id_reviewer", "book_id", "reviewer").
+			AddIndex("idx_book_reviews_book_id_status", "book_id", "status").Error
+		if err != nil {
+			return err
+		}
+		for _, stmt := range []string{
+			"ALTER TABLE books ADD COLUMN rating_average double NOT NULL DEFAULT 0",
+			"ALTER TABLE books ADD COLUMN rating_count int NOT NULL DEFAULT 0",
+		} {
+			if err := tx.Exec(stmt).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+	Down: func(tx *gorm.DB) error {
+		for _, stmt := range []string{
+			"ALTER TABLE books DROP COLUMN rating_count",
+			"ALTER TABLE books DROP COLUMN rating_average",
+		} {
+			if err := tx.Exec(stmt).Error; err != nil {
+				return err
+			}
+		}
+		return tx.DropTableIfExists("book_reviews").Error
+	},
+}
@@ -0,0 +1,22 @@
+package migrate
+
+import "github.com/jinzhu/gorm"
+
+// cartCustomers records who owns each cart. Carts created before it belong
+// to nobody, so only editors can still reach them.
+var cartCustomers = Migration{
+	Version: 10,
+	Name:    "cart_customers",
+	Up: func(tx *gorm.DB) error {
+		if err := tx.Exec("ALTER TABLE carts ADD COLUMN customer varchar(255) NOT NULL DEFAULT ''").Error; err != nil {
+			return err
+		}
+		return tx.Table("carts").AddIndex("idx_carts_customer", "customer").Error
+	},
+	Down: func(tx *gorm.DB) error {
+		if err := tx.Table("carts").RemoveIndex("idx_carts_customer").Error; err != nil {
+			return err
+		}
+		return tx.Exec("ALTER TABLE carts DROP COLUMN customer").Error
+	},
+}
@@ -0,0 +1,177 @@
+package migrate
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// Migration is one numbered schema change. Down must undo Up exactly.
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error
+}
+
+// record is a row of the migrations table.
+type record struct {
+	Version   int `gorm:"primary_key;auto_increment:false"`
+	Name      string
+	AppliedAt time.Time
+}
+
+func (record) TableName() string { return "migrations" }
+
+// Status describes one known migration and whether it has been applied.
+type Status struct {
+	Version   int
+	Name      string
+	AppliedAt *time.Time
+}
+
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+// New returns a Migrator for migrations, which may be given in any order.
+func New(db *gorm.DB, migrations []Migration) (*Migrator, error) {
+	ms := append([]Migration(nil), migrations...)
+	sort.Slice(ms, func(i, j int) bool { return ms[i].Version < ms[j].Version })
+	for i := 1; i < len(ms); i++ {
+		if ms[i].Version == ms[i-1].Version {
+			return nil, fmt.Errorf("migrate: version %d is used twice", ms[i].Version)
+		}
+	}
+	if !db.HasTable(&record{}) {
+		if err := db.CreateTable(&record{}).Error; err != nil {
+			return nil, fmt.Errorf("migrate: creating migrations table: %w", err)
+		}
+	}
+	return &Migrator{db: db, migrations: ms}, nil
+}
+
+func (m *Migrator) applied() (map[int]record, error) {
+	var rows []record
+	if err := m.db.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	done := make(map[int]record, len(rows))
+	for _, r := range rows {
+		done[r.Version] = r
+	}
+	return done, nil
+}
+
+func (m *Migrator) Status() ([]Status, error) {
+	done, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Status, len(m.migrations))
+	for i, mg := range m.migrations {
+		out[i] = Status{Version: mg.Version, Name: mg.Name}
+		if r, ok := done[mg.Version]; ok {
+			at := r.AppliedAt
+			out[i].AppliedAt = &at
+		}
+	}
+	return out, nil
+}
+
+// Pending lists migrations that have not been applied, oldest first.
+func (m *Migrator) Pending() ([]Migration, error) {
+	done, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	var pending []Migration
+	for _, mg := range m.migrations {
+		if _, ok := done[mg.Version]; !ok {
+			pending = append(pending, mg)
+		}
+	}
+	return pending, nil
+}
+
+// Unknown lists versions recorded in the database that this binary does not
+// know about, which means the schema is newer than the code.
+func (m *Migrator) Unknown() ([]int, error) {
+	done, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[int]bool, len(m.migrations))
+	for _, mg := range m.migrations {
+		known[mg.Version] = true
+	}
+	var unknown []int
+	for v := range done {
+		if !known[v] {
+			unknown = append(unknown, v)
+		}
+	}
+	sort.Ints(unknown)
+	return unknown, nil
+}
+
+// Up applies every pending migration in order and returns those applied.
+func (m *Migrator) Up() ([]Migration, error) {
+	pending, err := m.Pending()
+	if err != nil {
+		return nil, err
+	}
+	for i, mg := range pending {
+		err := m.run(mg, mg.Up, func(tx *gorm.DB) error {
+			return tx.Create(&record{Version: mg.Version, Name: mg.Name, AppliedAt: time.Now()}).Error
+		})
+		if err != nil {
+			return pending[:i], fmt.Errorf("migrate: %04d %s up: %w", mg.Version, mg.Name, err)
+		}
+	}
+	return pending, nil
+}
+
+// Down rolls back the latest steps applied migrations, newest first.
+func (m *Migrator) Down(steps int) ([]Migration, error) {
+	done, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	var rolled []Migration
+	for i := len(m.migrations) - 1; i >= 0 && len(rolled) < steps; i-- {
+		mg := m.migrations[i]
+		if _, ok := done[mg.Version]; !ok {
+			continue
+		}
+		err := m.run(mg, mg.Down, func(tx *gorm.DB) error {
+			return tx.Delete(&record{Version: mg.Version}).Error
+		})
+		if err != nil {
+			return rolled, fmt.Errorf("migrate: %04d %s down: %w", mg.Version, mg.Name, err)
+		}
+		rolled = append(rolled, mg)
+	}
+	return rolled, nil
+}
+
+// run executes step and the bookkeeping in one transaction. MySQL commits DDL
+// implicitly, so there a failed step may leave partial changes behind.
+func (m *Migrator) run(mg Migration, step, bookkeeping func(tx *gorm.DB) error) error {
+	tx := m.db.Begin()
+	if tx.Error != nil {
+		return tx.Error
+	}
+	if err := step(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := bookkeeping(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
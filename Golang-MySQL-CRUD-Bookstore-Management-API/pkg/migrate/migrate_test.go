@@ -0,0 +1,106 @@
+package migrate_test
+
+import (
+	"testing"
+
+	"github.com/jinzhu/gorm"
+	"github.com/yoloxsta/go-bookstore/pkg/config"
+	"github.com/yoloxsta/go-bookstore/pkg/migrate"
+	"github.com/yoloxsta/go-bookstore/pkg/models"
+)
+
+// baselineBook is the model the service used to AutoMigrate before
+// migrations existed.
+type baselineBook struct {
+	gorm.Model
+	Name        string `json:"name"`
+	Author      string `json:"author"`
+	Publication string `json:"publication"`
+}
+
+func (baselineBook) TableName() string { return "books" }
+
+func openSQLite(t *testing.T) *gorm.DB {
+	t.Helper()
+	cfg := config.Default().Database
+	cfg.Driver, cfg.Path = config.DriverSQLite, ":memory:"
+	db, err := config.Connect(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func up(t *testing.T, db *gorm.DB) {
+	t.Helper()
+	m, err := migrate.New(db, migrate.All)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Up(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUpTakesOverTheBaselineSchema(t *testing.T) {
+	db := openSQLite(t)
+	if err := db.AutoMigrate(&baselineBook{}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&baselineBook{Name: "Dune", Author: "Frank Herbert", Publication: "Chilton"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	up(t, db)
+	if !db.Dialect().HasColumn("books", "version") {
+		t.Fatal("books has no version column after migrating")
+	}
+
+	store, err := models.NewGormStore(config.Default().Inventory, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := store.Books.Get(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Version != 1 || b.Name != "Dune" || len(b.AuthorIDs) != 1 {
+		t.Fatalf("existing book after migrating = %+v; want version 1 and its author linked", b)
+	}
+	b.Publication = "Ace"
+	if err := store.Books.Update(b); err != nil || b.Version != 2 {
+		t.Fatalf("Update = %v, version %d; want version 2", err, b.Version)
+	}
+	stale := *b
+	stale.Version = 1
+	if err := store.Books.Update(&stale); err != models.ErrConflict {
+		t.Errorf("Update of a stale copy = %v, want ErrConflict", err)
+	}
+	created := &models.Book{Name: "Emma", Author: "Jane Austen"}
+	if err := store.Books.Create(created); err != nil || created.Version != 1 {
+		t.Fatalf("Create = %v, version %d; want version 1", err, created.Version)
+	}
+}
+
+func TestUpDownUp(t *testing.T) {
+	db := openSQLite(t)
+	up(t, db)
+	m, err := migrate.New(db, migrate.All)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Down(len(migrate.All)); err != nil {
+		t.Fatal(err)
+	}
+	if db.HasTable("books") {
+		t.Fatal("books survived migrating all the way down")
+	}
+	up(t, db)
+	store, err := models.NewGormStore(config.Default().Inventory, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Books.Create(&models.Book{Name: "Emma", Author: "Jane Austen"}); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,16 @@
+package migrate
+
+// All is the bookstore schema history. Append new migrations with the next
+// version number; never edit one that has been released.
+var All = []Migration{
+	createBooks,
+	booksFulltext,
+	authorsPublishers,
+	inventory,
+	orders,
+	isbn,
+	bookCovers,
+	bookAudits,
+	bookReviews,
+	cartCustomers,
+}
@@ -0,0 +1,183 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+)
+
+// Audit actions.
+const (
+	AuditCreate  = "create"
+	AuditUpdate  = "update"
+	AuditDelete  = "delete"
+	AuditRestore = "restore"
+	AuditPurge   = "purge"
+)
+
+// AuditSystem is the actor recorded for changes made without one, such as
+// the trash purge job.
+const AuditSystem = "system"
+
+// AuditInfo says who is making a change. Book repositories returned by
+// WithAudit record it with every change they write.
+type AuditInfo struct {
+	Actor     string
+	RequestID string
+	// Note explains the change, e.g. "revert to revision 3".
+	Note string
+}
+
+// RawJSON is a JSON document kept as text so it fits any text column.
+type RawJSON string
+
+func (j RawJSON) MarshalJSON() ([]byte, error) {
+	if j == "" {
+		return []byte("null"), nil
+	}
+	return []byte(j), nil
+}
+
+// BookAudit is one entry of the append-only book audit log. Before and After
+// are full snapshots of the book; Changes maps each field that differs
+// between them to its old and new value.
+type BookAudit struct {
+	ID     uint `json:"id" gorm:"primary_key"`
+	BookID uint `json:"book_id"`
+	// Revision is the book's version after the change.
+	Revision  uint      `json:"revision"`
+	Action    string    `json:"action"`
+	Actor     string    `json:"actor"`
+	RequestID string    `json:"request_id"`
+	Note      string    `json:"note,omitempty"`
+	Before    RawJSON   `json:"before" gorm:"column:before_json"`
+	After     RawJSON   `json:"after" gorm:"column:after_json"`
+	Changes   RawJSON   `json:"changes"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (BookAudit) TableName() string { return "book_audits" }
+
+// FieldChange is one entry of BookAudit.Changes.
+type FieldChange struct {
+	From json.RawMessage `json:"from"`
+	To   json.RawMessage `json:"to"`
+}
+
+// unaudited fields change on every write and would only clutter Changes.
+var unaudited = map[string]bool{"UpdatedAt": true, "version": true}
+
+// newAudit builds the entry for a change from before to after; either may be
+// nil for a creation or a purge.
+func newAudit(info AuditInfo, action string, before, after *Book) (BookAudit, error) {
+	e := BookAudit{
+		Action:    action,
+		Actor:     info.Actor,
+		RequestID: info.RequestID,
+		Note:      info.Note,
+		CreatedAt: time.Now(),
+	}
+	if e.Actor == "" {
+		e.Actor = AuditSystem
+	}
+	var err error
+	if e.Before, err = snapshot(before); err != nil {
+		return e, err
+	}
+	if e.After, err = snapshot(after); err != nil {
+		return e, err
+	}
+	if after != nil {
+		e.BookID, e.Revision = after.ID, after.Version
+	} else {
+		e.BookID, e.Revision = before.ID, before.Version
+	}
+
+	var old, cur map[string]json.RawMessage
+	json.Unmarshal([]byte(orNull(e.Before)), &old)
+	json.Unmarshal([]byte(orNull(e.After)), &cur)
+	changes := map[string]FieldChange{}
+	for k, v := range cur {
+		if from := orNullRaw(old[k]); !unaudited[k] && !bytes.Equal(from, v) {
+			changes[k] = FieldChange{From: from, To: v}
+		}
+	}
+	for k, v := range old {
+		if _, ok := cur[k]; !ok && !unaudited[k] && string(v) != "null" {
+			changes[k] = FieldChange{From: v, To: json.RawMessage("null")}
+		}
+	}
+	data, err := json.Marshal(changes)
+	e.Changes = RawJSON(data)
+	return e, err
+}
+
+// snapshot is the stored form of a book: its own fields and links, without
+// the related records that are only embedded on request.
+func snapshot(b *Book) (RawJSON, error) {
+	if b == nil {
+		return "", nil
+	}
+	s := *b
+	s.Authors, s.Publisher, s.Stock, s.Cover = nil, nil, nil, nil
+	if s.AuthorIDs == nil {
+		s.AuthorIDs = []uint{}
+	}
+	data, err := json.Marshal(s)
+	return RawJSON(data), err
+}
+
+func orNull(j RawJSON) RawJSON {
+	if j == "" {
+		return "null"
+	}
+	return j
+}
+
+func orNullRaw(m json.RawMessage) json.RawMessage {
+	if m == nil {
+		return json.RawMessage("null")
+	}
+	return m
+}
+
+// Book decodes the After snapshot of a create, update or restore entry.
+func (e BookAudit) Book() (*Book, error) {
+	var b Book
+	if err := json.Unmarshal([]byte(orNull(e.After)), &b); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// AuditQuery pages through a book's audit log, newest first.
+type AuditQuery struct {
+	Limit  int
+	Offset int
+}
+
+func (q AuditQuery) normalize() AuditQuery {
+	q.Limit = BookQuery{Limit: q.Limit}.PageSize()
+	if q.Offset < 0 {
+		q.Offset = 0
+	}
+	return q
+}
+
+// AuditRepository reads the log written by the book repositories. Entries
+// outlive their book, so the history of a purged book stays readable.
+type AuditRepository interface {
+	History(bookID int64, q AuditQuery) ([]BookAudit, int64, error)
+	// Revision returns the entry whose After snapshot is the given version
+	// of the book, or ErrNotFound.
+	Revision(bookID int64, revision uint) (*BookAudit, error)
+	// Since returns up to limit entries with IDs above afterID, oldest
+	// first, for one book or, when bookID is zero, for every book. LastID is
+	// the newest entry's ID, or zero when the log is empty. Together they let
+	// a watcher tail the log.
+	Since(afterID uint, bookID int64, limit int) ([]BookAudit, error)
+	LastID() (uint, error)
+}
+
+// snapshotActions are the actions whose After snapshot is a live revision.
+var snapshotActions = []string{AuditCreate, AuditUpdate, AuditRestore}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/yoloxsta/go-bookstore/pkg/validation"
+)
+
+type Author struct {
+	gorm.Model
+	Name string `json:"name"`
+	// NameKey is NameKey(Name); it lets free-text credits find the author.
+	NameKey string `json:"-" gorm:"index"`
+}
+
+func (a *Author) Validate() error {
+	var errs validation.Errors
+	if errs.Required("name", a.Name) {
+		errs.MaxLength("name", a.Name, MaxAuthorLength)
+	}
+	return errs.Err()
+}
+
+type AuthorRepository interface {
+	Create(a *Author) error
+	List(q NameQuery) ([]Author, int64, error)
+	Get(id int64) (*Author, error)
+	// GetMany returns the live authors among ids, in no particular order.
+	GetMany(ids []uint) ([]Author, error)
+	Update(a *Author) error
+	// Delete returns ErrInUse while any book still credits the author.
+	Delete(id int64) error
+}
@@ -0,0 +1,85 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/yoloxsta/go-bookstore/pkg/cover"
+	"github.com/yoloxsta/go-bookstore/pkg/storage"
+)
+
+// UseBlobs deletes the cover images of every book purged through s.Books,
+// whether on request or by the trash purge job. Like UseCache, it must be
+// called before the repositories are handed out.
+func (s *Store) UseBlobs(blobs storage.Storage) {
+	s.Books = &blobBookRepository{BookRepository: s.Books, covers: s.Covers, blobs: blobs}
+}
+
+// blobBookRepository looks up the covers a purge is about to remove and
+// deletes their blobs once it has. Failures only leave unreferenced blobs
+// behind, so they are logged rather than returned.
+type blobBookRepository struct {
+	BookRepository
+	covers CoverRepository
+	blobs  storage.Storage
+}
+
+// Purge cannot race a cover upload: changing the cover bumps the version, so
+// the purge would fail with ErrConflict.
+func (r *blobBookRepository) Purge(b *Book) error {
+	c, err := r.covers.GetAny(int64(b.ID))
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	if err := r.BookRepository.Purge(b); err != nil {
+		return err
+	}
+	if c != nil {
+		r.deleteBlobs(c)
+	}
+	return nil
+}
+
+func (r *blobBookRepository) PurgeDeletedBefore(t time.Time) (int64, error) {
+	covers, err := r.covers.ListTrashedBefore(t)
+	if err != nil {
+		return 0, err
+	}
+	n, err := r.BookRepository.PurgeDeletedBefore(t)
+	if err != nil {
+		return n, err
+	}
+	for i := range covers {
+		// A book restored since its cover was listed is not purged.
+		if r.gone(covers[i].BookID) {
+			r.deleteBlobs(&covers[i])
+		}
+	}
+	return n, nil
+}
+
+// gone reports whether the book is neither live nor in the trash.
+func (r *blobBookRepository) gone(id uint) bool {
+	_, err := r.BookRepository.Get(int64(id))
+	if errors.Is(err, ErrNotFound) {
+		_, err = r.BookRepository.GetDeleted(int64(id))
+	}
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		slog.Error("checking purged book", "book", id, "err", err)
+	}
+	return errors.Is(err, ErrNotFound)
+}
+
+func (r *blobBookRepository) deleteBlobs(c *Cover) {
+	for _, name := range cover.Renditions() {
+		if err := r.blobs.Delete(context.Background(), cover.Key(c.BookID, c.Digest, name)); err != nil {
+			slog.Error("deleting cover blob of purged book", "book", c.BookID, "err", err)
+		}
+	}
+}
+
+func (r *blobBookRepository) WithAudit(info AuditInfo) BookRepository {
+	return &blobBookRepository{BookRepository: r.BookRepository.WithAudit(info), covers: r.covers, blobs: r.blobs}
+}
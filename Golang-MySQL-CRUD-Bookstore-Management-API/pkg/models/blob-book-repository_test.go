@@ -0,0 +1,74 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/yoloxsta/go-bookstore/pkg/cover"
+	"github.com/yoloxsta/go-bookstore/pkg/storage"
+)
+
+func TestPurgeDeletesCoverBlobs(t *testing.T) {
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			blobs := storage.NewLocal(t.TempDir())
+			store.UseBlobs(blobs)
+			ctx := context.Background()
+
+			// Books 1 and 2 are purged, 1 on request and 2 from the trash;
+			// 3 is trashed too late for the purge and 4 stays live.
+			var books []*Book
+			for i := 1; i <= 4; i++ {
+				b := &Book{Name: "Dune", Author: "Herbert"}
+				if err := store.Books.Create(b); err != nil {
+					t.Fatal(err)
+				}
+				digest := string(rune('a'+i)) + "0123456789abcdef"
+				for _, name := range cover.Renditions() {
+					if err := blobs.Put(ctx, cover.Key(b.ID, digest, name), []byte("img"), "image/png"); err != nil {
+						t.Fatal(err)
+					}
+				}
+				if _, err := store.Covers.Put(b, &Cover{Digest: digest, ContentType: "image/png"}); err != nil {
+					t.Fatal(err)
+				}
+				books = append(books, b)
+			}
+			if err := store.Books.Purge(books[0]); err != nil {
+				t.Fatal(err)
+			}
+			if err := store.Books.Delete(books[1]); err != nil {
+				t.Fatal(err)
+			}
+			time.Sleep(10 * time.Millisecond)
+			cutoff := time.Now()
+			time.Sleep(10 * time.Millisecond)
+			if err := store.Books.Delete(books[2]); err != nil {
+				t.Fatal(err)
+			}
+			if n, err := store.Books.PurgeDeletedBefore(cutoff); err != nil || n != 1 {
+				t.Fatalf("PurgeDeletedBefore = %d, %v; want 1", n, err)
+			}
+
+			for i, b := range books {
+				c, err := store.Covers.GetAny(int64(b.ID))
+				purged := i < 2
+				if purged != errors.Is(err, ErrNotFound) {
+					t.Fatalf("book %d: cover %+v, %v", b.ID, c, err)
+				}
+				digest := string(rune('a'+i+1)) + "0123456789abcdef"
+				for _, name := range cover.Renditions() {
+					blob, err := blobs.Get(ctx, cover.Key(b.ID, digest, name))
+					if err == nil {
+						blob.Close()
+					}
+					if purged != errors.Is(err, storage.ErrNotFound) {
+						t.Errorf("book %d, %s: purged %t, blob err %v", b.ID, name, purged, err)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,259 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+// ErrInvalidQuery wraps every problem with a BookQuery that the caller can fix.
+var (
+	ErrInvalidQuery  = errors.New("invalid query")
+	ErrInvalidCursor = fmt.Errorf("%w: cursor is malformed or was issued for a different sort", ErrInvalidQuery)
+)
+
+// BookQuery selects one page of books. Filters are case-insensitive substring
+// matches. Either Offset or Cursor may be used, not both.
+type BookQuery struct {
+	Limit  int
+	Offset int
+	Cursor string
+
+	Name        string
+	Author      string
+	Publication string
+	// AuthorID and PublisherID, when non-zero, keep only books linked to them.
+	AuthorID    uint
+	PublisherID uint
+
+	Sort []SortField
+
+	// Deleted lists soft-deleted books (the trash) instead of live ones.
+	Deleted bool
+	// SkipTotal leaves BookPage.Total at zero, saving a count query for
+	// callers that only walk the pages.
+	SkipTotal bool
+	// NoCache reads past the book cache, for one-off scans such as exports
+	// that would only fill it with pages nobody asks for again.
+	NoCache bool
+}
+
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// BookPage is one page of a listing. NextCursor is empty on the last page.
+type BookPage struct {
+	Books      []Book
+	Total      int64
+	NextCursor string
+}
+
+// sortColumns maps the public sort keys to their column names.
+var sortColumns = map[string]string{
+	"id":          "id",
+	"name":        "name",
+	"author":      "author",
+	"publication": "publication",
+	"created_at":  "created_at",
+	"updated_at":  "updated_at",
+}
+
+// ParseSort reads a spec like "-created_at,name"; a leading "-" sorts
+// descending.
+func ParseSort(spec string) ([]SortField, error) {
+	var fields []SortField
+	seen := map[string]bool{}
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		f := SortField{Field: strings.TrimPrefix(part, "-"), Desc: strings.HasPrefix(part, "-")}
+		if _, ok := sortColumns[f.Field]; !ok {
+			return nil, fmt.Errorf("%w: cannot sort by %q", ErrInvalidQuery, f.Field)
+		}
+		if seen[f.Field] {
+			return nil, fmt.Errorf("%w: %q appears twice in sort", ErrInvalidQuery, f.Field)
+		}
+		seen[f.Field] = true
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+// PageSize is Limit with the default and maximum applied.
+func (q BookQuery) PageSize() int {
+	switch {
+	case q.Limit <= 0:
+		return DefaultPageSize
+	case q.Limit > MaxPageSize:
+		return MaxPageSize
+	}
+	return q.Limit
+}
+
+// normalize applies defaults and appends id as a tie-breaker so every sort is
+// total, which keyset pagination depends on.
+func (q BookQuery) normalize() (BookQuery, error) {
+	q.Limit = q.PageSize()
+	if q.Offset < 0 {
+		return q, fmt.Errorf("%w: offset must not be negative", ErrInvalidQuery)
+	}
+	if q.Offset > 0 && q.Cursor != "" {
+		return q, fmt.Errorf("%w: offset and cursor cannot be combined", ErrInvalidQuery)
+	}
+	hasID := false
+	for _, f := range q.Sort {
+		hasID = hasID || f.Field == "id"
+	}
+	if !hasID {
+		q.Sort = append(append([]SortField(nil), q.Sort...), SortField{Field: "id"})
+	}
+	return q, nil
+}
+
+func (q BookQuery) sortSpec() string {
+	parts := make([]string, len(q.Sort))
+	for i, f := range q.Sort {
+		parts[i] = f.Field
+		if f.Desc {
+			parts[i] = "-" + f.Field
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// cursor is the position after the last row of a page: the sort spec it was
+// issued for and that row's value for each sort field.
+type cursor struct {
+	Sort   string            `json:"s"`
+	Values map[string]string `json:"v"`
+}
+
+func sortValue(b Book, field string) interface{} {
+	switch field {
+	case "id":
+		return b.ID
+	case "name":
+		return b.Name
+	case "author":
+		return b.Author
+	case "publication":
+		return b.Publication
+	case "created_at":
+		return b.CreatedAt
+	case "updated_at":
+		return b.UpdatedAt
+	}
+	return nil
+}
+
+func encodeCursor(q BookQuery, last Book) string {
+	c := cursor{Sort: q.sortSpec(), Values: map[string]string{}}
+	for _, f := range q.Sort {
+		switch v := sortValue(last, f.Field).(type) {
+		case time.Time:
+			c.Values[f.Field] = v.UTC().Format(time.RFC3339Nano)
+		default:
+			c.Values[f.Field] = fmt.Sprint(v)
+		}
+	}
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor returns the typed sort values for q.Sort, in order.
+func decodeCursor(q BookQuery) ([]interface{}, error) {
+	data, err := base64.RawURLEncoding.DecodeString(q.Cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil || c.Sort != q.sortSpec() {
+		return nil, ErrInvalidCursor
+	}
+	values := make([]interface{}, len(q.Sort))
+	for i, f := range q.Sort {
+		raw, ok := c.Values[f.Field]
+		if !ok {
+			return nil, ErrInvalidCursor
+		}
+		switch f.Field {
+		case "id":
+			var id uint
+			if _, err := fmt.Sscan(raw, &id); err != nil {
+				return nil, ErrInvalidCursor
+			}
+			values[i] = id
+		case "created_at", "updated_at":
+			t, err := time.Parse(time.RFC3339Nano, raw)
+			if err != nil {
+				return nil, ErrInvalidCursor
+			}
+			// Timestamps are written in the local zone, and SQLite compares them as text.
+			values[i] = t.Local()
+		default:
+			values[i] = raw
+		}
+	}
+	return values, nil
+}
+
+// likePattern turns a user substring into a LIKE pattern escaped with '!'.
+func likePattern(s string) string {
+	r := strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+	return "%" + r.Replace(strings.ToLower(s)) + "%"
+}
+
+// compareSortValues orders two values of the same sort field the way the
+// databases do, with strings compared case-insensitively.
+func compareSortValues(a, b interface{}) int {
+	switch a := a.(type) {
+	case uint:
+		b := b.(uint)
+		switch {
+		case a < b:
+			return -1
+		case a > b:
+			return 1
+		}
+		return 0
+	case string:
+		return strings.Compare(strings.ToLower(a), strings.ToLower(b.(string)))
+	case time.Time:
+		return a.Compare(b.(time.Time))
+	}
+	return 0
+}
+
+// compareKeys compares two rows' sort keys under q.Sort.
+func compareKeys(q BookQuery, a, b []interface{}) int {
+	for i, f := range q.Sort {
+		c := compareSortValues(a[i], b[i])
+		if f.Desc {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+func sortKey(q BookQuery, b Book) []interface{} {
+	key := make([]interface{}, len(q.Sort))
+	for i, f := range q.Sort {
+		key[i] = sortValue(b, f.Field)
+	}
+	return key
+}
@@ -0,0 +1,258 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yoloxsta/go-bookstore/pkg/validation"
+)
+
+// The tests in this file run against every BookRepository so the memory
+// store cannot drift from the gorm one.
+
+func strPtr(s string) *string { return &s }
+
+func createBook(t *testing.T, repo BookRepository, b *Book) *Book {
+	t.Helper()
+	if err := repo.Create(b); err != nil {
+		t.Fatalf("create %q: %v", b.Name, err)
+	}
+	return b
+}
+
+func wantFieldError(t *testing.T, err error, field string) {
+	t.Helper()
+	var errs validation.Errors
+	if !errors.As(err, &errs) || !strings.Contains(errs.Error(), field) {
+		t.Fatalf("err = %v, want a validation error on %s", err, field)
+	}
+}
+
+func TestBookRepositoryNotFound(t *testing.T) {
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			repo := store.Books
+			missing := &Book{Name: "Ghost", Version: 1}
+			missing.ID = 99
+			checks := map[string]error{}
+			_, checks["Get"] = repo.Get(99)
+			_, checks["GetByISBN"] = repo.GetByISBN("9780306406157")
+			_, checks["GetDeleted"] = repo.GetDeleted(99)
+			_, checks["Restore"] = repo.Restore(99)
+			for op, err := range checks {
+				if !errors.Is(err, ErrNotFound) {
+					t.Errorf("%s of a missing book: %v, want ErrNotFound", op, err)
+				}
+			}
+			for op, err := range map[string]error{
+				"Update": repo.Update(missing),
+				"Delete": repo.Delete(missing),
+				"Purge":  repo.Purge(missing),
+			} {
+				if !errors.Is(err, ErrNotFound) && !errors.Is(err, ErrConflict) {
+					t.Errorf("%s of a missing book: %v, want ErrNotFound or ErrConflict", op, err)
+				}
+			}
+		})
+	}
+}
+
+func TestBookRepositoryConflicts(t *testing.T) {
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			repo := store.Books
+			b := createBook(t, repo, &Book{Name: "Dune", Author: "Herbert", SKU: strPtr("DUNE-1"), ISBN13: strPtr("978-0-306-40615-7")})
+			if b.Version != 1 || *b.ISBN13 != "9780306406157" || b.ISBN10 == nil || *b.ISBN10 != "0306406152" {
+				t.Fatalf("created %+v", b)
+			}
+
+			stale := *b
+			b.Name = "Dune Messiah"
+			if err := repo.Update(b); err != nil || b.Version != 2 {
+				t.Fatalf("update: %v, version %d", err, b.Version)
+			}
+			stale.Name = "Children of Dune"
+			if err := repo.Update(&stale); !errors.Is(err, ErrConflict) {
+				t.Fatalf("stale update: %v, want ErrConflict", err)
+			}
+			if err := repo.Delete(&stale); !errors.Is(err, ErrConflict) {
+				t.Fatalf("stale delete: %v, want ErrConflict", err)
+			}
+			if err := repo.Purge(&stale); !errors.Is(err, ErrConflict) {
+				t.Fatalf("stale purge: %v, want ErrConflict", err)
+			}
+			if got, _ := repo.Get(int64(b.ID)); got == nil || got.Name != "Dune Messiah" {
+				t.Fatalf("stale writes changed the book: %+v", got)
+			}
+
+			wantFieldError(t, repo.Create(&Book{Name: "Other", ISBN13: strPtr("0306406152")}), "isbn13")
+			wantFieldError(t, repo.Create(&Book{Name: "Other", SKU: strPtr("DUNE-1")}), "sku")
+			wantFieldError(t, repo.CheckUnique(&Book{Name: "Other", ISBN13: strPtr("9780306406157")}), "isbn13")
+			if err := repo.CheckUnique(b); err != nil {
+				t.Fatalf("a book clashes with itself: %v", err)
+			}
+
+			// Trashed books keep their SKU and ISBN.
+			if err := repo.Delete(b); err != nil {
+				t.Fatal(err)
+			}
+			wantFieldError(t, repo.Create(&Book{Name: "Other", SKU: strPtr("DUNE-1")}), "sku")
+		})
+	}
+}
+
+func TestBookRepositoryPagination(t *testing.T) {
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			repo := store.Books
+			for i, n := range []string{"Echo", "alpha", "Delta", "charlie", "Bravo"} {
+				createBook(t, repo, &Book{Name: n, Author: fmt.Sprintf("Author %d", i%2)})
+			}
+			names := func(books []Book) string {
+				out := make([]string, len(books))
+				for i, b := range books {
+					out[i] = b.Name
+				}
+				return strings.Join(out, ",")
+			}
+
+			page, err := repo.List(BookQuery{})
+			if err != nil || page.Total != 5 || names(page.Books) != "Echo,alpha,Delta,charlie,Bravo" || page.NextCursor != "" {
+				t.Fatalf("default list: %v, total %d, %s, cursor %q", err, page.Total, names(page.Books), page.NextCursor)
+			}
+
+			page, err = repo.List(BookQuery{Limit: 2, Offset: 2, Sort: []SortField{{Field: "id", Desc: true}}})
+			if err != nil || page.Total != 5 || names(page.Books) != "Delta,alpha" {
+				t.Fatalf("offset page: %v, total %d, %s", err, page.Total, names(page.Books))
+			}
+
+			// Names sort case-insensitively, as under MySQL's default collation.
+			page, err = repo.List(BookQuery{Sort: []SortField{{Field: "name"}}})
+			if err != nil || names(page.Books) != "alpha,Bravo,charlie,Delta,Echo" {
+				t.Fatalf("by name: %v, %s", err, names(page.Books))
+			}
+
+			page, err = repo.List(BookQuery{Author: "AUTHOR 1"})
+			if err != nil || page.Total != 2 || names(page.Books) != "alpha,charlie" {
+				t.Fatalf("filtered: %v, total %d, %s", err, page.Total, names(page.Books))
+			}
+
+			// Walking by cursor visits every book once, in order, whatever
+			// the page size.
+			for _, sort := range [][]SortField{{{Field: "name"}}, {{Field: "name", Desc: true}}, {{Field: "author"}, {Field: "name", Desc: true}}} {
+				var walked []Book
+				q := BookQuery{Limit: 2, Sort: sort}
+				for pages := 0; ; pages++ {
+					if pages > 5 {
+						t.Fatalf("sort %v: cursor never ran out", sort)
+					}
+					page, err := repo.List(q)
+					if err != nil {
+						t.Fatalf("sort %v: %v", sort, err)
+					}
+					walked = append(walked, page.Books...)
+					if page.NextCursor == "" {
+						break
+					}
+					q.Cursor = page.NextCursor
+				}
+				all, err := repo.List(BookQuery{Sort: sort})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if names(walked) != names(all.Books) {
+					t.Errorf("sort %v: cursor walk %s, want %s", sort, names(walked), names(all.Books))
+				}
+			}
+
+			first, err := repo.List(BookQuery{Limit: 2, Sort: []SortField{{Field: "name"}}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, q := range []BookQuery{
+				{Cursor: first.NextCursor, Sort: []SortField{{Field: "author"}}},
+				{Cursor: first.NextCursor, Offset: 1, Sort: []SortField{{Field: "name"}}},
+				{Cursor: "not a cursor"},
+				{Offset: -1},
+			} {
+				if _, err := repo.List(q); !errors.Is(err, ErrInvalidQuery) {
+					t.Errorf("List(%+v): %v, want ErrInvalidQuery", q, err)
+				}
+			}
+		})
+	}
+}
+
+func TestBookRepositorySoftDelete(t *testing.T) {
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			repo := store.Books
+			kept := createBook(t, repo, &Book{Name: "Kept", Author: "A"})
+			trashed := createBook(t, repo, &Book{Name: "Trashed", Author: "B", ISBN13: strPtr("9780306406157")})
+			if err := repo.Delete(trashed); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := repo.Get(int64(trashed.ID)); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("Get of a trashed book: %v, want ErrNotFound", err)
+			}
+			if _, err := repo.GetByISBN("9780306406157"); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("GetByISBN of a trashed book: %v, want ErrNotFound", err)
+			}
+			if _, err := repo.GetDeleted(int64(kept.ID)); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("GetDeleted of a live book: %v, want ErrNotFound", err)
+			}
+			got, err := repo.GetDeleted(int64(trashed.ID))
+			if err != nil || got.DeletedAt == nil {
+				t.Fatalf("GetDeleted: %+v, %v", got, err)
+			}
+			live, err := repo.List(BookQuery{})
+			if err != nil || live.Total != 1 || len(live.Books) != 1 || live.Books[0].ID != kept.ID {
+				t.Fatalf("live list: %+v, %v", live, err)
+			}
+			trash, err := repo.List(BookQuery{Deleted: true})
+			if err != nil || trash.Total != 1 || len(trash.Books) != 1 || trash.Books[0].ID != trashed.ID {
+				t.Fatalf("trash list: %+v, %v", trash, err)
+			}
+			if results, err := repo.Search("trashed", 10); err != nil || len(results) != 0 {
+				t.Fatalf("search found trashed books: %+v, %v", results, err)
+			}
+
+			restored, err := repo.Restore(int64(trashed.ID))
+			if err != nil || restored.DeletedAt != nil {
+				t.Fatalf("Restore: %+v, %v", restored, err)
+			}
+			if _, err := repo.Restore(int64(trashed.ID)); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("restoring a live book: %v, want ErrNotFound", err)
+			}
+			if got, err := repo.GetByISBN("9780306406157"); err != nil || got.ID != trashed.ID {
+				t.Fatalf("GetByISBN after restore: %+v, %v", got, err)
+			}
+
+			// Purging removes a trashed book for good, leaving newer trash.
+			if err := repo.Delete(restored); err != nil {
+				t.Fatal(err)
+			}
+			cutoff := time.Now().Add(time.Millisecond)
+			time.Sleep(10 * time.Millisecond)
+			if err := repo.Delete(kept); err != nil {
+				t.Fatal(err)
+			}
+			n, err := repo.PurgeDeletedBefore(cutoff)
+			if err != nil || n != 1 {
+				t.Fatalf("PurgeDeletedBefore = %d, %v; want 1", n, err)
+			}
+			if _, err := repo.GetDeleted(int64(trashed.ID)); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("GetDeleted of a purged book: %v, want ErrNotFound", err)
+			}
+			if _, err := repo.GetDeleted(int64(kept.ID)); err != nil {
+				t.Fatalf("the newer trash was purged: %v", err)
+			}
+			// A purged book frees its ISBN.
+			createBook(t, repo, &Book{Name: "Reissue", ISBN13: strPtr("9780306406157")})
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"strings"
+
+	"github.com/yoloxsta/go-bookstore/pkg/search"
+)
+
+// SearchResult is a book ranked by relevance, with the matched words of each
+// field wrapped in <em>.
+type SearchResult struct {
+	Book       Book              `json:"book"`
+	Score      float64           `json:"score"`
+	Highlights map[string]string `json:"highlights"`
+}
+
+func newBookIndex() *search.Index {
+	return search.NewIndex(
+		search.Field{Name: "name", Weight: 3},
+		search.Field{Name: "author", Weight: 2},
+		search.Field{Name: "publication", Weight: 1},
+	)
+}
+
+func indexBook(ix *search.Index, b Book) {
+	ix.Put(b.ID, b.Name, b.Author, b.Publication)
+}
+
+// highlightBook builds the highlights for a row found by the database rather
+// than the in-process index.
+func highlightBook(b Book, terms []string) map[string]string {
+	h := make(map[string]string)
+	for field, v := range map[string]string{"name": b.Name, "author": b.Author, "publication": b.Publication} {
+		if s, ok := search.Highlight(v, terms); ok {
+			h[field] = s
+		}
+	}
+	return h
+}
+
+// booleanQuery turns free text into a MySQL BOOLEAN MODE expression in which
+// every term also matches as a prefix.
+func booleanQuery(terms []string) string {
+	parts := make([]string, len(terms))
+	for i, t := range terms {
+		parts[i] = t + "*"
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"testing"
+)
+
+func TestBooleanQueryOnlyMatchesPrefixes(t *testing.T) {
+	// FULLTEXT has no edit distance; typos on MySQL are left to searchScan.
+	if got, want := booleanQuery([]string{"go", "programing"}), "go* programing*"; got != want {
+		t.Fatalf("booleanQuery = %q, want %q", got, want)
+	}
+}
+
+func TestSearchScanToleratesTypos(t *testing.T) {
+	store, db := newSQLiteStore(t)
+	for _, b := range []*Book{
+		{Name: "The Go Programming Language", Author: "Donovan"},
+		{Name: "Programming Pearls", Author: "Bentley"},
+		{Name: "Dune", Author: "Herbert"},
+	} {
+		if err := store.Books.Create(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pearls, err := store.Books.Get(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Books.Delete(pearls); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := newGormBookRepository(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, err := r.searchScan("programing", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Book.ID != 1 {
+		t.Fatalf("searchScan found %+v, want only book 1", results)
+	}
+	if got, want := results[0].Highlights["name"], "The Go <em>Programming</em> Language"; got != want {
+		t.Fatalf("name highlight = %q, want %q", got, want)
+	}
+}
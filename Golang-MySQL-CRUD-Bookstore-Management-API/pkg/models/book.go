@@ -1,45 +1,210 @@
 package models
 
 import (
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
 	"github.com/jinzhu/gorm"
-	"github.com/yoloxsta/go-bookstore/pkg/config"
+	"github.com/yoloxsta/go-bookstore/pkg/isbn"
+	"github.com/yoloxsta/go-bookstore/pkg/validation"
 )
 
-var db *gorm.DB
+var (
+	ErrNotFound = errors.New("models: record not found")
+	// ErrConflict means the row's version no longer matches the one the caller
+	// read, i.e. someone else changed it in between.
+	ErrConflict = errors.New("models: record was modified concurrently")
+	// ErrInUse means a record cannot be deleted while others refer to it.
+	ErrInUse = errors.New("models: record is still referenced")
+)
 
 type Book struct {
 	gorm.Model
-	Name        string `gorm:""json:"name"`
+	Name        string `json:"name"`
 	Author      string `json:"author"`
 	Publication string `json:"publication"`
+	// Version increases on every update and backs optimistic locking.
+	Version uint `json:"version" gorm:"not null;default:1"`
+
+	// SKU is optional but unique across all books, including trashed ones.
+	SKU *string `json:"sku"`
+	// ISBN13 and ISBN10 may be written in either form, with or without
+	// hyphens; they are stored as bare digits and each is filled in from the
+	// other. ISBN13 is unique across all books, including trashed ones. 979
+	// ISBNs have no ISBN-10.
+	ISBN13 *string `json:"isbn13" gorm:"column:isbn13"`
+	ISBN10 *string `json:"isbn10" gorm:"column:isbn10"`
+	// PriceCents is the list price in the smallest currency unit.
+	PriceCents int64 `json:"price_cents" gorm:"not null;default:0"`
+	// RatingAverage and RatingCount summarize the approved reviews. They are
+	// kept by the ReviewRepository and ignored when a book is written.
+	RatingAverage float64 `json:"rating_average" gorm:"not null;default:0"`
+	RatingCount   int     `json:"rating_count" gorm:"not null;default:0"`
+
+	// AuthorIDs and PublisherID link the book to its Author and Publisher
+	// records. When they are left empty on write, they are resolved from the
+	// Author and Publication credits, creating records as needed.
+	PublisherID *uint  `json:"publisher_id"`
+	AuthorIDs   []uint `json:"author_ids" gorm:"-"`
+
+	// Authors and Publisher are only filled when a request asks for
+	// ?include=authors,publisher.
+	Authors   []Author   `json:"authors,omitempty" gorm:"-"`
+	Publisher *Publisher `json:"publisher,omitempty" gorm:"-"`
+	// Stock is only filled for ?include=stock; it is changed through the
+	// inventory endpoints, never through the book.
+	Stock *StockLevel `json:"stock,omitempty" gorm:"-"`
+	// Cover is filled by GET /book/{bookId} when the book has a cover; it is
+	// changed through /book/{bookId}/cover.
+	Cover *Cover `json:"cover,omitempty" gorm:"-"`
 }
 
-func init() {
-	config.Connect()
-	db = config.GetDB()
-	db.AutoMigrate(&Book{})
+// Column limits enforced by Validate.
+const (
+	MaxNameLength        = 255
+	MaxAuthorLength      = 255
+	MaxPublicationLength = 255
+)
+
+var skuPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,63}$`)
+
+func (b *Book) Validate() error {
+	var errs validation.Errors
+	if errs.Required("name", b.Name) {
+		errs.MaxLength("name", b.Name, MaxNameLength)
+	}
+	if len(b.AuthorIDs) == 0 {
+		errs.Required("author", b.Author)
+	}
+	errs.MaxLength("author", b.Author, MaxAuthorLength)
+	errs.MaxLength("publication", b.Publication, MaxPublicationLength)
+	if b.SKU != nil && *b.SKU == "" {
+		errs.Add("sku", "must be null or non-empty")
+	} else if b.SKU != nil {
+		errs.Match("sku", *b.SKU, skuPattern, "up to 64 letters, digits, '.', '_' or '-'")
+	}
+	if b.PriceCents < 0 {
+		errs.Add("price_cents", "must not be negative")
+	}
+	b.checkISBN(&errs)
+	seen := make(map[uint]bool, len(b.AuthorIDs))
+	for _, id := range b.AuthorIDs {
+		if seen[id] {
+			errs.Add("author_ids", "lists author %d twice", id)
+		}
+		seen[id] = true
+	}
+	return errs.Err()
 }
 
-func (b *Book) CreateBook() *Book {
-	db.NewRecord(b)
-	db.Create(&b)
-	return b
+// checkISBN validates the ISBN fields, each in its own form, and checks that
+// they agree when both are given. It returns the normalized ISBN-13, or ""
+// when neither field is set.
+func (b *Book) checkISBN(errs *validation.Errors) (string, bool) {
+	ok := true
+	check := func(field, form string, v *string, want10 bool) string {
+		if v == nil {
+			return ""
+		}
+		n, err := isbn.Normalize(*v)
+		if err == nil && isbn.Is10(*v) != want10 {
+			err = fmt.Errorf("must be an %s", form)
+		}
+		if err != nil {
+			errs.Add(field, "%s", err)
+			ok = false
+			return ""
+		}
+		return n
+	}
+	from13 := check("isbn13", "ISBN-13", b.ISBN13, false)
+	from10 := check("isbn10", "ISBN-10", b.ISBN10, true)
+	if from13 != "" && from10 != "" && from13 != from10 {
+		errs.Add("isbn10", "does not match isbn13")
+		ok = false
+	}
+	if from13 == "" {
+		return from10, ok
+	}
+	return from13, ok
 }
 
-func GetAllBooks() []Book {
-	var Books []Book
-	db.Find(&Books)
-	return Books
+// normalizeISBN stores both ISBN fields in their bare canonical form, deriving
+// each from the other.
+func (b *Book) normalizeISBN() error {
+	var errs validation.Errors
+	n, ok := b.checkISBN(&errs)
+	if !ok {
+		return errs.Err()
+	}
+	b.ISBN13, b.ISBN10 = nil, nil
+	if n == "" {
+		return nil
+	}
+	b.ISBN13 = &n
+	if s, ok := isbn.To10(n); ok {
+		b.ISBN10 = &s
+	}
+	return nil
 }
 
-func GetBookById(Id int64) (*Book, *gorm.DB) {
-	var getBook Book
-	db := db.Where("ID=?", Id).Find(&getBook)
-	return &getBook, db
+func isbnTaken(bookID uint) error {
+	var errs validation.Errors
+	errs.Add("isbn13", "is already used by book %d", bookID)
+	return errs
 }
 
-func DeleteBook(ID int64) Book {
-	var book Book
-	db.Where("ID=?", ID).Delete(book)
-	return book
+func skuTaken(bookID uint) error {
+	var errs validation.Errors
+	errs.Add("sku", "is already used by book %d", bookID)
+	return errs
+}
+
+// updateColumns are the client-writable columns written by Update.
+func (b *Book) updateColumns() map[string]interface{} {
+	return map[string]interface{}{
+		"name":         b.Name,
+		"author":       b.Author,
+		"publication":  b.Publication,
+		"publisher_id": b.PublisherID,
+		"sku":          b.SKU,
+		"isbn13":       b.ISBN13,
+		"isbn10":       b.ISBN10,
+		"price_cents":  b.PriceCents,
+	}
+}
+
+// BookRepository is implemented once per storage engine so the controllers
+// never need to know which one is in use.
+type BookRepository interface {
+	Create(b *Book) error
+	List(q BookQuery) (BookPage, error)
+	Get(id int64) (*Book, error)
+	// GetByISBN finds a live book by its normalized ISBN-13.
+	GetByISBN(isbn13 string) (*Book, error)
+	// CheckUnique normalizes the ISBNs of b as Create does and returns the
+	// error Create would give for a SKU or ISBN another book already uses,
+	// without writing anything.
+	CheckUnique(b *Book) error
+	// Update and Delete only succeed while b.Version matches the stored row,
+	// returning ErrConflict otherwise. Update increments b.Version.
+	Update(b *Book) error
+	Delete(b *Book) error
+
+	// GetDeleted, Restore and Purge work on the trash. Purge removes a live
+	// or trashed book permanently, subject to the same version check as Delete.
+	GetDeleted(id int64) (*Book, error)
+	Restore(id int64) (*Book, error)
+	Purge(b *Book) error
+	// PurgeDeletedBefore permanently removes books trashed before t.
+	PurgeDeletedBefore(t time.Time) (int64, error)
+
+	Search(query string, limit int) ([]SearchResult, error)
+
+	// WithAudit returns a view of the repository that records info as the
+	// actor of every change in the audit log. Changes made without it are
+	// recorded as AuditSystem.
+	WithAudit(info AuditInfo) BookRepository
 }
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/yoloxsta/go-bookstore/pkg/validation"
+)
+
+func TestBookValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		book   Book
+		fields []string
+	}{
+		{"valid", Book{Name: "Dune", Author: "Herbert", SKU: strPtr("DUNE_1.b-2"), ISBN13: strPtr("978-0-306-40615-7"), ISBN10: strPtr("0306406152")}, nil},
+		{"linked authors need no credit", Book{Name: "Dune", AuthorIDs: []uint{1, 2}}, nil},
+		{"empty", Book{}, []string{"name", "author"}},
+		{"blank", Book{Name: "  ", Author: "\t"}, []string{"name", "author"}},
+		{"too long", Book{Name: strings.Repeat("n", MaxNameLength+1), Author: strings.Repeat("a", MaxAuthorLength+1),
+			Publication: strings.Repeat("p", MaxPublicationLength+1)}, []string{"name", "author", "publication"}},
+		{"at the limit", Book{Name: strings.Repeat("é", MaxNameLength), Author: "A"}, nil},
+		{"empty SKU", Book{Name: "Dune", Author: "A", SKU: strPtr("")}, []string{"sku"}},
+		{"bad SKU", Book{Name: "Dune", Author: "A", SKU: strPtr("-dune 1")}, []string{"sku"}},
+		{"long SKU", Book{Name: "Dune", Author: "A", SKU: strPtr(strings.Repeat("s", 65))}, []string{"sku"}},
+		{"negative price", Book{Name: "Dune", Author: "A", PriceCents: -1}, []string{"price_cents"}},
+		{"bad ISBN-13", Book{Name: "Dune", Author: "A", ISBN13: strPtr("9780306406158")}, []string{"isbn13"}},
+		{"ISBN-10 in isbn13", Book{Name: "Dune", Author: "A", ISBN13: strPtr("0306406152")}, []string{"isbn13"}},
+		{"ISBN-13 in isbn10", Book{Name: "Dune", Author: "A", ISBN10: strPtr("9780306406157")}, []string{"isbn10"}},
+		{"ISBNs disagree", Book{Name: "Dune", Author: "A", ISBN13: strPtr("9780306406157"), ISBN10: strPtr("080442957X")}, []string{"isbn10"}},
+		{"author listed twice", Book{Name: "Dune", AuthorIDs: []uint{1, 2, 1}}, []string{"author_ids"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.book.Validate()
+			var errs validation.Errors
+			if err != nil && !errors.As(err, &errs) {
+				t.Fatalf("Validate = %v, want validation.Errors", err)
+			}
+			var got []string
+			for _, fe := range errs {
+				got = append(got, fe.Field)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.fields, ",") {
+				t.Fatalf("Validate flagged %v, want %v: %v", got, tt.fields, err)
+			}
+		})
+	}
+}
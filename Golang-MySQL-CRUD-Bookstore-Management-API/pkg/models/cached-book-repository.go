@@ -0,0 +1,243 @@
+package models
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/yoloxsta/go-bookstore/pkg/cache"
+)
+
+// bookCache holds the cached books shared by the repositories that change
+// them. A book fetched by ID is cached under its ID and dropped by every
+// write to it. ISBN lookups and listings are cached under a generation that
+// every write replaces, since any write may change them. Cache failures are
+// logged and fall through to the database, and a read racing a write may
+// still cache the old book until ttl runs out.
+type bookCache struct {
+	cache cache.Cache
+	ttl   time.Duration
+}
+
+// UseCache reads books through c, keeping entries for up to ttl. It wraps
+// every repository that changes books, so it must be called before the
+// repositories are handed out.
+func (s *Store) UseCache(c cache.Cache, ttl time.Duration) {
+	bc := &bookCache{cache: c, ttl: ttl}
+	s.Books = &cachedBookRepository{BookRepository: s.Books, bookCache: bc}
+	s.Covers = &cachedCoverRepository{CoverRepository: s.Covers, bookCache: bc}
+	s.Reviews = &cachedReviewRepository{ReviewRepository: s.Reviews, bookCache: bc}
+}
+
+// cachedBookRepository serves Get, GetByISBN and List, unless the query says
+// NoCache, from the cache.
+type cachedBookRepository struct {
+	BookRepository
+	*bookCache
+}
+
+// cachedCoverRepository drops cached books whose cover, and so version,
+// changes.
+type cachedCoverRepository struct {
+	CoverRepository
+	*bookCache
+}
+
+func (r *cachedCoverRepository) Put(b *Book, c *Cover) (*Cover, error) {
+	previous, err := r.CoverRepository.Put(b, c)
+	r.invalidate(b.ID)
+	return previous, err
+}
+
+func (r *cachedCoverRepository) Delete(b *Book) (*Cover, error) {
+	previous, err := r.CoverRepository.Delete(b)
+	r.invalidate(b.ID)
+	return previous, err
+}
+
+// cachedReviewRepository drops cached books whose rating may have changed.
+type cachedReviewRepository struct {
+	ReviewRepository
+	*bookCache
+}
+
+func (r *cachedReviewRepository) Create(rv *Review) error {
+	err := r.ReviewRepository.Create(rv)
+	if err == nil && rv.Status == ReviewApproved {
+		r.invalidate(rv.BookID)
+	}
+	return err
+}
+
+func (r *cachedReviewRepository) Moderate(bookID, id int64, status string) (*Review, error) {
+	rv, err := r.ReviewRepository.Moderate(bookID, id, status)
+	r.invalidate(uint(bookID))
+	return rv, err
+}
+
+const bookGenerationKey = "books:gen"
+
+func bookKey(id int64) string { return "book:" + strconv.FormatInt(id, 10) }
+
+func (r *cachedBookRepository) Get(id int64) (*Book, error) {
+	b := &Book{}
+	err := r.readThrough("book", bookKey(id), b, func() (interface{}, error) {
+		return r.BookRepository.Get(id)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func (r *cachedBookRepository) GetByISBN(isbn13 string) (*Book, error) {
+	b := &Book{}
+	err := r.readThrough("book_isbn", r.generational("isbn:"+isbn13), b, func() (interface{}, error) {
+		return r.BookRepository.GetByISBN(isbn13)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func (r *cachedBookRepository) List(q BookQuery) (BookPage, error) {
+	if q.NoCache {
+		return r.BookRepository.List(q)
+	}
+	var page BookPage
+	spec, err := json.Marshal(q)
+	if err != nil {
+		return page, err
+	}
+	sum := sha256.Sum256(spec)
+	err = r.readThrough("book_list", r.generational("list:"+hex.EncodeToString(sum[:])), &page, func() (interface{}, error) {
+		return r.BookRepository.List(q)
+	})
+	return page, err
+}
+
+// readThrough decodes the value cached under key into dst, or stores and
+// decodes the one load returns. Errors from load are returned uncached. An
+// empty key skips the cache.
+func (r *bookCache) readThrough(kind, key string, dst interface{}, load func() (interface{}, error)) error {
+	if key != "" {
+		data, ok, err := r.cache.Get(key)
+		if err != nil {
+			r.failed(err)
+		}
+		cache.Record(kind, ok)
+		if ok && json.Unmarshal(data, dst) == nil {
+			return nil
+		}
+	}
+	v, err := load()
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if key != "" {
+		if err := r.cache.Set(key, data, r.ttl); err != nil {
+			r.failed(err)
+		}
+	}
+	return json.Unmarshal(data, dst)
+}
+
+// generational prefixes key with the current generation, starting one when
+// there is none. It returns "" when the cache cannot be read.
+func (r *bookCache) generational(key string) string {
+	gen, ok, err := r.cache.Get(bookGenerationKey)
+	if err != nil {
+		r.failed(err)
+		return ""
+	}
+	if !ok {
+		if gen, err = r.newGeneration(); err != nil {
+			r.failed(err)
+			return ""
+		}
+	}
+	return "books:" + string(gen) + ":" + key
+}
+
+func (r *bookCache) newGeneration() ([]byte, error) {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return nil, err
+	}
+	gen := []byte(hex.EncodeToString(b[:]))
+	return gen, r.cache.Set(bookGenerationKey, gen, 0)
+}
+
+// invalidate drops the cached books with ids and starts a new generation.
+func (r *bookCache) invalidate(ids ...uint) {
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = bookKey(int64(id))
+	}
+	if err := r.cache.Delete(keys...); err != nil {
+		r.failed(err)
+	}
+	if _, err := r.newGeneration(); err != nil {
+		r.failed(err)
+	}
+}
+
+func (r *bookCache) failed(err error) {
+	cache.RecordError()
+	slog.Warn("cache call failed", "err", err)
+}
+
+func (r *cachedBookRepository) Create(b *Book) error {
+	if err := r.BookRepository.Create(b); err != nil {
+		return err
+	}
+	r.invalidate()
+	return nil
+}
+
+// Update, Delete, Restore and Purge invalidate even when they fail, since a
+// conflict means the cached copy was out of date.
+func (r *cachedBookRepository) Update(b *Book) error {
+	err := r.BookRepository.Update(b)
+	r.invalidate(b.ID)
+	return err
+}
+
+func (r *cachedBookRepository) Delete(b *Book) error {
+	err := r.BookRepository.Delete(b)
+	r.invalidate(b.ID)
+	return err
+}
+
+func (r *cachedBookRepository) Restore(id int64) (*Book, error) {
+	b, err := r.BookRepository.Restore(id)
+	r.invalidate(uint(id))
+	return b, err
+}
+
+func (r *cachedBookRepository) Purge(b *Book) error {
+	err := r.BookRepository.Purge(b)
+	r.invalidate(b.ID)
+	return err
+}
+
+func (r *cachedBookRepository) PurgeDeletedBefore(t time.Time) (int64, error) {
+	n, err := r.BookRepository.PurgeDeletedBefore(t)
+	if n > 0 {
+		r.invalidate()
+	}
+	return n, err
+}
+
+func (r *cachedBookRepository) WithAudit(info AuditInfo) BookRepository {
+	return &cachedBookRepository{BookRepository: r.BookRepository.WithAudit(info), bookCache: r.bookCache}
+}
@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// Cover describes a book's uploaded cover image. The blobs themselves live in
+// pkg/storage under keys derived from BookID and Digest, so every upload gets
+// fresh keys and cached copies of an older cover never go stale.
+type Cover struct {
+	BookID uint `json:"-" gorm:"primary_key;auto_increment:false"`
+	// Digest is the hex SHA-256 of the original upload.
+	Digest      string    `json:"-" gorm:"not null"`
+	ContentType string    `json:"content_type" gorm:"not null"`
+	Size        int64     `json:"size" gorm:"not null"`
+	Width       int       `json:"width" gorm:"not null"`
+	Height      int       `json:"height" gorm:"not null"`
+	UpdatedAt   time.Time `json:"updated_at"`
+
+	// URL and Thumbnails are filled in by the controllers.
+	URL        string            `json:"url" gorm:"-"`
+	Thumbnails map[string]string `json:"thumbnails" gorm:"-"`
+}
+
+func (Cover) TableName() string { return "book_covers" }
+
+// CoverRepository stores cover metadata. Put and Delete change the book, so
+// they are subject to the same version check as BookRepository.Update and
+// increment b.Version.
+type CoverRepository interface {
+	// Get returns the cover of a live book, or ErrNotFound when it has none.
+	Get(bookID int64) (*Cover, error)
+	// Put sets the cover of b and returns the one it replaced, if any.
+	Put(b *Book, c *Cover) (*Cover, error)
+	// Delete removes the cover of b and returns it.
+	Delete(b *Book) (*Cover, error)
+
+	// GetAny returns the cover of a live or trashed book.
+	GetAny(bookID int64) (*Cover, error)
+	// ListTrashedBefore returns the covers of the books trashed before t,
+	// which BookRepository.PurgeDeletedBefore(t) removes.
+	ListTrashedBefore(t time.Time) ([]Cover, error)
+}
@@ -0,0 +1,48 @@
+package models
+
+import "github.com/jinzhu/gorm"
+
+type gormAuditRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormAuditRepository) History(bookID int64, q AuditQuery) ([]BookAudit, int64, error) {
+	q = q.normalize()
+	scope := r.db.Model(&BookAudit{}).Where("book_id = ?", bookID)
+	var total int64
+	if err := scope.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	entries := []BookAudit{}
+	err := scope.Order("id DESC").Limit(q.Limit).Offset(q.Offset).Find(&entries).Error
+	return entries, total, err
+}
+
+func (r *gormAuditRepository) Revision(bookID int64, revision uint) (*BookAudit, error) {
+	var e BookAudit
+	err := r.db.Where("book_id = ? AND revision = ? AND action IN (?)", bookID, revision, snapshotActions).
+		Order("id DESC").First(&e).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+func (r *gormAuditRepository) Since(afterID uint, bookID int64, limit int) ([]BookAudit, error) {
+	scope := r.db.Where("id > ?", afterID)
+	if bookID != 0 {
+		scope = scope.Where("book_id = ?", bookID)
+	}
+	entries := []BookAudit{}
+	err := scope.Order("id").Limit(limit).Find(&entries).Error
+	return entries, err
+}
+
+func (r *gormAuditRepository) LastID() (uint, error) {
+	var last struct{ ID uint }
+	err := r.db.Model(&BookAudit{}).Select("COALESCE(MAX(id), 0) AS id").Scan(&last).Error
+	return last.ID, err
+}
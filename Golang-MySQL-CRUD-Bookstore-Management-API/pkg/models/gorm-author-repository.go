@@ -0,0 +1,92 @@
+package models
+
+import (
+	"github.com/jinzhu/gorm"
+)
+
+type gormAuthorRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormAuthorRepository) Create(a *Author) error {
+	a.NameKey = NameKey(a.Name)
+	return r.db.Create(a).Error
+}
+
+func (r *gormAuthorRepository) List(q NameQuery) ([]Author, int64, error) {
+	q = q.normalize()
+	scope := r.db.Model(&Author{})
+	if q.Name != "" {
+		scope = scope.Where("LOWER(name) LIKE ? ESCAPE '!'", likePattern(q.Name))
+	}
+	var total int64
+	if err := scope.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	authors := []Author{}
+	if err := scope.Order("id").Limit(q.Limit).Offset(q.Offset).Find(&authors).Error; err != nil {
+		return nil, 0, err
+	}
+	return authors, total, nil
+}
+
+func (r *gormAuthorRepository) Get(id int64) (*Author, error) {
+	var a Author
+	err := r.db.Where("id = ?", id).First(&a).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (r *gormAuthorRepository) GetMany(ids []uint) ([]Author, error) {
+	authors := []Author{}
+	if len(ids) == 0 {
+		return authors, nil
+	}
+	err := r.db.Where("id IN (?)", ids).Find(&authors).Error
+	return authors, err
+}
+
+func (r *gormAuthorRepository) Update(a *Author) error {
+	res := r.db.Model(&Author{}).Where("id = ?", a.ID).UpdateColumns(map[string]interface{}{
+		"name":       a.Name,
+		"name_key":   NameKey(a.Name),
+		"updated_at": gorm.NowFunc(),
+	})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	stored, err := r.Get(int64(a.ID))
+	if err != nil {
+		return err
+	}
+	*a = *stored
+	return nil
+}
+
+func (r *gormAuthorRepository) Delete(id int64) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var n int
+		if err := tx.Model(&bookAuthor{}).Where("author_id = ?", id).Count(&n).Error; err != nil {
+			return err
+		}
+		if n > 0 {
+			return ErrInUse
+		}
+		res := tx.Where("id = ?", id).Delete(&Author{})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrNotFound
+		}
+		return nil
+	})
+}
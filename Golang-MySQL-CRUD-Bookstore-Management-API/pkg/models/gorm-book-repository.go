@@ -0,0 +1,684 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/yoloxsta/go-bookstore/pkg/search"
+	"github.com/yoloxsta/go-bookstore/pkg/validation"
+)
+
+// gormBookRepository serves every SQL engine gorm has a dialect for; MySQL
+// and SQLite differ only in how config.Connect opened the *gorm.DB.
+type gormBookRepository struct {
+	db *gorm.DB
+	// index backs Search on engines without MySQL's FULLTEXT support.
+	index *search.Index
+	audit AuditInfo
+}
+
+// bookAuthor is a row of the book_authors join table.
+type bookAuthor struct {
+	BookID   uint `gorm:"primary_key;auto_increment:false"`
+	AuthorID uint `gorm:"primary_key;auto_increment:false"`
+	Position int
+}
+
+func (bookAuthor) TableName() string { return "book_authors" }
+
+func newGormBookRepository(db *gorm.DB) (*gormBookRepository, error) {
+	r := &gormBookRepository{db: db}
+	if db.Dialect().GetName() == "mysql" {
+		return r, nil
+	}
+	r.index = newBookIndex()
+	rows, err := db.Model(&Book{}).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var b Book
+		if err := db.ScanRows(rows, &b); err != nil {
+			return nil, err
+		}
+		indexBook(r.index, b)
+	}
+	return r, rows.Err()
+}
+
+func (r *gormBookRepository) Create(b *Book) error {
+	b.Version = 1
+	b.RatingAverage, b.RatingCount = 0, 0
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := b.normalizeISBN(); err != nil {
+			return err
+		}
+		if err := checkUnique(tx, b); err != nil {
+			return err
+		}
+		if err := resolveCredits(tx, b); err != nil {
+			return err
+		}
+		if err := tx.Create(b).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&StockLevel{BookID: b.ID}).Error; err != nil {
+			return err
+		}
+		if err := setBookAuthors(tx, b.ID, b.AuthorIDs); err != nil {
+			return err
+		}
+		return r.record(tx, AuditCreate, nil, b)
+	})
+	if err != nil {
+		return err
+	}
+	if r.index != nil {
+		indexBook(r.index, *b)
+	}
+	return nil
+}
+
+// checkUnique rejects a SKU or ISBN already used by another book, live or
+// trashed.
+func checkUnique(tx *gorm.DB, b *Book) error {
+	if b.SKU != nil {
+		id, err := takenBy(tx, "sku", *b.SKU, b.ID)
+		if err != nil {
+			return err
+		}
+		if id != 0 {
+			return skuTaken(id)
+		}
+	}
+	if b.ISBN13 != nil {
+		id, err := takenBy(tx, "isbn13", *b.ISBN13, b.ID)
+		if err != nil {
+			return err
+		}
+		if id != 0 {
+			return isbnTaken(id)
+		}
+	}
+	return nil
+}
+
+func (r *gormBookRepository) CheckUnique(b *Book) error {
+	if err := b.normalizeISBN(); err != nil {
+		return err
+	}
+	return checkUnique(r.db, b)
+}
+
+// takenBy returns the ID of another book whose column holds value, or 0.
+func takenBy(tx *gorm.DB, column, value string, bookID uint) (uint, error) {
+	var other Book
+	err := tx.Unscoped().Select("id").Where(column+" = ? AND id <> ?", value, bookID).First(&other).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return 0, nil
+	}
+	return other.ID, err
+}
+
+// resolveCredits checks the author and publisher links of b, or derives them
+// from the free-text credits, creating authors and publishers that do not
+// exist yet.
+func resolveCredits(tx *gorm.DB, b *Book) error {
+	var errs validation.Errors
+	if len(b.AuthorIDs) > 0 {
+		var authors []Author
+		if err := tx.Where("id IN (?)", b.AuthorIDs).Find(&authors).Error; err != nil {
+			return err
+		}
+		byID := make(map[uint]string, len(authors))
+		for _, a := range authors {
+			byID[a.ID] = a.Name
+		}
+		var names []string
+		for _, id := range b.AuthorIDs {
+			name, ok := byID[id]
+			if !ok {
+				errs.Add("author_ids", "author %d does not exist", id)
+			}
+			names = append(names, name)
+		}
+		if b.Author == "" {
+			b.Author = JoinCredit(names)
+		}
+	} else {
+		for _, name := range SplitCredit(b.Author) {
+			a := Author{Name: name, NameKey: NameKey(name)}
+			if err := tx.Where(Author{NameKey: a.NameKey}).Order("id").FirstOrCreate(&a).Error; err != nil {
+				return err
+			}
+			b.AuthorIDs = append(b.AuthorIDs, a.ID)
+		}
+	}
+	if b.PublisherID != nil {
+		var p Publisher
+		err := tx.Where("id = ?", *b.PublisherID).First(&p).Error
+		switch {
+		case gorm.IsRecordNotFoundError(err):
+			errs.Add("publisher_id", "publisher %d does not exist", *b.PublisherID)
+		case err != nil:
+			return err
+		case b.Publication == "":
+			b.Publication = p.Name
+		}
+	} else if name := strings.Join(strings.Fields(b.Publication), " "); name != "" {
+		p := Publisher{Name: name, NameKey: NameKey(name)}
+		if err := tx.Where(Publisher{NameKey: p.NameKey}).Order("id").FirstOrCreate(&p).Error; err != nil {
+			return err
+		}
+		b.PublisherID = &p.ID
+	}
+	return errs.Err()
+}
+
+// deleteBookRows removes the rows other tables keep per book, for the books
+// selected by where.
+func deleteBookRows(tx *gorm.DB, where string, args ...interface{}) error {
+	for _, table := range []interface{}{&bookAuthor{}, &StockLevel{}, &StockMovement{}, &Cover{}, &Review{}} {
+		if err := tx.Where(where, args...).Delete(table).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func setBookAuthors(tx *gorm.DB, bookID uint, authorIDs []uint) error {
+	if err := tx.Where("book_id = ?", bookID).Delete(&bookAuthor{}).Error; err != nil {
+		return err
+	}
+	for i, id := range authorIDs {
+		if err := tx.Create(&bookAuthor{BookID: bookID, AuthorID: id, Position: i}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadAuthorIDs fills AuthorIDs for books with a single query.
+func loadAuthorIDs(db *gorm.DB, books []Book) error {
+	if len(books) == 0 {
+		return nil
+	}
+	ids := make([]uint, len(books))
+	for i, b := range books {
+		ids[i] = b.ID
+	}
+	var links []bookAuthor
+	if err := db.Where("book_id IN (?)", ids).Order("book_id, position").Find(&links).Error; err != nil {
+		return err
+	}
+	byBook := make(map[uint][]uint, len(books))
+	for _, l := range links {
+		byBook[l.BookID] = append(byBook[l.BookID], l.AuthorID)
+	}
+	for i := range books {
+		books[i].AuthorIDs = append([]uint{}, byBook[books[i].ID]...)
+	}
+	return nil
+}
+
+// loadBook reads one book and its author links inside tx.
+func loadBook(tx *gorm.DB, id uint, unscoped bool) (*Book, error) {
+	scope := tx
+	if unscoped {
+		scope = tx.Unscoped()
+	}
+	var b Book
+	err := scope.Where("id = ?", id).First(&b).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	books := []Book{b}
+	if err := loadAuthorIDs(tx, books); err != nil {
+		return nil, err
+	}
+	return &books[0], nil
+}
+
+func (r *gormBookRepository) WithAudit(info AuditInfo) BookRepository {
+	cp := *r
+	cp.audit = info
+	return &cp
+}
+
+// record appends an audit entry for a change made in tx.
+func (r *gormBookRepository) record(tx *gorm.DB, action string, before, after *Book) error {
+	e, err := newAudit(r.audit, action, before, after)
+	if err != nil {
+		return err
+	}
+	return tx.Create(&e).Error
+}
+
+func (r *gormBookRepository) loadOne(b *Book) error {
+	books := []Book{*b}
+	if err := loadAuthorIDs(r.db, books); err != nil {
+		return err
+	}
+	*b = books[0]
+	return nil
+}
+
+func (r *gormBookRepository) List(q BookQuery) (BookPage, error) {
+	q, err := q.normalize()
+	if err != nil {
+		return BookPage{}, err
+	}
+	scope := r.db.Model(&Book{})
+	if q.Deleted {
+		scope = r.db.Unscoped().Model(&Book{}).Where("deleted_at IS NOT NULL")
+	}
+	for col, v := range map[string]string{"name": q.Name, "author": q.Author, "publication": q.Publication} {
+		if v != "" {
+			scope = scope.Where("LOWER("+col+") LIKE ? ESCAPE '!'", likePattern(v))
+		}
+	}
+	if q.AuthorID != 0 {
+		scope = scope.Where("id IN (SELECT book_id FROM book_authors WHERE author_id = ?)", q.AuthorID)
+	}
+	if q.PublisherID != 0 {
+		scope = scope.Where("publisher_id = ?", q.PublisherID)
+	}
+
+	var page BookPage
+	if !q.SkipTotal {
+		if err := scope.Count(&page.Total).Error; err != nil {
+			return BookPage{}, err
+		}
+	}
+
+	if q.Cursor != "" {
+		after, err := decodeCursor(q)
+		if err != nil {
+			return BookPage{}, err
+		}
+		scope = keysetWhere(scope, q, after)
+	}
+	for _, f := range q.Sort {
+		dir := " ASC"
+		if f.Desc {
+			dir = " DESC"
+		}
+		scope = scope.Order(sortExpr(r.db, f.Field) + dir)
+	}
+	if q.Offset > 0 {
+		scope = scope.Offset(q.Offset)
+	}
+
+	var Books []Book
+	if err := scope.Limit(q.Limit + 1).Find(&Books).Error; err != nil {
+		return BookPage{}, err
+	}
+	if len(Books) > q.Limit {
+		Books = Books[:q.Limit]
+		page.NextCursor = encodeCursor(q, Books[len(Books)-1])
+	}
+	if err := loadAuthorIDs(r.db, Books); err != nil {
+		return BookPage{}, err
+	}
+	page.Books = Books
+	return page, nil
+}
+
+// keysetWhere restricts scope to rows strictly after the cursor position:
+// (a > ?) OR (a = ? AND b > ?) OR ..., flipping > for descending fields.
+func keysetWhere(scope *gorm.DB, q BookQuery, after []interface{}) *gorm.DB {
+	var (
+		ors  []string
+		args []interface{}
+	)
+	for i, f := range q.Sort {
+		var ands []string
+		for j := 0; j < i; j++ {
+			ands = append(ands, sortExpr(scope, q.Sort[j].Field)+" = ?")
+			args = append(args, after[j])
+		}
+		op := " > ?"
+		if f.Desc {
+			op = " < ?"
+		}
+		ands = append(ands, sortExpr(scope, f.Field)+op)
+		args = append(args, after[i])
+		ors = append(ors, "("+strings.Join(ands, " AND ")+")")
+	}
+	return scope.Where(strings.Join(ors, " OR "), args...)
+}
+
+// sortExpr is what a sort field orders and compares by. SQLite compares text
+// case-sensitively, unlike MySQL's default collation and the memory store, so
+// text columns get NOCASE there.
+func sortExpr(db *gorm.DB, field string) string {
+	col := sortColumns[field]
+	if _, text := sortValue(Book{}, field).(string); text && db.Dialect().GetName() == "sqlite3" {
+		return col + " COLLATE NOCASE"
+	}
+	return col
+}
+
+func (r *gormBookRepository) Get(id int64) (*Book, error) {
+	var getBook Book
+	err := r.db.Where("ID=?", id).First(&getBook).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &getBook, r.loadOne(&getBook)
+}
+
+func (r *gormBookRepository) GetByISBN(isbn13 string) (*Book, error) {
+	var b Book
+	err := r.db.Where("isbn13 = ?", isbn13).First(&b).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &b, r.loadOne(&b)
+}
+
+func (r *gormBookRepository) Update(b *Book) error {
+	now := gorm.NowFunc()
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := b.normalizeISBN(); err != nil {
+			return err
+		}
+		before, err := loadBook(tx, b.ID, false)
+		if err != nil {
+			return err
+		}
+		if err := checkUnique(tx, b); err != nil {
+			return err
+		}
+		if err := resolveCredits(tx, b); err != nil {
+			return err
+		}
+		cols := b.updateColumns()
+		cols["version"] = b.Version + 1
+		cols["updated_at"] = now
+		res := tx.Model(&Book{}).Where("id = ? AND version = ?", b.ID, b.Version).UpdateColumns(cols)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return missing(tx, b.ID)
+		}
+		if err := setBookAuthors(tx, b.ID, b.AuthorIDs); err != nil {
+			return err
+		}
+		after, err := loadBook(tx, b.ID, false)
+		if err != nil {
+			return err
+		}
+		b.RatingAverage, b.RatingCount = after.RatingAverage, after.RatingCount
+		return r.record(tx, AuditUpdate, before, after)
+	})
+	if err != nil {
+		return err
+	}
+	b.Version++
+	b.UpdatedAt = now
+	if r.index != nil {
+		indexBook(r.index, *b)
+	}
+	return nil
+}
+
+func (r *gormBookRepository) Delete(b *Book) error {
+	now := gorm.NowFunc()
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		before, err := loadBook(tx, b.ID, false)
+		if err != nil {
+			return err
+		}
+		res := tx.Model(&Book{}).Where("id = ? AND version = ?", b.ID, b.Version).UpdateColumn("deleted_at", now)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return missing(tx, b.ID)
+		}
+		after := *before
+		after.DeletedAt = &now
+		return r.record(tx, AuditDelete, before, &after)
+	})
+	if err != nil {
+		return err
+	}
+	b.DeletedAt = &now
+	if r.index != nil {
+		r.index.Delete(b.ID)
+	}
+	return nil
+}
+
+func (r *gormBookRepository) GetDeleted(id int64) (*Book, error) {
+	var b Book
+	err := r.db.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", id).First(&b).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &b, r.loadOne(&b)
+}
+
+func (r *gormBookRepository) Restore(id int64) (*Book, error) {
+	var b *Book
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		before, err := loadBook(tx, uint(id), true)
+		if err != nil {
+			return err
+		}
+		if before.DeletedAt == nil {
+			return ErrNotFound
+		}
+		res := tx.Unscoped().Model(&Book{}).Where("id = ? AND deleted_at IS NOT NULL", id).UpdateColumns(map[string]interface{}{
+			"deleted_at": nil,
+			"updated_at": gorm.NowFunc(),
+			"version":    gorm.Expr("version + 1"),
+		})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrNotFound
+		}
+		if b, err = loadBook(tx, uint(id), false); err != nil {
+			return err
+		}
+		return r.record(tx, AuditRestore, before, b)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if r.index != nil {
+		indexBook(r.index, *b)
+	}
+	return b, nil
+}
+
+func (r *gormBookRepository) Purge(b *Book) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		before, err := loadBook(tx, b.ID, true)
+		if err != nil {
+			return err
+		}
+		res := tx.Unscoped().Where("id = ? AND version = ?", b.ID, b.Version).Delete(&Book{})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			var n int
+			if err := tx.Unscoped().Model(&Book{}).Where("id = ?", b.ID).Count(&n).Error; err != nil {
+				return err
+			}
+			if n == 0 {
+				return ErrNotFound
+			}
+			return ErrConflict
+		}
+		if err := r.record(tx, AuditPurge, before, nil); err != nil {
+			return err
+		}
+		return deleteBookRows(tx, "book_id = ?", b.ID)
+	})
+	if err != nil {
+		return err
+	}
+	if r.index != nil {
+		r.index.Delete(b.ID)
+	}
+	return nil
+}
+
+func (r *gormBookRepository) PurgeDeletedBefore(t time.Time) (int64, error) {
+	var n int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var expired []Book
+		if err := tx.Unscoped().Where("deleted_at IS NOT NULL AND deleted_at < ?", t).Find(&expired).Error; err != nil {
+			return err
+		}
+		if err := loadAuthorIDs(tx, expired); err != nil {
+			return err
+		}
+		for i := range expired {
+			if err := r.record(tx, AuditPurge, &expired[i], nil); err != nil {
+				return err
+			}
+		}
+		err := deleteBookRows(tx, "book_id IN (SELECT id FROM books WHERE deleted_at IS NOT NULL AND deleted_at < ?)", t)
+		if err != nil {
+			return err
+		}
+		res := tx.Unscoped().Where("deleted_at IS NOT NULL AND deleted_at < ?", t).Delete(&Book{})
+		n = res.RowsAffected
+		return res.Error
+	})
+	return n, err
+}
+
+// missing explains why a versioned write matched no rows. It must run on the
+// write's transaction: SQLite has a single connection, so querying outside
+// it would wait forever.
+func missing(tx *gorm.DB, id uint) error {
+	var n int
+	if err := tx.Model(&Book{}).Where("id = ?", id).Count(&n).Error; err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return ErrConflict
+}
+
+func (r *gormBookRepository) Search(query string, limit int) ([]SearchResult, error) {
+	terms := search.Tokenize(query)
+	if len(terms) == 0 {
+		return []SearchResult{}, nil
+	}
+	if r.index != nil {
+		return r.searchIndex(query, limit)
+	}
+
+	const match = "MATCH(name, author, publication) AGAINST (? IN BOOLEAN MODE)"
+	expr := booleanQuery(terms)
+	var rows []struct {
+		Book
+		Score float64
+	}
+	err := r.db.Table("books").
+		Select("books.*, "+match+" AS score", expr).
+		Where("deleted_at IS NULL").
+		Where(match, expr).
+		Order("score DESC").
+		Limit(limit).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return r.searchScan(query, limit)
+	}
+	found := make([]Book, len(rows))
+	for i, row := range rows {
+		found[i] = row.Book
+	}
+	if err := loadAuthorIDs(r.db, found); err != nil {
+		return nil, err
+	}
+	results := make([]SearchResult, len(rows))
+	for i, row := range rows {
+		row.Book = found[i]
+		results[i] = SearchResult{Book: row.Book, Score: row.Score, Highlights: highlightBook(row.Book, terms)}
+	}
+	return results, nil
+}
+
+// searchScan is the typo-tolerant fallback for a FULLTEXT query that found
+// nothing: it ranks the live books through a throwaway in-process index. It
+// reads every live book's searchable columns, so it only runs on a miss.
+func (r *gormBookRepository) searchScan(query string, limit int) ([]SearchResult, error) {
+	rows, err := r.db.Model(&Book{}).Select("id, name, author, publication").Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	ix := newBookIndex()
+	for rows.Next() {
+		var b Book
+		if err := rows.Scan(&b.ID, &b.Name, &b.Author, &b.Publication); err != nil {
+			return nil, err
+		}
+		indexBook(ix, b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return r.searchIn(ix, query, limit)
+}
+
+func (r *gormBookRepository) searchIndex(query string, limit int) ([]SearchResult, error) {
+	return r.searchIn(r.index, query, limit)
+}
+
+func (r *gormBookRepository) searchIn(ix *search.Index, query string, limit int) ([]SearchResult, error) {
+	hits := ix.Search(query, limit)
+	if len(hits) == 0 {
+		return []SearchResult{}, nil
+	}
+	ids := make([]uint, len(hits))
+	for i, h := range hits {
+		ids[i] = h.ID
+	}
+	var Books []Book
+	if err := r.db.Where("id IN (?)", ids).Find(&Books).Error; err != nil {
+		return nil, err
+	}
+	if err := loadAuthorIDs(r.db, Books); err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]Book, len(Books))
+	for _, b := range Books {
+		byID[b.ID] = b
+	}
+	results := make([]SearchResult, 0, len(hits))
+	for _, h := range hits {
+		if b, ok := byID[h.ID]; ok {
+			results = append(results, SearchResult{Book: b, Score: h.Score, Highlights: h.Highlights})
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,100 @@
+package models
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+type gormCoverRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormCoverRepository) Get(bookID int64) (*Cover, error) {
+	return findCover(liveBooks(r.db), bookID)
+}
+
+func (r *gormCoverRepository) GetAny(bookID int64) (*Cover, error) {
+	return findCover(r.db, bookID)
+}
+
+func (r *gormCoverRepository) ListTrashedBefore(t time.Time) ([]Cover, error) {
+	var covers []Cover
+	err := r.db.Where("book_id IN (SELECT id FROM books WHERE deleted_at IS NOT NULL AND deleted_at < ?)", t).
+		Order("book_id").Find(&covers).Error
+	return covers, err
+}
+
+func findCover(db *gorm.DB, bookID int64) (*Cover, error) {
+	var c Cover
+	err := db.Where("book_id = ?", bookID).First(&c).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (r *gormCoverRepository) Put(b *Book, c *Cover) (*Cover, error) {
+	var previous *Cover
+	now := gorm.NowFunc()
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := touchBook(tx, b, now); err != nil {
+			return err
+		}
+		old, err := findCover(tx, int64(b.ID))
+		if err != nil && err != ErrNotFound {
+			return err
+		}
+		previous = old
+		if err := tx.Where("book_id = ?", b.ID).Delete(&Cover{}).Error; err != nil {
+			return err
+		}
+		c.BookID = b.ID
+		c.UpdatedAt = now
+		return tx.Create(c).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	b.Version++
+	b.UpdatedAt = now
+	return previous, nil
+}
+
+func (r *gormCoverRepository) Delete(b *Book) (*Cover, error) {
+	var previous *Cover
+	now := gorm.NowFunc()
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		old, err := findCover(tx, int64(b.ID))
+		if err != nil {
+			return err
+		}
+		previous = old
+		if err := touchBook(tx, b, now); err != nil {
+			return err
+		}
+		return tx.Where("book_id = ?", b.ID).Delete(&Cover{}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	b.Version++
+	b.UpdatedAt = now
+	return previous, nil
+}
+
+// touchBook bumps the version of the live book b if it still matches.
+func touchBook(tx *gorm.DB, b *Book, now time.Time) error {
+	res := tx.Model(&Book{}).Where("id = ? AND version = ?", b.ID, b.Version).
+		UpdateColumns(map[string]interface{}{"version": b.Version + 1, "updated_at": now})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return missing(tx, b.ID)
+	}
+	return nil
+}
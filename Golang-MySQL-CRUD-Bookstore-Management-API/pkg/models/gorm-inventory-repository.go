@@ -0,0 +1,137 @@
+package models
+
+import (
+	"github.com/jinzhu/gorm"
+)
+
+type gormInventoryRepository struct {
+	db               *gorm.DB
+	defaultThreshold int
+}
+
+// liveBooks restricts a stock_levels query to books that are not trashed.
+func liveBooks(db *gorm.DB) *gorm.DB {
+	return db.Where("book_id IN (SELECT id FROM books WHERE deleted_at IS NULL)")
+}
+
+func (r *gormInventoryRepository) Get(bookID int64) (*StockLevel, error) {
+	var s StockLevel
+	err := liveBooks(r.db).Where("book_id = ?", bookID).First(&s).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.LowStock = s.Quantity <= s.threshold(r.defaultThreshold)
+	return &s, nil
+}
+
+func (r *gormInventoryRepository) GetMany(bookIDs []uint) ([]StockLevel, error) {
+	levels := []StockLevel{}
+	if len(bookIDs) == 0 {
+		return levels, nil
+	}
+	if err := liveBooks(r.db).Where("book_id IN (?)", bookIDs).Find(&levels).Error; err != nil {
+		return nil, err
+	}
+	for i := range levels {
+		levels[i].LowStock = levels[i].Quantity <= levels[i].threshold(r.defaultThreshold)
+	}
+	return levels, nil
+}
+
+func (r *gormInventoryRepository) SetThreshold(bookID int64, threshold *int) (*StockLevel, error) {
+	res := liveBooks(r.db.Model(&StockLevel{})).Where("book_id = ?", bookID).UpdateColumns(map[string]interface{}{
+		"low_stock_threshold": threshold,
+		"updated_at":          gorm.NowFunc(),
+	})
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, ErrNotFound
+	}
+	return r.Get(bookID)
+}
+
+func (r *gormInventoryRepository) Move(m *StockMovement) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return applyMovement(tx, m)
+	})
+}
+
+// applyMovement does the work of Move inside the caller's transaction. The
+// balance is guarded by a conditional update, so concurrent movements cannot
+// both spend the same stock. Releases also reach trashed books, so that a
+// restored book has its stock back.
+func applyMovement(tx *gorm.DB, m *StockMovement) error {
+	now := gorm.NowFunc()
+	scope := liveBooks(tx)
+	if m.Kind == MovementRelease {
+		scope = tx
+	}
+	var s StockLevel
+	err := scope.Where("book_id = ?", m.BookID).First(&s).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	m.Change = m.change()
+	res := tx.Model(&StockLevel{}).Where("book_id = ? AND quantity + ? >= 0", m.BookID, m.Change).
+		UpdateColumns(map[string]interface{}{
+			"quantity":   gorm.Expr("quantity + ?", m.Change),
+			"updated_at": now,
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return &InsufficientStockError{BookID: m.BookID}
+	}
+	if err := tx.Where("book_id = ?", m.BookID).First(&s).Error; err != nil {
+		return err
+	}
+	m.ID = 0
+	m.Balance, m.CreatedAt = s.Quantity, now
+	return tx.Create(m).Error
+}
+
+func (r *gormInventoryRepository) Movements(bookID int64, q MovementQuery) ([]StockMovement, int64, error) {
+	q = q.normalize()
+	if _, err := r.Get(bookID); err != nil {
+		return nil, 0, err
+	}
+	scope := r.db.Model(&StockMovement{}).Where("book_id = ?", bookID)
+	if q.Kind != "" {
+		scope = scope.Where("kind = ?", q.Kind)
+	}
+	var total int64
+	if err := scope.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	movements := []StockMovement{}
+	err := scope.Order("id DESC").Limit(q.Limit).Offset(q.Offset).Find(&movements).Error
+	return movements, total, err
+}
+
+func (r *gormInventoryRepository) Report() (InventoryReport, error) {
+	rep := InventoryReport{LowStock: []LowStockItem{}}
+	const from = " FROM books b JOIN stock_levels s ON s.book_id = b.id WHERE b.deleted_at IS NULL"
+	err := r.db.Raw(`SELECT COUNT(*), COALESCE(SUM(s.quantity), 0), COALESCE(SUM(s.quantity * b.price_cents), 0),
+		COALESCE(SUM(CASE WHEN s.quantity = 0 THEN 1 ELSE 0 END), 0)`+from).
+		Row().Scan(&rep.Titles, &rep.Units, &rep.ValueCents, &rep.OutOfStock)
+	if err != nil {
+		return InventoryReport{}, err
+	}
+	err = r.db.Raw(`SELECT b.id AS book_id, b.name, b.sku, s.quantity,
+		COALESCE(s.low_stock_threshold, ?) AS threshold`+from+`
+		AND s.quantity <= COALESCE(s.low_stock_threshold, ?) ORDER BY s.quantity, b.id`,
+		r.defaultThreshold, r.defaultThreshold).Scan(&rep.LowStock).Error
+	if err != nil {
+		return InventoryReport{}, err
+	}
+	return rep, nil
+}
@@ -0,0 +1,291 @@
+package models
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/yoloxsta/go-bookstore/pkg/validation"
+)
+
+type gormCartRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormCartRepository) Create(c *Cart) error {
+	*c = Cart{Customer: c.Customer}
+	if err := r.db.Create(c).Error; err != nil {
+		return err
+	}
+	c.Items = []CartItem{}
+	return nil
+}
+
+func (r *gormCartRepository) Get(id int64) (*Cart, error) {
+	var c Cart
+	err := r.db.Where("id = ?", id).First(&c).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	c.Items = []CartItem{}
+	if err := r.db.Where("cart_id = ?", c.ID).Order("book_id").Find(&c.Items).Error; err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (r *gormCartRepository) SetItem(cartID int64, item CartItem) (*Cart, error) {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var c Cart
+		err := tx.Where("id = ?", cartID).First(&c).Error
+		if gorm.IsRecordNotFoundError(err) {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if c.OrderID != nil {
+			return ErrCheckedOut
+		}
+		item.CartID = c.ID
+		if err := tx.Where("cart_id = ? AND book_id = ?", c.ID, item.BookID).Delete(&CartItem{}).Error; err != nil {
+			return err
+		}
+		if item.Quantity > 0 {
+			var n int
+			if err := tx.Model(&Book{}).Where("id = ?", item.BookID).Count(&n).Error; err != nil {
+				return err
+			}
+			if n == 0 {
+				var errs validation.Errors
+				errs.Add("book_id", "book %d does not exist", item.BookID)
+				return errs
+			}
+			if err := tx.Create(&item).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&c).UpdateColumn("updated_at", gorm.NowFunc()).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.Get(cartID)
+}
+
+func (r *gormCartRepository) Delete(id int64) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var c Cart
+		err := tx.Where("id = ?", id).First(&c).Error
+		if gorm.IsRecordNotFoundError(err) {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if c.OrderID != nil {
+			return ErrCheckedOut
+		}
+		if err := tx.Where("cart_id = ?", c.ID).Delete(&CartItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&c).Error
+	})
+}
+
+type gormOrderRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormOrderRepository) Checkout(co Checkout, currency string) (*Order, error) {
+	var id uint
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var c Cart
+		err := tx.Where("id = ?", co.CartID).First(&c).Error
+		if gorm.IsRecordNotFoundError(err) {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if c.OrderID != nil {
+			return ErrCheckedOut
+		}
+		var items []CartItem
+		if err := tx.Where("cart_id = ?", c.ID).Order("book_id").Find(&items).Error; err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			return ErrEmptyCart
+		}
+
+		o := Order{Customer: co.Customer, Status: OrderPending, Currency: currency}
+		if err := tx.Create(&o).Error; err != nil {
+			return err
+		}
+		for _, it := range items {
+			var b Book
+			err := tx.Where("id = ?", it.BookID).First(&b).Error
+			if gorm.IsRecordNotFoundError(err) {
+				return bookUnavailable(it.BookID)
+			}
+			if err != nil {
+				return err
+			}
+			m := StockMovement{BookID: b.ID, Kind: MovementReserve, Quantity: it.Quantity, OrderID: &o.ID}
+			if err := applyMovement(tx, &m); err != nil {
+				return err
+			}
+			oi := OrderItem{
+				OrderID:        o.ID,
+				BookID:         b.ID,
+				Name:           b.Name,
+				SKU:            b.SKU,
+				Quantity:       it.Quantity,
+				UnitPriceCents: b.PriceCents,
+			}
+			if err := tx.Create(&oi).Error; err != nil {
+				return err
+			}
+			o.TotalCents += int64(it.Quantity) * b.PriceCents
+		}
+		if err := tx.Model(&o).UpdateColumn("total_cents", o.TotalCents).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&OrderEvent{OrderID: o.ID, To: OrderPending}).Error; err != nil {
+			return err
+		}
+		// Claiming the cart last, conditionally, stops two concurrent
+		// checkouts of the same cart from both succeeding.
+		res := tx.Model(&Cart{}).Where("id = ? AND order_id IS NULL", c.ID).
+			UpdateColumns(map[string]interface{}{"order_id": o.ID, "updated_at": gorm.NowFunc()})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrCheckedOut
+		}
+		id = o.ID
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.Get(int64(id))
+}
+
+func (r *gormOrderRepository) Get(id int64) (*Order, error) {
+	var o Order
+	err := r.db.Where("id = ?", id).First(&o).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	orders := []Order{o}
+	if err := r.loadItems(orders); err != nil {
+		return nil, err
+	}
+	return &orders[0], nil
+}
+
+// loadItems fills the items of orders with a single query.
+func (r *gormOrderRepository) loadItems(orders []Order) error {
+	if len(orders) == 0 {
+		return nil
+	}
+	ids := make([]uint, len(orders))
+	for i, o := range orders {
+		ids[i] = o.ID
+	}
+	var items []OrderItem
+	if err := r.db.Where("order_id IN (?)", ids).Order("id").Find(&items).Error; err != nil {
+		return err
+	}
+	byOrder := make(map[uint][]OrderItem, len(orders))
+	for _, it := range items {
+		byOrder[it.OrderID] = append(byOrder[it.OrderID], it)
+	}
+	for i := range orders {
+		orders[i].Items = append([]OrderItem{}, byOrder[orders[i].ID]...)
+	}
+	return nil
+}
+
+func (r *gormOrderRepository) List(q OrderQuery) ([]Order, int64, error) {
+	q = q.normalize()
+	scope := r.db.Model(&Order{})
+	if q.Customer != "" {
+		scope = scope.Where("customer = ?", q.Customer)
+	}
+	if q.Status != "" {
+		scope = scope.Where("status = ?", q.Status)
+	}
+	var total int64
+	if err := scope.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	orders := []Order{}
+	if err := scope.Order("id DESC").Limit(q.Limit).Offset(q.Offset).Find(&orders).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := r.loadItems(orders); err != nil {
+		return nil, 0, err
+	}
+	return orders, total, nil
+}
+
+func (r *gormOrderRepository) Transition(id int64, t OrderTransition) (*Order, error) {
+	if !CanTransition(t.From, t.To) {
+		return nil, ErrConflict
+	}
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		cols := map[string]interface{}{"status": t.To, "updated_at": gorm.NowFunc()}
+		if t.PaymentID != "" {
+			cols["payment_id"] = t.PaymentID
+		}
+		res := tx.Model(&Order{}).Where("id = ? AND status = ?", id, t.From).UpdateColumns(cols)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			var n int
+			if err := tx.Model(&Order{}).Where("id = ?", id).Count(&n).Error; err != nil {
+				return err
+			}
+			if n == 0 {
+				return ErrNotFound
+			}
+			return ErrConflict
+		}
+		if releasesStock(t.From, t.To) {
+			var items []OrderItem
+			if err := tx.Where("order_id = ?", id).Find(&items).Error; err != nil {
+				return err
+			}
+			for _, it := range items {
+				orderID := uint(id)
+				m := StockMovement{BookID: it.BookID, Kind: MovementRelease, Quantity: it.Quantity, OrderID: &orderID}
+				// A purged book has no stock left to release.
+				if err := applyMovement(tx, &m); err != nil && err != ErrNotFound {
+					return err
+				}
+			}
+		}
+		return tx.Create(&OrderEvent{OrderID: uint(id), From: t.From, To: t.To, Note: t.Note}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.Get(id)
+}
+
+func (r *gormOrderRepository) Events(id int64) ([]OrderEvent, error) {
+	if _, err := r.Get(id); err != nil {
+		return nil, err
+	}
+	events := []OrderEvent{}
+	err := r.db.Where("order_id = ?", id).Order("id").Find(&events).Error
+	return events, err
+}
@@ -0,0 +1,92 @@
+package models
+
+import (
+	"github.com/jinzhu/gorm"
+)
+
+type gormPublisherRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormPublisherRepository) Create(a *Publisher) error {
+	a.NameKey = NameKey(a.Name)
+	return r.db.Create(a).Error
+}
+
+func (r *gormPublisherRepository) List(q NameQuery) ([]Publisher, int64, error) {
+	q = q.normalize()
+	scope := r.db.Model(&Publisher{})
+	if q.Name != "" {
+		scope = scope.Where("LOWER(name) LIKE ? ESCAPE '!'", likePattern(q.Name))
+	}
+	var total int64
+	if err := scope.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	publishers := []Publisher{}
+	if err := scope.Order("id").Limit(q.Limit).Offset(q.Offset).Find(&publishers).Error; err != nil {
+		return nil, 0, err
+	}
+	return publishers, total, nil
+}
+
+func (r *gormPublisherRepository) Get(id int64) (*Publisher, error) {
+	var a Publisher
+	err := r.db.Where("id = ?", id).First(&a).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (r *gormPublisherRepository) GetMany(ids []uint) ([]Publisher, error) {
+	publishers := []Publisher{}
+	if len(ids) == 0 {
+		return publishers, nil
+	}
+	err := r.db.Where("id IN (?)", ids).Find(&publishers).Error
+	return publishers, err
+}
+
+func (r *gormPublisherRepository) Update(a *Publisher) error {
+	res := r.db.Model(&Publisher{}).Where("id = ?", a.ID).UpdateColumns(map[string]interface{}{
+		"name":       a.Name,
+		"name_key":   NameKey(a.Name),
+		"updated_at": gorm.NowFunc(),
+	})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	stored, err := r.Get(int64(a.ID))
+	if err != nil {
+		return err
+	}
+	*a = *stored
+	return nil
+}
+
+func (r *gormPublisherRepository) Delete(id int64) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var n int
+		if err := tx.Unscoped().Model(&Book{}).Where("publisher_id = ?", id).Count(&n).Error; err != nil {
+			return err
+		}
+		if n > 0 {
+			return ErrInUse
+		}
+		res := tx.Where("id = ?", id).Delete(&Publisher{})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrNotFound
+		}
+		return nil
+	})
+}
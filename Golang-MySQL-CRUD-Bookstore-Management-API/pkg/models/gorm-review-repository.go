@@ -0,0 +1,221 @@
+package models
+
+import (
+	"errors"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jinzhu/gorm"
+	"github.com/mattn/go-sqlite3"
+)
+
+type gormReviewRepository struct {
+	db    *gorm.DB
+	audit AuditInfo
+}
+
+func (r *gormReviewRepository) WithAudit(info AuditInfo) ReviewRepository {
+	cp := *r
+	cp.audit = info
+	return &cp
+}
+
+func (r *gormReviewRepository) Create(rv *Review) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var n int
+		if err := tx.Model(&Book{}).Where("id = ?", rv.BookID).Count(&n).Error; err != nil {
+			return err
+		}
+		if n == 0 {
+			return ErrNotFound
+		}
+		err := tx.Model(&Review{}).Where("book_id = ? AND reviewer = ?", rv.BookID, rv.Reviewer).Count(&n).Error
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			return ErrDuplicateReview
+		}
+		rv.ID = 0
+		if err := tx.Create(rv).Error; err != nil {
+			// A concurrent review by the same reviewer got in after the
+			// check above; the unique index catches it.
+			if isUniqueViolation(err) {
+				return ErrDuplicateReview
+			}
+			return err
+		}
+		if rv.Status == ReviewApproved {
+			return refreshRating(tx, rv.BookID, ratingAudit(r.audit, rv))
+		}
+		return nil
+	})
+}
+
+func (r *gormReviewRepository) Get(bookID, id int64) (*Review, error) {
+	return findReview(r.db, bookID, id)
+}
+
+func findReview(db *gorm.DB, bookID, id int64) (*Review, error) {
+	var rv Review
+	err := liveBooks(db).Where("book_id = ? AND id = ?", bookID, id).First(&rv).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rv, nil
+}
+
+func (r *gormReviewRepository) List(q ReviewQuery) ([]Review, int64, error) {
+	q = q.normalize()
+	scope := liveBooks(r.db.Model(&Review{})).Where("book_id = ?", q.BookID)
+	if q.Status != "" {
+		scope = scope.Where("status = ?", q.Status)
+	}
+	var total int64
+	if err := scope.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	reviews := []Review{}
+	if err := scope.Order("id DESC").Limit(q.Limit).Offset(q.Offset).Find(&reviews).Error; err != nil {
+		return nil, 0, err
+	}
+	return reviews, total, nil
+}
+
+// rankedReview is a review with its position among its book's matching
+// reviews.
+type rankedReview struct {
+	Review
+	Position int
+}
+
+// ListMany counts the matching reviews of every book in one query and pages
+// through them in another, numbering each book's reviews with ROW_NUMBER,
+// which needs MySQL 8 or SQLite 3.25.
+func (r *gormReviewRepository) ListMany(bookIDs []uint, q ReviewQuery) (map[uint]ReviewPage, error) {
+	pages := map[uint]ReviewPage{}
+	if len(bookIDs) == 0 {
+		return pages, nil
+	}
+	q = q.normalize()
+	where, args := "book_id IN (SELECT id FROM books WHERE deleted_at IS NULL AND id IN (?))", []interface{}{bookIDs}
+	if q.Status != "" {
+		where += " AND status = ?"
+		args = append(args, q.Status)
+	}
+
+	var counts []struct {
+		BookID uint
+		Total  int64
+	}
+	err := r.db.Model(&Review{}).Select("book_id, COUNT(*) AS total").Where(where, args...).
+		Group("book_id").Scan(&counts).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range counts {
+		pages[c.BookID] = ReviewPage{Reviews: []Review{}, Total: c.Total}
+	}
+	if len(pages) == 0 {
+		return pages, nil
+	}
+
+	rows, err := r.db.Raw(`SELECT * FROM (
+			SELECT book_reviews.*, ROW_NUMBER() OVER (PARTITION BY book_id ORDER BY id DESC) AS position
+			FROM book_reviews WHERE `+where+`
+		) ranked WHERE position > ? AND position <= ? ORDER BY book_id, position`,
+		append(args, q.Offset, q.Offset+q.Limit)...).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var rr rankedReview
+		if err := r.db.ScanRows(rows, &rr); err != nil {
+			return nil, err
+		}
+		page := pages[rr.BookID]
+		page.Reviews = append(page.Reviews, rr.Review)
+		pages[rr.BookID] = page
+	}
+	return pages, rows.Err()
+}
+
+func (r *gormReviewRepository) Moderate(bookID, id int64, status string) (*Review, error) {
+	var rv *Review
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if rv, err = findReview(tx, bookID, id); err != nil {
+			return err
+		}
+		if rv.Status == status {
+			return nil
+		}
+		was := rv.Status
+		rv.Status, rv.UpdatedAt = status, gorm.NowFunc()
+		err = tx.Model(rv).UpdateColumns(map[string]interface{}{"status": rv.Status, "updated_at": rv.UpdatedAt}).Error
+		if err != nil {
+			return err
+		}
+		if was == ReviewApproved || status == ReviewApproved {
+			return refreshRating(tx, rv.BookID, ratingAudit(r.audit, rv))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rv, nil
+}
+
+// refreshRating recomputes the rating of a book from its approved reviews
+// and bumps its version, so cached copies and ETags change with it. Like
+// any other change to the book it is recorded in the audit log.
+func refreshRating(tx *gorm.DB, bookID uint, info AuditInfo) error {
+	before, err := loadBook(tx, bookID, false)
+	if err != nil {
+		return err
+	}
+	var agg struct {
+		Count int
+		Total int
+	}
+	err = tx.Model(&Review{}).Select("COUNT(*) AS count, COALESCE(SUM(rating), 0) AS total").
+		Where("book_id = ? AND status = ?", bookID, ReviewApproved).Scan(&agg).Error
+	if err != nil {
+		return err
+	}
+	err = tx.Model(&Book{}).Where("id = ?", bookID).UpdateColumns(map[string]interface{}{
+		"rating_average": averageRating(agg.Total, agg.Count),
+		"rating_count":   agg.Count,
+		"version":        gorm.Expr("version + 1"),
+	}).Error
+	if err != nil {
+		return err
+	}
+	after, err := loadBook(tx, bookID, false)
+	if err != nil {
+		return err
+	}
+	e, err := newAudit(info, AuditUpdate, before, after)
+	if err != nil {
+		return err
+	}
+	return tx.Create(&e).Error
+}
+
+// isUniqueViolation reports whether err is a unique index rejecting a row,
+// in either supported database.
+func isUniqueViolation(err error) bool {
+	var myErr *mysql.MySQLError
+	if errors.As(err, &myErr) {
+		return myErr.Number == 1062
+	}
+	var liteErr sqlite3.Error
+	if errors.As(err, &liteErr) {
+		return liteErr.ExtendedCode == sqlite3.ErrConstraintUnique
+	}
+	return false
+}
@@ -0,0 +1,153 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/yoloxsta/go-bookstore/pkg/validation"
+)
+
+// ErrInsufficientStock means a movement would take a book's stock below zero.
+var ErrInsufficientStock = errors.New("models: insufficient stock")
+
+// InsufficientStockError names the book that ran short; it matches
+// ErrInsufficientStock with errors.Is.
+type InsufficientStockError struct {
+	BookID uint
+}
+
+func (e *InsufficientStockError) Error() string {
+	return fmt.Sprintf("models: insufficient stock for book %d", e.BookID)
+}
+
+func (e *InsufficientStockError) Is(target error) bool { return target == ErrInsufficientStock }
+
+// StockLevel is the number of copies on hand for one book. Every book has
+// one, created with the book at zero.
+type StockLevel struct {
+	BookID   uint `json:"book_id" gorm:"primary_key;auto_increment:false"`
+	Quantity int  `json:"quantity" gorm:"not null;default:0"`
+	// LowStockThreshold overrides the configured default when set. A book is
+	// low on stock when Quantity is at or below its threshold.
+	LowStockThreshold *int `json:"low_stock_threshold"`
+	// LowStock is worked out on read against the effective threshold.
+	LowStock  bool      `json:"low_stock" gorm:"-"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// threshold is the book's own threshold or else defaultThreshold.
+func (s *StockLevel) threshold(defaultThreshold int) int {
+	if s.LowStockThreshold != nil {
+		return *s.LowStockThreshold
+	}
+	return defaultThreshold
+}
+
+// Movement kinds. Receive and return add stock, sell removes it and adjust
+// applies a signed correction, e.g. after a stock take. Reserve and release
+// are written by orders: stock is reserved when an order is placed and
+// released when it is cancelled or refunded before shipping.
+const (
+	MovementReceive = "receive"
+	MovementSell    = "sell"
+	MovementAdjust  = "adjust"
+	MovementReturn  = "return"
+	MovementReserve = "reserve"
+	MovementRelease = "release"
+)
+
+// StockMovement is one entry of the append-only stock ledger.
+type StockMovement struct {
+	ID     uint   `json:"id" gorm:"primary_key"`
+	BookID uint   `json:"book_id" gorm:"index"`
+	Kind   string `json:"kind"`
+	// Quantity is as entered: positive, except for adjustments which may be
+	// negative. Change is the signed effect on stock and Balance the stock
+	// level right after the movement.
+	Quantity int    `json:"quantity"`
+	Change   int    `json:"change"`
+	Balance  int    `json:"balance"`
+	Note     string `json:"note"`
+	// OrderID is set on reservations and releases.
+	OrderID   *uint     `json:"order_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+const MaxNoteLength = 255
+
+// Validate accepts the kinds that may be entered by hand; reservations and
+// releases only come from orders.
+func (m *StockMovement) Validate() error {
+	var errs validation.Errors
+	switch m.Kind {
+	case MovementReceive, MovementSell, MovementReturn:
+		if m.Quantity <= 0 {
+			errs.Add("quantity", "must be positive")
+		}
+	case MovementAdjust:
+		if m.Quantity == 0 {
+			errs.Add("quantity", "must not be zero")
+		}
+	case "":
+		errs.Add("kind", "is required")
+	default:
+		errs.Add("kind", "must be one of receive, sell, adjust or return")
+	}
+	errs.MaxLength("note", m.Note, MaxNoteLength)
+	return errs.Err()
+}
+
+// change is the signed effect of the movement on stock.
+func (m *StockMovement) change() int {
+	if m.Kind == MovementSell || m.Kind == MovementReserve {
+		return -m.Quantity
+	}
+	return m.Quantity
+}
+
+// MovementQuery pages through a book's ledger, newest first.
+type MovementQuery struct {
+	Limit  int
+	Offset int
+	Kind   string
+}
+
+func (q MovementQuery) normalize() MovementQuery {
+	q.Limit = BookQuery{Limit: q.Limit}.PageSize()
+	if q.Offset < 0 {
+		q.Offset = 0
+	}
+	return q
+}
+
+// InventoryReport summarises stock across all live books.
+type InventoryReport struct {
+	Titles     int64 `json:"titles"`
+	Units      int64 `json:"units"`
+	ValueCents int64 `json:"value_cents"`
+	OutOfStock int64 `json:"out_of_stock"`
+	// LowStock lists books at or below their threshold, emptiest first.
+	LowStock []LowStockItem `json:"low_stock"`
+}
+
+type LowStockItem struct {
+	BookID    uint    `json:"book_id"`
+	Name      string  `json:"name"`
+	SKU       *string `json:"sku"`
+	Quantity  int     `json:"quantity"`
+	Threshold int     `json:"threshold"`
+}
+
+type InventoryRepository interface {
+	// Get and GetMany only return stock for live books.
+	Get(bookID int64) (*StockLevel, error)
+	GetMany(bookIDs []uint) ([]StockLevel, error)
+	SetThreshold(bookID int64, threshold *int) (*StockLevel, error)
+	// Move applies m to the book's stock and records it in the ledger in one
+	// transaction, filling in Change, Balance and the ID. It returns
+	// ErrInsufficientStock rather than let stock go negative.
+	Move(m *StockMovement) error
+	Movements(bookID int64, q MovementQuery) ([]StockMovement, int64, error)
+	Report() (InventoryReport, error)
+}
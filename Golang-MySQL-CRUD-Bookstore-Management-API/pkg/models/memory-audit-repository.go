@@ -0,0 +1,71 @@
+package models
+
+type memoryAuditRepository struct {
+	*memoryDB
+}
+
+func (r *memoryAuditRepository) History(bookID int64, q AuditQuery) ([]BookAudit, int64, error) {
+	q = q.normalize()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var matched []BookAudit
+	for i := len(r.audits) - 1; i >= 0; i-- {
+		if r.audits[i].BookID == uint(bookID) {
+			matched = append(matched, r.audits[i])
+		}
+	}
+	total := int64(len(matched))
+	if q.Offset > len(matched) {
+		q.Offset = len(matched)
+	}
+	matched = matched[q.Offset:]
+	if len(matched) > q.Limit {
+		matched = matched[:q.Limit]
+	}
+	return append([]BookAudit{}, matched...), total, nil
+}
+
+func (r *memoryAuditRepository) Revision(bookID int64, revision uint) (*BookAudit, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for i := len(r.audits) - 1; i >= 0; i-- {
+		e := r.audits[i]
+		if e.BookID == uint(bookID) && e.Revision == revision && isSnapshotAction(e.Action) {
+			return &e, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *memoryAuditRepository) Since(afterID uint, bookID int64, limit int) ([]BookAudit, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	entries := []BookAudit{}
+	for _, e := range r.audits {
+		if len(entries) == limit {
+			break
+		}
+		if e.ID > afterID && (bookID == 0 || e.BookID == uint(bookID)) {
+			entries = append(entries, e)
+		}
+	}
+	return entries, nil
+}
+
+func (r *memoryAuditRepository) LastID() (uint, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if len(r.audits) == 0 {
+		return 0, nil
+	}
+	return r.audits[len(r.audits)-1].ID, nil
+}
+
+func isSnapshotAction(action string) bool {
+	for _, a := range snapshotActions {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
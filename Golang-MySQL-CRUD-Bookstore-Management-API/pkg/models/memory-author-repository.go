@@ -0,0 +1,99 @@
+package models
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+type memoryAuthorRepository struct {
+	*memoryDB
+}
+
+func (r *memoryAuthorRepository) Create(a *Author) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	a.ID = r.nextID("authors")
+	a.CreatedAt, a.UpdatedAt, a.DeletedAt = now, now, nil
+	a.NameKey = NameKey(a.Name)
+	r.authors[a.ID] = *a
+	return nil
+}
+
+func (r *memoryAuthorRepository) List(q NameQuery) ([]Author, int64, error) {
+	q = q.normalize()
+	r.mu.RLock()
+	var matched []Author
+	for _, a := range r.authors {
+		if a.DeletedAt == nil && strings.Contains(strings.ToLower(a.Name), strings.ToLower(q.Name)) {
+			matched = append(matched, a)
+		}
+	}
+	r.mu.RUnlock()
+	sort.Slice(matched, func(i, j int) bool { return matched[i].ID < matched[j].ID })
+	total := int64(len(matched))
+	if q.Offset >= len(matched) {
+		return []Author{}, total, nil
+	}
+	matched = matched[q.Offset:]
+	if len(matched) > q.Limit {
+		matched = matched[:q.Limit]
+	}
+	return matched, total, nil
+}
+
+func (r *memoryAuthorRepository) Get(id int64) (*Author, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	a, ok := r.authors[uint(id)]
+	if !ok || a.DeletedAt != nil {
+		return nil, ErrNotFound
+	}
+	return &a, nil
+}
+
+func (r *memoryAuthorRepository) GetMany(ids []uint) ([]Author, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]Author, 0, len(ids))
+	for _, id := range ids {
+		if a, ok := r.authors[id]; ok && a.DeletedAt == nil {
+			out = append(out, a)
+		}
+	}
+	return out, nil
+}
+
+func (r *memoryAuthorRepository) Update(a *Author) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.authors[a.ID]
+	if !ok || stored.DeletedAt != nil {
+		return ErrNotFound
+	}
+	a.CreatedAt, a.UpdatedAt = stored.CreatedAt, time.Now()
+	a.NameKey = NameKey(a.Name)
+	r.authors[a.ID] = *a
+	return nil
+}
+
+func (r *memoryAuthorRepository) Delete(id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	a, ok := r.authors[uint(id)]
+	if !ok || a.DeletedAt != nil {
+		return ErrNotFound
+	}
+	for _, ids := range r.bookAuthors {
+		for _, linked := range ids {
+			if linked == a.ID {
+				return ErrInUse
+			}
+		}
+	}
+	now := time.Now()
+	a.DeletedAt = &now
+	r.authors[a.ID] = a
+	return nil
+}
@@ -0,0 +1,408 @@
+package models
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yoloxsta/go-bookstore/pkg/validation"
+)
+
+type memoryBookRepository struct {
+	*memoryDB
+	audit AuditInfo
+}
+
+func (r *memoryBookRepository) WithAudit(info AuditInfo) BookRepository {
+	return &memoryBookRepository{memoryDB: r.memoryDB, audit: info}
+}
+
+// record appends an audit entry; the caller holds the write lock and calls it
+// before changing anything, so a failure leaves the book untouched.
+func (r *memoryBookRepository) record(action string, before, after *Book) error {
+	e, err := newAudit(r.audit, action, before, after)
+	if err != nil {
+		return err
+	}
+	e.ID = r.nextID("book_audits")
+	r.audits = append(r.audits, e)
+	return nil
+}
+
+func (r *memoryBookRepository) Create(b *Book) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := b.normalizeISBN(); err != nil {
+		return err
+	}
+	if err := r.checkUnique(b); err != nil {
+		return err
+	}
+	if err := r.resolveCredits(b); err != nil {
+		return err
+	}
+	now := time.Now()
+	b.ID = r.nextID("books")
+	b.CreatedAt, b.UpdatedAt, b.DeletedAt = now, now, nil
+	b.Version = 1
+	b.RatingAverage, b.RatingCount = 0, 0
+	if err := r.record(AuditCreate, nil, b); err != nil {
+		return err
+	}
+	r.store(*b)
+	r.stock[b.ID] = StockLevel{BookID: b.ID, UpdatedAt: now}
+	return nil
+}
+
+// store writes b and its author links; the caller holds the write lock.
+func (r *memoryBookRepository) store(b Book) {
+	r.bookAuthors[b.ID] = append([]uint(nil), b.AuthorIDs...)
+	b.AuthorIDs, b.Authors, b.Publisher, b.Stock, b.Cover = nil, nil, nil, nil, nil
+	r.books[b.ID] = b
+	if b.DeletedAt == nil {
+		indexBook(r.bookIndex, b)
+	}
+}
+
+// load returns a stored book with its author links filled in.
+func (r *memoryBookRepository) load(b Book) Book {
+	b.AuthorIDs = append([]uint{}, r.bookAuthors[b.ID]...)
+	return b
+}
+
+func (r *memoryBookRepository) CheckUnique(b *Book) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if err := b.normalizeISBN(); err != nil {
+		return err
+	}
+	return r.checkUnique(b)
+}
+
+// checkUnique rejects a SKU or ISBN already used by another book, live or
+// trashed. The caller holds the write lock.
+func (r *memoryBookRepository) checkUnique(b *Book) error {
+	for id, other := range r.books {
+		if id == b.ID {
+			continue
+		}
+		if b.SKU != nil && other.SKU != nil && *other.SKU == *b.SKU {
+			return skuTaken(id)
+		}
+		if b.ISBN13 != nil && other.ISBN13 != nil && *other.ISBN13 == *b.ISBN13 {
+			return isbnTaken(id)
+		}
+	}
+	return nil
+}
+
+// resolveCredits checks the author and publisher links of b, or derives them
+// from the free-text credits, creating authors and publishers that do not
+// exist yet. The caller holds the write lock.
+func (r *memoryBookRepository) resolveCredits(b *Book) error {
+	var errs validation.Errors
+	if len(b.AuthorIDs) > 0 {
+		var names []string
+		for _, id := range b.AuthorIDs {
+			a, ok := r.authors[id]
+			if !ok || a.DeletedAt != nil {
+				errs.Add("author_ids", "author %d does not exist", id)
+				continue
+			}
+			names = append(names, a.Name)
+		}
+		if b.Author == "" {
+			b.Author = JoinCredit(names)
+		}
+	} else {
+		for _, name := range SplitCredit(b.Author) {
+			b.AuthorIDs = append(b.AuthorIDs, r.findOrCreateAuthor(name))
+		}
+	}
+	if b.PublisherID != nil {
+		p, ok := r.publishers[*b.PublisherID]
+		if !ok || p.DeletedAt != nil {
+			errs.Add("publisher_id", "publisher %d does not exist", *b.PublisherID)
+		} else if b.Publication == "" {
+			b.Publication = p.Name
+		}
+	} else if name := strings.Join(strings.Fields(b.Publication), " "); name != "" {
+		id := r.findOrCreatePublisher(name)
+		b.PublisherID = &id
+	}
+	return errs.Err()
+}
+
+func (r *memoryBookRepository) findOrCreateAuthor(name string) uint {
+	key := NameKey(name)
+	var best uint
+	for id, a := range r.authors {
+		if a.DeletedAt == nil && a.NameKey == key && (best == 0 || id < best) {
+			best = id
+		}
+	}
+	if best != 0 {
+		return best
+	}
+	now := time.Now()
+	a := Author{Name: name, NameKey: key}
+	a.ID, a.CreatedAt, a.UpdatedAt = r.nextID("authors"), now, now
+	r.authors[a.ID] = a
+	return a.ID
+}
+
+func (r *memoryBookRepository) findOrCreatePublisher(name string) uint {
+	key := NameKey(name)
+	var best uint
+	for id, p := range r.publishers {
+		if p.DeletedAt == nil && p.NameKey == key && (best == 0 || id < best) {
+			best = id
+		}
+	}
+	if best != 0 {
+		return best
+	}
+	now := time.Now()
+	p := Publisher{Name: name, NameKey: key}
+	p.ID, p.CreatedAt, p.UpdatedAt = r.nextID("publishers"), now, now
+	r.publishers[p.ID] = p
+	return p.ID
+}
+
+func (r *memoryBookRepository) hasAuthor(b Book, authorID uint) bool {
+	for _, id := range r.bookAuthors[b.ID] {
+		if id == authorID {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *memoryBookRepository) List(q BookQuery) (BookPage, error) {
+	q, err := q.normalize()
+	if err != nil {
+		return BookPage{}, err
+	}
+	var after []interface{}
+	if q.Cursor != "" {
+		if after, err = decodeCursor(q); err != nil {
+			return BookPage{}, err
+		}
+	}
+	contains := func(s, sub string) bool {
+		return sub == "" || strings.Contains(strings.ToLower(s), strings.ToLower(sub))
+	}
+
+	r.mu.RLock()
+	matched := make([]Book, 0, len(r.books))
+	for _, b := range r.books {
+		if (b.DeletedAt != nil) != q.Deleted ||
+			!contains(b.Name, q.Name) || !contains(b.Author, q.Author) || !contains(b.Publication, q.Publication) ||
+			(q.AuthorID != 0 && !r.hasAuthor(b, q.AuthorID)) ||
+			(q.PublisherID != 0 && (b.PublisherID == nil || *b.PublisherID != q.PublisherID)) {
+			continue
+		}
+		matched = append(matched, r.load(b))
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(matched, func(i, j int) bool {
+		return compareKeys(q, sortKey(q, matched[i]), sortKey(q, matched[j])) < 0
+	})
+	var page BookPage
+	if !q.SkipTotal {
+		page.Total = int64(len(matched))
+	}
+	if after != nil {
+		start := sort.Search(len(matched), func(i int) bool {
+			return compareKeys(q, sortKey(q, matched[i]), after) > 0
+		})
+		matched = matched[start:]
+	}
+	if q.Offset >= len(matched) {
+		matched = nil
+	} else {
+		matched = matched[q.Offset:]
+	}
+	if len(matched) > q.Limit {
+		matched = matched[:q.Limit]
+		page.NextCursor = encodeCursor(q, matched[len(matched)-1])
+	}
+	page.Books = append([]Book{}, matched...)
+	return page, nil
+}
+
+func (r *memoryBookRepository) Get(id int64) (*Book, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	b, ok := r.books[uint(id)]
+	if !ok || b.DeletedAt != nil {
+		return nil, ErrNotFound
+	}
+	b = r.load(b)
+	return &b, nil
+}
+
+func (r *memoryBookRepository) GetByISBN(isbn13 string) (*Book, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, b := range r.books {
+		if b.DeletedAt == nil && b.ISBN13 != nil && *b.ISBN13 == isbn13 {
+			b = r.load(b)
+			return &b, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *memoryBookRepository) Update(b *Book) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, err := r.current(b)
+	if err != nil {
+		return err
+	}
+	if err := b.normalizeISBN(); err != nil {
+		return err
+	}
+	if err := r.checkUnique(b); err != nil {
+		return err
+	}
+	if err := r.resolveCredits(b); err != nil {
+		return err
+	}
+	before := r.load(stored)
+	b.CreatedAt = stored.CreatedAt
+	b.RatingAverage, b.RatingCount = stored.RatingAverage, stored.RatingCount
+	b.UpdatedAt = time.Now()
+	b.Version++
+	if err := r.record(AuditUpdate, &before, b); err != nil {
+		b.Version--
+		return err
+	}
+	r.store(*b)
+	return nil
+}
+
+func (r *memoryBookRepository) Delete(b *Book) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, err := r.current(b)
+	if err != nil {
+		return err
+	}
+	before := r.load(stored)
+	now := time.Now()
+	stored.DeletedAt = &now
+	after := r.load(stored)
+	if err := r.record(AuditDelete, &before, &after); err != nil {
+		return err
+	}
+	r.books[stored.ID] = stored
+	r.bookIndex.Delete(stored.ID)
+	*b = r.load(stored)
+	return nil
+}
+
+func (r *memoryBookRepository) GetDeleted(id int64) (*Book, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	b, ok := r.books[uint(id)]
+	if !ok || b.DeletedAt == nil {
+		return nil, ErrNotFound
+	}
+	b = r.load(b)
+	return &b, nil
+}
+
+func (r *memoryBookRepository) Restore(id int64) (*Book, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	b, ok := r.books[uint(id)]
+	if !ok || b.DeletedAt == nil {
+		return nil, ErrNotFound
+	}
+	before := r.load(b)
+	b.DeletedAt = nil
+	b.UpdatedAt = time.Now()
+	b.Version++
+	after := r.load(b)
+	if err := r.record(AuditRestore, &before, &after); err != nil {
+		return nil, err
+	}
+	r.books[b.ID] = b
+	indexBook(r.bookIndex, b)
+	b = r.load(b)
+	return &b, nil
+}
+
+func (r *memoryBookRepository) Purge(b *Book) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.books[b.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	if stored.Version != b.Version {
+		return ErrConflict
+	}
+	before := r.load(stored)
+	if err := r.record(AuditPurge, &before, nil); err != nil {
+		return err
+	}
+	delete(r.books, b.ID)
+	delete(r.bookAuthors, b.ID)
+	delete(r.stock, b.ID)
+	delete(r.movements, b.ID)
+	delete(r.covers, b.ID)
+	r.dropReviews(b.ID)
+	r.bookIndex.Delete(b.ID)
+	return nil
+}
+
+func (r *memoryBookRepository) PurgeDeletedBefore(t time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var n int64
+	for id, b := range r.books {
+		if b.DeletedAt != nil && b.DeletedAt.Before(t) {
+			before := r.load(b)
+			if err := r.record(AuditPurge, &before, nil); err != nil {
+				return n, err
+			}
+			delete(r.books, id)
+			delete(r.bookAuthors, id)
+			delete(r.stock, id)
+			delete(r.movements, id)
+			delete(r.covers, id)
+			r.dropReviews(id)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// current returns the live stored copy of b, checking its version.
+func (r *memoryBookRepository) current(b *Book) (Book, error) {
+	stored, ok := r.books[b.ID]
+	if !ok || stored.DeletedAt != nil {
+		return Book{}, ErrNotFound
+	}
+	if stored.Version != b.Version {
+		return Book{}, ErrConflict
+	}
+	return stored, nil
+}
+
+func (r *memoryBookRepository) Search(query string, limit int) ([]SearchResult, error) {
+	hits := r.bookIndex.Search(query, limit)
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	results := make([]SearchResult, 0, len(hits))
+	for _, h := range hits {
+		if b, ok := r.books[h.ID]; ok && b.DeletedAt == nil {
+			results = append(results, SearchResult{Book: r.load(b), Score: h.Score, Highlights: h.Highlights})
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,93 @@
+package models
+
+import (
+	"sort"
+	"time"
+)
+
+type memoryCoverRepository struct {
+	*memoryDB
+}
+
+func (r *memoryCoverRepository) Get(bookID int64) (*Cover, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	b, ok := r.books[uint(bookID)]
+	c, found := r.covers[uint(bookID)]
+	if !ok || b.DeletedAt != nil || !found {
+		return nil, ErrNotFound
+	}
+	return &c, nil
+}
+
+func (r *memoryCoverRepository) GetAny(bookID int64) (*Cover, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	c, ok := r.covers[uint(bookID)]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &c, nil
+}
+
+func (r *memoryCoverRepository) ListTrashedBefore(t time.Time) ([]Cover, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var covers []Cover
+	for id, c := range r.covers {
+		if b, ok := r.books[id]; ok && b.DeletedAt != nil && b.DeletedAt.Before(t) {
+			covers = append(covers, c)
+		}
+	}
+	sort.Slice(covers, func(i, j int) bool { return covers[i].BookID < covers[j].BookID })
+	return covers, nil
+}
+
+func (r *memoryCoverRepository) Put(b *Book, c *Cover) (*Cover, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.touch(b); err != nil {
+		return nil, err
+	}
+	var previous *Cover
+	if old, ok := r.covers[b.ID]; ok {
+		previous = &old
+	}
+	c.BookID = b.ID
+	c.UpdatedAt = b.UpdatedAt
+	stored := *c
+	stored.URL, stored.Thumbnails = "", nil
+	r.covers[b.ID] = stored
+	return previous, nil
+}
+
+func (r *memoryCoverRepository) Delete(b *Book) (*Cover, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	old, ok := r.covers[b.ID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if err := r.touch(b); err != nil {
+		return nil, err
+	}
+	delete(r.covers, b.ID)
+	return &old, nil
+}
+
+// touch bumps the version of the live book b if it still matches. The
+// caller holds the write lock.
+func (r *memoryCoverRepository) touch(b *Book) error {
+	stored, ok := r.books[b.ID]
+	if !ok || stored.DeletedAt != nil {
+		return ErrNotFound
+	}
+	if stored.Version != b.Version {
+		return ErrConflict
+	}
+	stored.Version++
+	stored.UpdatedAt = time.Now()
+	r.books[b.ID] = stored
+	b.Version, b.UpdatedAt = stored.Version, stored.UpdatedAt
+	return nil
+}
@@ -0,0 +1,144 @@
+package models
+
+import (
+	"sort"
+	"time"
+)
+
+type memoryInventoryRepository struct {
+	*memoryDB
+	defaultThreshold int
+}
+
+// liveStock returns the stock of a live book; the caller holds the lock.
+func (r *memoryInventoryRepository) liveStock(bookID uint) (StockLevel, error) {
+	b, ok := r.books[bookID]
+	if !ok || b.DeletedAt != nil {
+		return StockLevel{}, ErrNotFound
+	}
+	s := r.stock[bookID]
+	s.LowStock = s.Quantity <= s.threshold(r.defaultThreshold)
+	return s, nil
+}
+
+func (r *memoryInventoryRepository) Get(bookID int64) (*StockLevel, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	s, err := r.liveStock(uint(bookID))
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *memoryInventoryRepository) GetMany(bookIDs []uint) ([]StockLevel, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]StockLevel, 0, len(bookIDs))
+	for _, id := range bookIDs {
+		if s, err := r.liveStock(id); err == nil {
+			out = append(out, s)
+		}
+	}
+	return out, nil
+}
+
+func (r *memoryInventoryRepository) SetThreshold(bookID int64, threshold *int) (*StockLevel, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s, err := r.liveStock(uint(bookID))
+	if err != nil {
+		return nil, err
+	}
+	s.LowStockThreshold = threshold
+	s.UpdatedAt = time.Now()
+	s.LowStock = s.Quantity <= s.threshold(r.defaultThreshold)
+	r.stock[s.BookID] = s
+	return &s, nil
+}
+
+func (r *memoryInventoryRepository) Move(m *StockMovement) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.applyMovement(m)
+}
+
+// applyMovement is Move for callers that already hold the write lock.
+// Releases also reach trashed books, so that a restored book has its stock
+// back.
+func (db *memoryDB) applyMovement(m *StockMovement) error {
+	b, ok := db.books[m.BookID]
+	if !ok || b.DeletedAt != nil && m.Kind != MovementRelease {
+		return ErrNotFound
+	}
+	s := db.stock[m.BookID]
+	m.Change = m.change()
+	if s.Quantity+m.Change < 0 {
+		return &InsufficientStockError{BookID: m.BookID}
+	}
+	now := time.Now()
+	s.Quantity += m.Change
+	s.UpdatedAt = now
+	db.stock[s.BookID] = s
+	m.ID = db.nextID("stock_movements")
+	m.Balance, m.CreatedAt = s.Quantity, now
+	db.movements[s.BookID] = append(db.movements[s.BookID], *m)
+	return nil
+}
+
+func (r *memoryInventoryRepository) Movements(bookID int64, q MovementQuery) ([]StockMovement, int64, error) {
+	q = q.normalize()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if _, err := r.liveStock(uint(bookID)); err != nil {
+		return nil, 0, err
+	}
+	ledger := r.movements[uint(bookID)]
+	matched := []StockMovement{}
+	for i := len(ledger) - 1; i >= 0; i-- {
+		if q.Kind == "" || ledger[i].Kind == q.Kind {
+			matched = append(matched, ledger[i])
+		}
+	}
+	total := int64(len(matched))
+	if q.Offset >= len(matched) {
+		return []StockMovement{}, total, nil
+	}
+	matched = matched[q.Offset:]
+	if len(matched) > q.Limit {
+		matched = matched[:q.Limit]
+	}
+	return matched, total, nil
+}
+
+func (r *memoryInventoryRepository) Report() (InventoryReport, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	rep := InventoryReport{LowStock: []LowStockItem{}}
+	for id, b := range r.books {
+		if b.DeletedAt != nil {
+			continue
+		}
+		s := r.stock[id]
+		rep.Titles++
+		rep.Units += int64(s.Quantity)
+		rep.ValueCents += int64(s.Quantity) * b.PriceCents
+		if s.Quantity == 0 {
+			rep.OutOfStock++
+		}
+		threshold := s.threshold(r.defaultThreshold)
+		if s.Quantity <= threshold {
+			rep.LowStock = append(rep.LowStock, LowStockItem{
+				BookID: id, Name: b.Name, SKU: b.SKU, Quantity: s.Quantity, Threshold: threshold,
+			})
+		}
+	}
+	sort.Slice(rep.LowStock, func(i, j int) bool {
+		a, b := rep.LowStock[i], rep.LowStock[j]
+		if a.Quantity != b.Quantity {
+			return a.Quantity < b.Quantity
+		}
+		return a.BookID < b.BookID
+	})
+	return rep, nil
+}
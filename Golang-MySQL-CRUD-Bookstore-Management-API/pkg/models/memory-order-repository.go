@@ -0,0 +1,226 @@
+package models
+
+import (
+	"sort"
+	"time"
+
+	"github.com/yoloxsta/go-bookstore/pkg/validation"
+)
+
+type memoryCartRepository struct {
+	*memoryDB
+}
+
+func (r *memoryCartRepository) Create(c *Cart) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	*c = Cart{ID: r.nextID("carts"), Customer: c.Customer, Items: []CartItem{}, CreatedAt: now, UpdatedAt: now}
+	r.carts[c.ID] = *c
+	return nil
+}
+
+// load returns a copy of the stored cart that callers may modify.
+func (r *memoryCartRepository) load(id uint) (Cart, bool) {
+	c, ok := r.carts[id]
+	c.Items = append([]CartItem{}, c.Items...)
+	return c, ok
+}
+
+func (r *memoryCartRepository) Get(id int64) (*Cart, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	c, ok := r.load(uint(id))
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &c, nil
+}
+
+func (r *memoryCartRepository) SetItem(cartID int64, item CartItem) (*Cart, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c, ok := r.load(uint(cartID))
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if c.OrderID != nil {
+		return nil, ErrCheckedOut
+	}
+	if b, ok := r.books[item.BookID]; item.Quantity > 0 && (!ok || b.DeletedAt != nil) {
+		var errs validation.Errors
+		errs.Add("book_id", "book %d does not exist", item.BookID)
+		return nil, errs
+	}
+	item.CartID = c.ID
+	items := c.Items[:0]
+	for _, it := range c.Items {
+		if it.BookID != item.BookID {
+			items = append(items, it)
+		}
+	}
+	if item.Quantity > 0 {
+		items = append(items, item)
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].BookID < items[j].BookID })
+	c.Items = items
+	c.UpdatedAt = time.Now()
+	r.carts[c.ID] = c
+	out, _ := r.load(c.ID)
+	return &out, nil
+}
+
+func (r *memoryCartRepository) Delete(id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c, ok := r.carts[uint(id)]
+	if !ok {
+		return ErrNotFound
+	}
+	if c.OrderID != nil {
+		return ErrCheckedOut
+	}
+	delete(r.carts, c.ID)
+	return nil
+}
+
+type memoryOrderRepository struct {
+	*memoryDB
+}
+
+func (r *memoryOrderRepository) Checkout(co Checkout, currency string) (*Order, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c, ok := r.carts[co.CartID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if c.OrderID != nil {
+		return nil, ErrCheckedOut
+	}
+	if len(c.Items) == 0 {
+		return nil, ErrEmptyCart
+	}
+
+	// Check every item before touching stock, so a short item leaves
+	// nothing half reserved.
+	for _, it := range c.Items {
+		b, ok := r.books[it.BookID]
+		if !ok || b.DeletedAt != nil {
+			return nil, bookUnavailable(it.BookID)
+		}
+		if r.stock[it.BookID].Quantity < it.Quantity {
+			return nil, &InsufficientStockError{BookID: it.BookID}
+		}
+	}
+
+	now := time.Now()
+	o := Order{
+		ID:        r.nextID("orders"),
+		Customer:  co.Customer,
+		Status:    OrderPending,
+		Currency:  currency,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	for _, it := range c.Items {
+		b := r.books[it.BookID]
+		m := StockMovement{BookID: it.BookID, Kind: MovementReserve, Quantity: it.Quantity, OrderID: &o.ID}
+		if err := r.applyMovement(&m); err != nil {
+			return nil, err
+		}
+		o.Items = append(o.Items, OrderItem{
+			ID:             r.nextID("order_items"),
+			OrderID:        o.ID,
+			BookID:         b.ID,
+			Name:           b.Name,
+			SKU:            b.SKU,
+			Quantity:       it.Quantity,
+			UnitPriceCents: b.PriceCents,
+		})
+		o.TotalCents += int64(it.Quantity) * b.PriceCents
+	}
+	r.orders[o.ID] = o
+	r.orderEvents[o.ID] = []OrderEvent{{ID: r.nextID("order_events"), OrderID: o.ID, To: OrderPending, CreatedAt: now}}
+	c.OrderID = &o.ID
+	c.UpdatedAt = now
+	r.carts[c.ID] = c
+	return r.load(o.ID), nil
+}
+
+func (r *memoryOrderRepository) load(id uint) *Order {
+	o := r.orders[id]
+	o.Items = append([]OrderItem{}, o.Items...)
+	return &o
+}
+
+func (r *memoryOrderRepository) Get(id int64) (*Order, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if _, ok := r.orders[uint(id)]; !ok {
+		return nil, ErrNotFound
+	}
+	return r.load(uint(id)), nil
+}
+
+func (r *memoryOrderRepository) List(q OrderQuery) ([]Order, int64, error) {
+	q = q.normalize()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	matched := []Order{}
+	for id, o := range r.orders {
+		if (q.Customer == "" || o.Customer == q.Customer) && (q.Status == "" || o.Status == q.Status) {
+			matched = append(matched, *r.load(id))
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool { return matched[i].ID > matched[j].ID })
+	total := int64(len(matched))
+	if q.Offset >= len(matched) {
+		return []Order{}, total, nil
+	}
+	matched = matched[q.Offset:]
+	if len(matched) > q.Limit {
+		matched = matched[:q.Limit]
+	}
+	return matched, total, nil
+}
+
+func (r *memoryOrderRepository) Transition(id int64, t OrderTransition) (*Order, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	o, ok := r.orders[uint(id)]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if o.Status != t.From || !CanTransition(t.From, t.To) {
+		return nil, ErrConflict
+	}
+	if releasesStock(t.From, t.To) {
+		for _, it := range o.Items {
+			m := StockMovement{BookID: it.BookID, Kind: MovementRelease, Quantity: it.Quantity, OrderID: &o.ID}
+			// A purged book has no stock left to release.
+			if err := r.applyMovement(&m); err != nil && err != ErrNotFound {
+				return nil, err
+			}
+		}
+	}
+	now := time.Now()
+	o.Status, o.UpdatedAt = t.To, now
+	if t.PaymentID != "" {
+		o.PaymentID = t.PaymentID
+	}
+	r.orders[o.ID] = o
+	r.orderEvents[o.ID] = append(r.orderEvents[o.ID], OrderEvent{
+		ID: r.nextID("order_events"), OrderID: o.ID, From: t.From, To: t.To, Note: t.Note, CreatedAt: now,
+	})
+	return r.load(o.ID), nil
+}
+
+func (r *memoryOrderRepository) Events(id int64) ([]OrderEvent, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if _, ok := r.orders[uint(id)]; !ok {
+		return nil, ErrNotFound
+	}
+	return append([]OrderEvent{}, r.orderEvents[uint(id)]...), nil
+}
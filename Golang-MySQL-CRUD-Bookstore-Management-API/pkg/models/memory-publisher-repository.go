@@ -0,0 +1,97 @@
+package models
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+type memoryPublisherRepository struct {
+	*memoryDB
+}
+
+func (r *memoryPublisherRepository) Create(p *Publisher) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	p.ID = r.nextID("publishers")
+	p.CreatedAt, p.UpdatedAt, p.DeletedAt = now, now, nil
+	p.NameKey = NameKey(p.Name)
+	r.publishers[p.ID] = *p
+	return nil
+}
+
+func (r *memoryPublisherRepository) List(q NameQuery) ([]Publisher, int64, error) {
+	q = q.normalize()
+	r.mu.RLock()
+	var matched []Publisher
+	for _, p := range r.publishers {
+		if p.DeletedAt == nil && strings.Contains(strings.ToLower(p.Name), strings.ToLower(q.Name)) {
+			matched = append(matched, p)
+		}
+	}
+	r.mu.RUnlock()
+	sort.Slice(matched, func(i, j int) bool { return matched[i].ID < matched[j].ID })
+	total := int64(len(matched))
+	if q.Offset >= len(matched) {
+		return []Publisher{}, total, nil
+	}
+	matched = matched[q.Offset:]
+	if len(matched) > q.Limit {
+		matched = matched[:q.Limit]
+	}
+	return matched, total, nil
+}
+
+func (r *memoryPublisherRepository) Get(id int64) (*Publisher, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	p, ok := r.publishers[uint(id)]
+	if !ok || p.DeletedAt != nil {
+		return nil, ErrNotFound
+	}
+	return &p, nil
+}
+
+func (r *memoryPublisherRepository) GetMany(ids []uint) ([]Publisher, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]Publisher, 0, len(ids))
+	for _, id := range ids {
+		if p, ok := r.publishers[id]; ok && p.DeletedAt == nil {
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+
+func (r *memoryPublisherRepository) Update(p *Publisher) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.publishers[p.ID]
+	if !ok || stored.DeletedAt != nil {
+		return ErrNotFound
+	}
+	p.CreatedAt, p.UpdatedAt = stored.CreatedAt, time.Now()
+	p.NameKey = NameKey(p.Name)
+	r.publishers[p.ID] = *p
+	return nil
+}
+
+func (r *memoryPublisherRepository) Delete(id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p, ok := r.publishers[uint(id)]
+	if !ok || p.DeletedAt != nil {
+		return ErrNotFound
+	}
+	for _, b := range r.books {
+		if b.PublisherID != nil && *b.PublisherID == p.ID {
+			return ErrInUse
+		}
+	}
+	now := time.Now()
+	p.DeletedAt = &now
+	r.publishers[p.ID] = p
+	return nil
+}
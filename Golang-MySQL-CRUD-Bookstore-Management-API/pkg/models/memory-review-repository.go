@@ -0,0 +1,152 @@
+package models
+
+import (
+	"sort"
+	"time"
+)
+
+type memoryReviewRepository struct {
+	*memoryDB
+	audit AuditInfo
+}
+
+func (r *memoryReviewRepository) WithAudit(info AuditInfo) ReviewRepository {
+	cp := *r
+	cp.audit = info
+	return &cp
+}
+
+// liveBook reports whether id is a book outside the trash; the caller holds
+// the lock.
+func (r *memoryReviewRepository) liveBook(id uint) bool {
+	b, ok := r.books[id]
+	return ok && b.DeletedAt == nil
+}
+
+func (r *memoryReviewRepository) Create(rv *Review) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.liveBook(rv.BookID) {
+		return ErrNotFound
+	}
+	for _, other := range r.reviews {
+		if other.BookID == rv.BookID && other.Reviewer == rv.Reviewer {
+			return ErrDuplicateReview
+		}
+	}
+	now := time.Now()
+	rv.ID = r.nextID("book_reviews")
+	rv.CreatedAt, rv.UpdatedAt = now, now
+	r.reviews[rv.ID] = *rv
+	if rv.Status == ReviewApproved {
+		if err := r.refreshRating(rv.BookID, ratingAudit(r.audit, rv)); err != nil {
+			delete(r.reviews, rv.ID)
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *memoryReviewRepository) Get(bookID, id int64) (*Review, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	rv, ok := r.reviews[uint(id)]
+	if !ok || rv.BookID != uint(bookID) || !r.liveBook(rv.BookID) {
+		return nil, ErrNotFound
+	}
+	return &rv, nil
+}
+
+func (r *memoryReviewRepository) List(q ReviewQuery) ([]Review, int64, error) {
+	q = q.normalize()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	matched := []Review{}
+	if r.liveBook(q.BookID) {
+		for _, rv := range r.reviews {
+			if rv.BookID == q.BookID && (q.Status == "" || rv.Status == q.Status) {
+				matched = append(matched, rv)
+			}
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool { return matched[i].ID > matched[j].ID })
+	total := int64(len(matched))
+	if q.Offset >= len(matched) {
+		return []Review{}, total, nil
+	}
+	matched = matched[q.Offset:]
+	if len(matched) > q.Limit {
+		matched = matched[:q.Limit]
+	}
+	return matched, total, nil
+}
+
+func (r *memoryReviewRepository) ListMany(bookIDs []uint, q ReviewQuery) (map[uint]ReviewPage, error) {
+	pages := make(map[uint]ReviewPage, len(bookIDs))
+	for _, id := range bookIDs {
+		q.BookID = id
+		reviews, total, err := r.List(q)
+		if err != nil {
+			return nil, err
+		}
+		if total > 0 {
+			pages[id] = ReviewPage{Reviews: reviews, Total: total}
+		}
+	}
+	return pages, nil
+}
+
+func (r *memoryReviewRepository) Moderate(bookID, id int64, status string) (*Review, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rv, ok := r.reviews[uint(id)]
+	if !ok || rv.BookID != uint(bookID) || !r.liveBook(rv.BookID) {
+		return nil, ErrNotFound
+	}
+	if rv.Status == status {
+		return &rv, nil
+	}
+	previous := rv
+	rv.Status, rv.UpdatedAt = status, time.Now()
+	r.reviews[rv.ID] = rv
+	if previous.Status == ReviewApproved || status == ReviewApproved {
+		if err := r.refreshRating(rv.BookID, ratingAudit(r.audit, &rv)); err != nil {
+			r.reviews[rv.ID] = previous
+			return nil, err
+		}
+	}
+	return &rv, nil
+}
+
+// refreshRating recomputes the rating of a book from its approved reviews,
+// bumps its version and records the change; the caller holds the write lock.
+func (r *memoryReviewRepository) refreshRating(bookID uint, info AuditInfo) error {
+	var count, total int
+	for _, rv := range r.reviews {
+		if rv.BookID == bookID && rv.Status == ReviewApproved {
+			count++
+			total += rv.Rating
+		}
+	}
+	books := &memoryBookRepository{memoryDB: r.memoryDB, audit: info}
+	b := r.books[bookID]
+	before := books.load(b)
+	b.RatingAverage, b.RatingCount = averageRating(total, count), count
+	b.Version++
+	after := books.load(b)
+	if err := books.record(AuditUpdate, &before, &after); err != nil {
+		return err
+	}
+	r.books[bookID] = b
+	return nil
+}
+
+// dropReviews removes every review of a purged book; the caller holds the
+// write lock.
+func (db *memoryDB) dropReviews(bookID uint) {
+	for id, rv := range db.reviews {
+		if rv.BookID == bookID {
+			delete(db.reviews, id)
+		}
+	}
+}
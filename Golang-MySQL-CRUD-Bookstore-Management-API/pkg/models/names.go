@@ -0,0 +1,53 @@
+package models
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// creditSeparators split a free-text credit such as "Kernighan & Ritchie"
+// into individual names. Commas are left alone because "Smith, John" is one
+// name.
+var creditSeparators = regexp.MustCompile(`(?i)\s*(?:;|&|\band\b)\s*`)
+
+// SplitCredit breaks a free-text author or publisher credit into names.
+func SplitCredit(s string) []string {
+	var names []string
+	for _, n := range creditSeparators.Split(s, -1) {
+		if n = strings.Join(strings.Fields(n), " "); n != "" {
+			names = append(names, n)
+		}
+	}
+	return names
+}
+
+// JoinCredit renders names as a credit that SplitCredit turns back into the
+// same names.
+func JoinCredit(names []string) string {
+	return strings.Join(names, "; ")
+}
+
+// NameKey folds spelling differences that do not change who is meant: case,
+// punctuation and spacing. "J.R.R. Tolkien" and "j. r. r. tolkien" share a key.
+func NameKey(name string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
+
+// NameQuery pages through authors or publishers, optionally filtered by a
+// case-insensitive name substring.
+type NameQuery struct {
+	Limit  int
+	Offset int
+	Name   string
+}
+
+func (q NameQuery) normalize() NameQuery {
+	q.Limit = BookQuery{Limit: q.Limit}.PageSize()
+	if q.Offset < 0 {
+		q.Offset = 0
+	}
+	return q
+}
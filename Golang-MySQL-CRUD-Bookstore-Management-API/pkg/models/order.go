@@ -0,0 +1,188 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/yoloxsta/go-bookstore/pkg/validation"
+)
+
+var (
+	// ErrCheckedOut means a cart has already become an order and can no
+	// longer change.
+	ErrCheckedOut = errors.New("models: cart is already checked out")
+	ErrEmptyCart  = errors.New("models: cart is empty")
+)
+
+// Cart collects books before checkout. Once checked out it is kept, read
+// only, with OrderID pointing at the order it became. Customer is the token
+// subject of whoever created it.
+type Cart struct {
+	ID        uint       `json:"id" gorm:"primary_key"`
+	Customer  string     `json:"customer" gorm:"index"`
+	Items     []CartItem `json:"items" gorm:"-"`
+	OrderID   *uint      `json:"order_id"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+type CartItem struct {
+	CartID   uint `json:"-" gorm:"primary_key;auto_increment:false"`
+	BookID   uint `json:"book_id" gorm:"primary_key;auto_increment:false"`
+	Quantity int  `json:"quantity"`
+}
+
+// MaxCartQuantity caps a single cart line.
+const MaxCartQuantity = 1000
+
+func (i *CartItem) Validate() error {
+	var errs validation.Errors
+	if i.Quantity < 0 || i.Quantity > MaxCartQuantity {
+		errs.Add("quantity", "must be between 0 and %d", MaxCartQuantity)
+	}
+	return errs.Err()
+}
+
+// bookUnavailable reports a cart line whose book has since been deleted.
+func bookUnavailable(bookID uint) error {
+	var errs validation.Errors
+	errs.Add("items", "book %d is no longer available", bookID)
+	return errs
+}
+
+// Order statuses.
+const (
+	OrderPending   = "pending"
+	OrderPaid      = "paid"
+	OrderShipped   = "shipped"
+	OrderCancelled = "cancelled"
+	OrderRefunded  = "refunded"
+)
+
+// orderTransitions lists the statuses each status may move to. Cancelled
+// and refunded are final.
+var orderTransitions = map[string][]string{
+	OrderPending: {OrderPaid, OrderCancelled},
+	OrderPaid:    {OrderShipped, OrderRefunded},
+	OrderShipped: {OrderRefunded},
+}
+
+// CanTransition reports whether an order may move from one status to another.
+func CanTransition(from, to string) bool {
+	for _, s := range orderTransitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// releasesStock reports whether moving from one status to another gives the
+// reserved stock back: the books never left the shop.
+func releasesStock(from, to string) bool {
+	return to == OrderCancelled || to == OrderRefunded && from != OrderShipped
+}
+
+type Order struct {
+	ID       uint   `json:"id" gorm:"primary_key"`
+	Customer string `json:"customer" gorm:"index"`
+	Status   string `json:"status"`
+	// TotalCents is the sum of the items at the prices when the order was
+	// placed.
+	TotalCents int64       `json:"total_cents"`
+	Currency   string      `json:"currency"`
+	PaymentID  string      `json:"payment_id,omitempty"`
+	Items      []OrderItem `json:"items" gorm:"-"`
+	CreatedAt  time.Time   `json:"created_at"`
+	UpdatedAt  time.Time   `json:"updated_at"`
+}
+
+// OrderItem copies the book's name, SKU and price at the time of the order,
+// so later edits to the book do not change past orders.
+type OrderItem struct {
+	ID             uint    `json:"-" gorm:"primary_key"`
+	OrderID        uint    `json:"-" gorm:"index"`
+	BookID         uint    `json:"book_id"`
+	Name           string  `json:"name"`
+	SKU            *string `json:"sku"`
+	Quantity       int     `json:"quantity"`
+	UnitPriceCents int64   `json:"unit_price_cents"`
+}
+
+// OrderEvent records one status change; the first event of every order has
+// an empty From.
+type OrderEvent struct {
+	ID        uint      `json:"-" gorm:"primary_key"`
+	OrderID   uint      `json:"-" gorm:"index"`
+	From      string    `json:"from" gorm:"column:from_status"`
+	To        string    `json:"to" gorm:"column:to_status"`
+	Note      string    `json:"note"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+const MaxCustomerLength = 255
+
+// Checkout is what a client sends to turn a cart into an order. The order
+// goes to the cart's owner, so Customer is not read from the body.
+type Checkout struct {
+	CartID   uint   `json:"cart_id"`
+	Customer string `json:"-"`
+}
+
+func (c *Checkout) Validate() error {
+	var errs validation.Errors
+	if c.CartID == 0 {
+		errs.Add("cart_id", "is required")
+	}
+	if errs.Required("customer", c.Customer) {
+		errs.MaxLength("customer", c.Customer, MaxCustomerLength)
+	}
+	return errs.Err()
+}
+
+// OrderTransition moves an order from From to To. PaymentID, when set, is
+// stored on the order.
+type OrderTransition struct {
+	From      string
+	To        string
+	Note      string
+	PaymentID string
+}
+
+// OrderQuery pages through orders, newest first.
+type OrderQuery struct {
+	Limit    int
+	Offset   int
+	Customer string
+	Status   string
+}
+
+func (q OrderQuery) normalize() OrderQuery {
+	q.Limit = BookQuery{Limit: q.Limit}.PageSize()
+	if q.Offset < 0 {
+		q.Offset = 0
+	}
+	return q
+}
+
+type CartRepository interface {
+	Create(c *Cart) error
+	Get(id int64) (*Cart, error)
+	// SetItem sets the quantity of a book in the cart; zero removes it. It
+	// returns ErrCheckedOut once the cart has become an order.
+	SetItem(cartID int64, item CartItem) (*Cart, error)
+	Delete(id int64) error
+}
+
+type OrderRepository interface {
+	// Checkout turns a cart into a pending order and reserves stock for every
+	// item in the same transaction, so either the whole order is placed or
+	// nothing changes. A short item yields an *InsufficientStockError.
+	Checkout(c Checkout, currency string) (*Order, error)
+	Get(id int64) (*Order, error)
+	List(q OrderQuery) ([]Order, int64, error)
+	// Transition returns ErrConflict when the order is no longer in t.From.
+	// Cancelling, or refunding before shipping, releases the reserved stock.
+	Transition(id int64, t OrderTransition) (*Order, error)
+	Events(id int64) ([]OrderEvent, error)
+}
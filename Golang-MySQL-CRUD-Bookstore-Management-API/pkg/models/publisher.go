@@ -0,0 +1,31 @@
+package models
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/yoloxsta/go-bookstore/pkg/validation"
+)
+
+type Publisher struct {
+	gorm.Model
+	Name string `json:"name"`
+	// NameKey is NameKey(Name); it lets free-text credits find the publisher.
+	NameKey string `json:"-" gorm:"index"`
+}
+
+func (p *Publisher) Validate() error {
+	var errs validation.Errors
+	if errs.Required("name", p.Name) {
+		errs.MaxLength("name", p.Name, MaxPublicationLength)
+	}
+	return errs.Err()
+}
+
+type PublisherRepository interface {
+	Create(p *Publisher) error
+	List(q NameQuery) ([]Publisher, int64, error)
+	Get(id int64) (*Publisher, error)
+	GetMany(ids []uint) ([]Publisher, error)
+	Update(p *Publisher) error
+	// Delete returns ErrInUse while any book still names the publisher.
+	Delete(id int64) error
+}
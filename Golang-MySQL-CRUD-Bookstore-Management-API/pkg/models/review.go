@@ -0,0 +1,121 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/yoloxsta/go-bookstore/pkg/validation"
+)
+
+// ErrDuplicateReview means the reviewer has already reviewed the book.
+var ErrDuplicateReview = errors.New("models: book already reviewed by this reviewer")
+
+// Review statuses. Only approved reviews are shown to every reader and count
+// toward the book's rating.
+const (
+	ReviewPending  = "pending"
+	ReviewApproved = "approved"
+	ReviewRejected = "rejected"
+)
+
+// IsReviewStatus reports whether s is one of the review statuses.
+func IsReviewStatus(s string) bool {
+	return s == ReviewPending || s == ReviewApproved || s == ReviewRejected
+}
+
+type Review struct {
+	ID     uint `json:"id" gorm:"primary_key"`
+	BookID uint `json:"book_id" gorm:"not null"`
+	// Reviewer is the subject of the token the review was posted with. Each
+	// reviewer may review a book once.
+	Reviewer  string    `json:"reviewer" gorm:"not null"`
+	Rating    int       `json:"rating" gorm:"not null"`
+	Title     string    `json:"title"`
+	Body      string    `json:"body"`
+	Status    string    `json:"status" gorm:"not null"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (Review) TableName() string { return "book_reviews" }
+
+const (
+	MinRating            = 1
+	MaxRating            = 5
+	MaxReviewTitleLength = 255
+	MaxReviewBodyLength  = 10000
+)
+
+func (r *Review) Validate() error {
+	var errs validation.Errors
+	if r.Rating < MinRating || r.Rating > MaxRating {
+		errs.Add("rating", "must be between %d and %d", MinRating, MaxRating)
+	}
+	errs.MaxLength("title", r.Title, MaxReviewTitleLength)
+	errs.MaxLength("body", r.Body, MaxReviewBodyLength)
+	return errs.Err()
+}
+
+// ReviewQuery pages through a book's reviews, newest first. An empty Status
+// lists every review.
+type ReviewQuery struct {
+	BookID uint
+	Status string
+	Limit  int
+	Offset int
+}
+
+// ReviewPage is one page of a book's reviews and how many match in all.
+type ReviewPage struct {
+	Reviews []Review
+	Total   int64
+}
+
+func (q ReviewQuery) normalize() ReviewQuery {
+	q.Limit = BookQuery{Limit: q.Limit}.PageSize()
+	if q.Offset < 0 {
+		q.Offset = 0
+	}
+	return q
+}
+
+// averageRating rounds the mean of ratings to two decimal places.
+func averageRating(sum, count int) float64 {
+	if count == 0 {
+		return 0
+	}
+	return math.Round(float64(sum)/float64(count)*100) / 100
+}
+
+// ReviewRepository stores reviews. Every change that alters the approved
+// reviews of a book updates its RatingAverage and RatingCount, bumps its
+// version and records the change in the book's audit log, in the same
+// transaction.
+type ReviewRepository interface {
+	// Create adds a review to a live book, or returns ErrNotFound.
+	// ErrDuplicateReview means r.Reviewer has already reviewed the book.
+	Create(r *Review) error
+	// Get returns a review of a live book.
+	Get(bookID, id int64) (*Review, error)
+	List(q ReviewQuery) ([]Review, int64, error)
+	// ListMany pages through the reviews of each of bookIDs as List does,
+	// ignoring q.BookID, without a query per book. Books without matching
+	// reviews, and books that are not live, are left out.
+	ListMany(bookIDs []uint, q ReviewQuery) (map[uint]ReviewPage, error)
+	// Moderate moves a review to status.
+	Moderate(bookID, id int64, status string) (*Review, error)
+	// WithAudit returns a repository that records info with the rating
+	// changes it writes.
+	WithAudit(info AuditInfo) ReviewRepository
+}
+
+// ratingAudit is info for the rating change caused by rv, noting the review
+// unless the caller gave a note of its own.
+func ratingAudit(info AuditInfo, rv *Review) AuditInfo {
+	if info.Note == "" {
+		info.Note = fmt.Sprintf("rating after review %d was %s", rv.ID, rv.Status)
+	}
+	return info
+}
@@ -0,0 +1,218 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/jinzhu/gorm"
+	"github.com/yoloxsta/go-bookstore/pkg/config"
+	"github.com/yoloxsta/go-bookstore/pkg/migrate"
+)
+
+// newSQLiteStore is a gorm store on a migrated in-memory SQLite database.
+func newSQLiteStore(t *testing.T) (*Store, *gorm.DB) {
+	t.Helper()
+	cfg := config.Default()
+	cfg.Database.Driver, cfg.Database.Path = config.DriverSQLite, ":memory:"
+	db, err := config.Connect(cfg.Database)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	m, err := migrate.New(db, migrate.All)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Up(); err != nil {
+		t.Fatal(err)
+	}
+	store, err := NewGormStore(cfg.Inventory, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store, db
+}
+
+func testStores(t *testing.T) map[string]*Store {
+	sqlite, _ := newSQLiteStore(t)
+	return map[string]*Store{
+		"memory": NewMemoryStore(config.Default().Inventory),
+		"sqlite": sqlite,
+	}
+}
+
+func TestRatingChangesAreAudited(t *testing.T) {
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			book := &Book{Name: "Dune", Author: "Herbert"}
+			if err := store.Books.Create(book); err != nil {
+				t.Fatal(err)
+			}
+			reviews := store.Reviews.WithAudit(AuditInfo{Actor: "ana", RequestID: "req-1"})
+			rv := &Review{BookID: book.ID, Reviewer: "ana", Rating: 4, Status: ReviewApproved}
+			if err := reviews.Create(rv); err != nil {
+				t.Fatal(err)
+			}
+			pending := &Review{BookID: book.ID, Reviewer: "bob", Rating: 2, Status: ReviewPending}
+			if err := reviews.Create(pending); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := store.Reviews.WithAudit(AuditInfo{Actor: "ed"}).Moderate(int64(book.ID), int64(pending.ID), ReviewApproved); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := store.Books.Get(int64(book.ID))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Version != 3 || got.RatingCount != 2 || got.RatingAverage != 3 {
+				t.Fatalf("book version %d, rating %v from %d reviews; want 3, 3 from 2", got.Version, got.RatingAverage, got.RatingCount)
+			}
+			history, total, err := store.Audit.History(int64(book.ID), AuditQuery{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if total != 3 {
+				t.Fatalf("%d audit entries, want one per version", total)
+			}
+			for i, want := range []struct {
+				revision uint
+				actor    string
+			}{{3, "ed"}, {2, "ana"}, {1, AuditSystem}} {
+				if e := history[i]; e.Revision != want.revision || e.Actor != want.actor {
+					t.Errorf("entry %d: revision %d by %q, want %d by %q", i, e.Revision, e.Actor, want.revision, want.actor)
+				}
+			}
+			if e := history[1]; e.Action != AuditUpdate || e.RequestID != "req-1" || e.Note == "" || e.Changes == "{}" {
+				t.Errorf("rating entry = %+v", e)
+			}
+
+			// Rejecting a pending review leaves the rating, and the version,
+			// alone.
+			third := &Review{BookID: book.ID, Reviewer: "cy", Rating: 1, Status: ReviewPending}
+			if err := reviews.Create(third); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := reviews.Moderate(int64(book.ID), int64(third.ID), ReviewRejected); err != nil {
+				t.Fatal(err)
+			}
+			if _, total, _ := store.Audit.History(int64(book.ID), AuditQuery{}); total != 3 {
+				t.Errorf("%d audit entries after a rejection, want 3", total)
+			}
+		})
+	}
+}
+
+func TestDuplicateReview(t *testing.T) {
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			book := &Book{Name: "Dune", Author: "Herbert"}
+			if err := store.Books.Create(book); err != nil {
+				t.Fatal(err)
+			}
+			if err := store.Reviews.Create(&Review{BookID: book.ID, Reviewer: "ana", Rating: 5, Status: ReviewPending}); err != nil {
+				t.Fatal(err)
+			}
+			err := store.Reviews.Create(&Review{BookID: book.ID, Reviewer: "ana", Rating: 1, Status: ReviewPending})
+			if !errors.Is(err, ErrDuplicateReview) {
+				t.Fatalf("second review = %v, want ErrDuplicateReview", err)
+			}
+		})
+	}
+}
+
+// TestConcurrentDuplicateReview has a second review by the same reviewer
+// land between the duplicate check and the insert; the unique index still
+// turns it into ErrDuplicateReview.
+func TestConcurrentDuplicateReview(t *testing.T) {
+	store, db := newSQLiteStore(t)
+	book := &Book{Name: "Dune", Author: "Herbert"}
+	if err := store.Books.Create(book); err != nil {
+		t.Fatal(err)
+	}
+	raced := false
+	db.Callback().Create().Before("gorm:create").Register("test:race", func(scope *gorm.Scope) {
+		if raced || scope.TableName() != "book_reviews" {
+			return
+		}
+		raced = true
+		err := scope.NewDB().Exec("INSERT INTO book_reviews (book_id, reviewer, rating, status) VALUES (?, ?, 5, ?)",
+			book.ID, "ana", ReviewPending).Error
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	err := store.Reviews.Create(&Review{BookID: book.ID, Reviewer: "ana", Rating: 1, Status: ReviewApproved})
+	if !raced || !errors.Is(err, ErrDuplicateReview) {
+		t.Fatalf("racing review = %v, want ErrDuplicateReview", err)
+	}
+	if got, _ := store.Books.Get(int64(book.ID)); got.Version != 1 {
+		t.Errorf("book version = %d after a refused review, want 1", got.Version)
+	}
+}
+
+func TestListManyReviews(t *testing.T) {
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			var ids []uint
+			for i := 0; i < 4; i++ {
+				b := &Book{Name: "Dune", Author: "Herbert"}
+				if err := store.Books.Create(b); err != nil {
+					t.Fatal(err)
+				}
+				ids = append(ids, b.ID)
+			}
+			// Book 1 has three approved reviews and a pending one, book 2 one
+			// rejected review, book 3 is trashed and book 4 has none.
+			for i, rv := range []Review{
+				{BookID: ids[0], Reviewer: "a", Status: ReviewApproved},
+				{BookID: ids[0], Reviewer: "b", Status: ReviewApproved},
+				{BookID: ids[0], Reviewer: "c", Status: ReviewPending},
+				{BookID: ids[0], Reviewer: "d", Status: ReviewApproved},
+				{BookID: ids[1], Reviewer: "a", Status: ReviewRejected},
+				{BookID: ids[2], Reviewer: "a", Status: ReviewApproved},
+			} {
+				rv.Rating = i%5 + 1
+				if err := store.Reviews.Create(&rv); err != nil {
+					t.Fatal(err)
+				}
+			}
+			trashed, _ := store.Books.Get(int64(ids[2]))
+			if err := store.Books.Delete(trashed); err != nil {
+				t.Fatal(err)
+			}
+
+			reviewers := func(p ReviewPage) string {
+				var names []string
+				for _, rv := range p.Reviews {
+					names = append(names, rv.Reviewer)
+				}
+				return fmt.Sprintf("%d:%s", p.Total, strings.Join(names, ","))
+			}
+			for _, tc := range []struct {
+				q    ReviewQuery
+				want map[uint]string
+			}{
+				{ReviewQuery{Status: ReviewApproved, Limit: 2}, map[uint]string{ids[0]: "3:d,b"}},
+				{ReviewQuery{Status: ReviewApproved, Limit: 2, Offset: 2}, map[uint]string{ids[0]: "3:a"}},
+				{ReviewQuery{Status: ReviewApproved, Offset: 5}, map[uint]string{ids[0]: "3:"}},
+				{ReviewQuery{}, map[uint]string{ids[0]: "4:d,c,b,a", ids[1]: "1:a"}},
+			} {
+				pages, err := store.Reviews.ListMany(ids, tc.q)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got := map[uint]string{}
+				for id, p := range pages {
+					got[id] = reviewers(p)
+				}
+				if fmt.Sprint(got) != fmt.Sprint(tc.want) {
+					t.Errorf("%+v: pages %v, want %v", tc.q, got, tc.want)
+				}
+			}
+		})
+	}
+}
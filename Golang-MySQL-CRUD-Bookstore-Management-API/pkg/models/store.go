@@ -0,0 +1,117 @@
+package models
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/jinzhu/gorm"
+	"github.com/yoloxsta/go-bookstore/pkg/config"
+	"github.com/yoloxsta/go-bookstore/pkg/search"
+)
+
+// Store bundles the repositories of one storage engine.
+type Store struct {
+	Books      BookRepository
+	Authors    AuthorRepository
+	Publishers PublisherRepository
+	Inventory  InventoryRepository
+	Carts      CartRepository
+	Orders     OrderRepository
+	Covers     CoverRepository
+	Audit      AuditRepository
+	Reviews    ReviewRepository
+}
+
+// NewStore returns the repositories for cfg.Database.Driver. db is the
+// connection from config.Connect and is ignored by the memory driver.
+func NewStore(cfg config.Config, db *gorm.DB) (*Store, error) {
+	switch cfg.Database.Driver {
+	case config.DriverMemory:
+		return NewMemoryStore(cfg.Inventory), nil
+	case config.DriverMySQL, config.DriverSQLite:
+		return NewGormStore(cfg.Inventory, db)
+	}
+	return nil, errors.New("models: unsupported driver " + cfg.Database.Driver)
+}
+
+// NewGormStore expects a schema brought up to date by pkg/migrate.
+func NewGormStore(inventory config.InventoryConfig, db *gorm.DB) (*Store, error) {
+	if db == nil {
+		return nil, errors.New("models: nil database")
+	}
+	books, err := newGormBookRepository(db)
+	if err != nil {
+		return nil, err
+	}
+	return &Store{
+		Books:      books,
+		Authors:    &gormAuthorRepository{db: db},
+		Publishers: &gormPublisherRepository{db: db},
+		Inventory:  &gormInventoryRepository{db: db, defaultThreshold: inventory.LowStockThreshold},
+		Carts:      &gormCartRepository{db: db},
+		Orders:     &gormOrderRepository{db: db},
+		Covers:     &gormCoverRepository{db: db},
+		Audit:      &gormAuditRepository{db: db},
+		Reviews:    &gormReviewRepository{db: db},
+	}, nil
+}
+
+// memoryDB is the shared state behind the memory repositories, so that links
+// between books, authors and publishers stay consistent under one lock.
+type memoryDB struct {
+	mu         sync.RWMutex
+	nextIDs    map[string]uint
+	books      map[uint]Book
+	bookIndex  *search.Index
+	authors    map[uint]Author
+	publishers map[uint]Publisher
+	// bookAuthors holds each book's author IDs in credit order.
+	bookAuthors map[uint][]uint
+	stock       map[uint]StockLevel
+	// movements holds each book's ledger, oldest first.
+	movements map[uint][]StockMovement
+	carts     map[uint]Cart
+	orders    map[uint]Order
+	// orderEvents holds each order's status history, oldest first.
+	orderEvents map[uint][]OrderEvent
+	covers      map[uint]Cover
+	// audits is the book audit log, oldest first.
+	audits  []BookAudit
+	reviews map[uint]Review
+}
+
+func (db *memoryDB) nextID(table string) uint {
+	db.nextIDs[table]++
+	return db.nextIDs[table]
+}
+
+// NewMemoryStore keeps everything in maps. It mirrors gorm's soft-delete
+// behaviour so handlers act the same whichever engine is selected.
+func NewMemoryStore(inventory config.InventoryConfig) *Store {
+	db := &memoryDB{
+		nextIDs:     make(map[string]uint),
+		books:       make(map[uint]Book),
+		bookIndex:   newBookIndex(),
+		authors:     make(map[uint]Author),
+		publishers:  make(map[uint]Publisher),
+		bookAuthors: make(map[uint][]uint),
+		stock:       make(map[uint]StockLevel),
+		movements:   make(map[uint][]StockMovement),
+		carts:       make(map[uint]Cart),
+		orders:      make(map[uint]Order),
+		orderEvents: make(map[uint][]OrderEvent),
+		covers:      make(map[uint]Cover),
+		reviews:     make(map[uint]Review),
+	}
+	return &Store{
+		Books:      &memoryBookRepository{memoryDB: db},
+		Authors:    &memoryAuthorRepository{db},
+		Publishers: &memoryPublisherRepository{db},
+		Inventory:  &memoryInventoryRepository{db, inventory.LowStockThreshold},
+		Carts:      &memoryCartRepository{db},
+		Orders:     &memoryOrderRepository{db},
+		Covers:     &memoryCoverRepository{db},
+		Audit:      &memoryAuditRepository{db},
+		Reviews:    &memoryReviewRepository{memoryDB: db},
+	}
+}
@@ -0,0 +1,57 @@
+// Package openapi serves the OpenAPI 3.1 description of the REST API and a
+// Swagger UI page to browse it.
+package openapi
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+// Paths of the document and the UI. They are served ahead of the API
+// router, outside authentication, like the health endpoints.
+const (
+	SpecPath = "/openapi.json"
+	UIPath   = "/docs"
+)
+
+// Spec is the OpenAPI document. It is written by hand: a route added to
+// pkg/routes must be described here too, or the router test fails.
+//
+//go:embed openapi.json
+var Spec []byte
+
+// Handler serves Spec.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(Spec)
+	})
+}
+
+// UIHandler serves a Swagger UI page for Spec. The page loads Swagger UI
+// from the unpkg CDN, so the browser needs internet access.
+func UIHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(uiPage))
+	})
+}
+
+const uiPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>Bookstore API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js" crossorigin></script>
+  <script>
+    window.onload = () => {
+      window.ui = SwaggerUIBundle({url: "` + SpecPath + `", dom_id: "#swagger-ui"});
+    };
+  </script>
+</body>
+</html>
+`
@@ -0,0 +1,153 @@
+package patch
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// jsonEqual compares two JSON documents regardless of member order.
+func jsonEqual(t *testing.T, got []byte, want string) bool {
+	t.Helper()
+	var g, w interface{}
+	if err := json.Unmarshal(got, &g); err != nil {
+		t.Fatalf("result %s: %v", got, err)
+	}
+	if err := json.Unmarshal([]byte(want), &w); err != nil {
+		t.Fatalf("want %s: %v", want, err)
+	}
+	return reflect.DeepEqual(g, w)
+}
+
+func TestJSONPatch(t *testing.T) {
+	tests := []struct {
+		name  string
+		doc   string
+		patch string
+		want  string
+		err   error
+	}{
+		// RFC 6902, Appendix A.
+		{"A.1 add object member", `{"foo":"bar"}`,
+			`[{"op":"add","path":"/baz","value":"qux"}]`,
+			`{"baz":"qux","foo":"bar"}`, nil},
+		{"A.2 add array element", `{"foo":["bar","baz"]}`,
+			`[{"op":"add","path":"/foo/1","value":"qux"}]`,
+			`{"foo":["bar","qux","baz"]}`, nil},
+		{"A.3 remove object member", `{"baz":"qux","foo":"bar"}`,
+			`[{"op":"remove","path":"/baz"}]`,
+			`{"foo":"bar"}`, nil},
+		{"A.4 remove array element", `{"foo":["bar","qux","baz"]}`,
+			`[{"op":"remove","path":"/foo/1"}]`,
+			`{"foo":["bar","baz"]}`, nil},
+		{"A.5 replace", `{"baz":"qux","foo":"bar"}`,
+			`[{"op":"replace","path":"/baz","value":"boo"}]`,
+			`{"baz":"boo","foo":"bar"}`, nil},
+		{"A.6 move value", `{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`,
+			`[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`,
+			`{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`, nil},
+		{"A.7 move array element", `{"foo":["all","grass","cows","eat"]}`,
+			`[{"op":"move","from":"/foo/1","path":"/foo/3"}]`,
+			`{"foo":["all","cows","eat","grass"]}`, nil},
+		{"A.8 test success", `{"baz":"qux","foo":["a",2,"c"]}`,
+			`[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2}]`,
+			`{"baz":"qux","foo":["a",2,"c"]}`, nil},
+		{"A.9 test failure", `{"baz":"qux"}`,
+			`[{"op":"test","path":"/baz","value":"bar"}]`,
+			"", ErrTestFailed},
+		{"A.10 add nested member", `{"foo":"bar"}`,
+			`[{"op":"add","path":"/child","value":{"grandchild":{}}}]`,
+			`{"foo":"bar","child":{"grandchild":{}}}`, nil},
+		{"A.11 ignore unknown members", `{"foo":"bar"}`,
+			`[{"op":"add","path":"/baz","value":"qux","xyz":123}]`,
+			`{"foo":"bar","baz":"qux"}`, nil},
+		{"A.12 add to nonexistent target", `{"foo":"bar"}`,
+			`[{"op":"add","path":"/baz/bat","value":"qux"}]`,
+			"", ErrInvalidPath},
+		{"A.14 ~ escape ordering", `{"/":9,"~1":10}`,
+			`[{"op":"test","path":"/~01","value":10}]`,
+			`{"/":9,"~1":10}`, nil},
+		{"A.15 string is not a number", `{"/":9,"~1":10}`,
+			`[{"op":"test","path":"/~01","value":"10"}]`,
+			"", ErrTestFailed},
+		{"A.16 add array value", `{"foo":["bar"]}`,
+			`[{"op":"add","path":"/foo/-","value":["abc","def"]}]`,
+			`{"foo":["bar",["abc","def"]]}`, nil},
+
+		{"~1 addresses a slash", `{"a/b":1}`,
+			`[{"op":"replace","path":"/a~1b","value":2}]`,
+			`{"a/b":2}`, nil},
+		{"~0 addresses a tilde", `{"m~n":1}`,
+			`[{"op":"remove","path":"/m~0n"}]`,
+			`{}`, nil},
+		{"- appends", `{"tags":[]}`,
+			`[{"op":"add","path":"/tags/-","value":"a"},{"op":"add","path":"/tags/-","value":"b"}]`,
+			`{"tags":["a","b"]}`, nil},
+		{"- only names the end for add", `{"tags":["a"]}`,
+			`[{"op":"remove","path":"/tags/-"}]`,
+			"", ErrInvalidPath},
+		{"add at the array length appends", `{"tags":["a"]}`,
+			`[{"op":"add","path":"/tags/1","value":"b"}]`,
+			`{"tags":["a","b"]}`, nil},
+		{"add past the array length", `{"tags":["a"]}`,
+			`[{"op":"add","path":"/tags/2","value":"b"}]`,
+			"", ErrInvalidPath},
+		{"leading zero index", `{"tags":["a","b"]}`,
+			`[{"op":"remove","path":"/tags/01"}]`,
+			"", ErrInvalidPath},
+		{"copy", `{"a":{"b":[1]}}`,
+			`[{"op":"copy","from":"/a","path":"/c"},{"op":"add","path":"/c/b/-","value":2}]`,
+			`{"a":{"b":[1]},"c":{"b":[1,2]}}`, nil},
+		{"copy array element", `{"a":[1,2]}`,
+			`[{"op":"copy","from":"/a/0","path":"/a/-"}]`,
+			`{"a":[1,2,1]}`, nil},
+		{"move into itself", `{"a":{"b":{}}}`,
+			`[{"op":"move","from":"/a","path":"/a/b/c"}]`,
+			"", ErrInvalidPath},
+		{"move from a missing member", `{"a":1}`,
+			`[{"op":"move","from":"/b","path":"/c"}]`,
+			"", ErrInvalidPath},
+		{"replace a missing member", `{"a":1}`,
+			`[{"op":"replace","path":"/b","value":2}]`,
+			"", ErrInvalidPath},
+		{"replace the root", `{"a":1}`,
+			`[{"op":"replace","path":"","value":[1]}]`,
+			`[1]`, nil},
+		{"test a whole object", `{"a":{"b":1,"c":[true,null]}}`,
+			`[{"op":"test","path":"/a","value":{"c":[true,null],"b":1}}]`,
+			`{"a":{"b":1,"c":[true,null]}}`, nil},
+		{"a failed test applies nothing", `{"a":1}`,
+			`[{"op":"replace","path":"/a","value":2},{"op":"test","path":"/a","value":1}]`,
+			"", ErrTestFailed},
+		{"missing value", `{}`,
+			`[{"op":"add","path":"/a"}]`,
+			"", ErrMalformed},
+		{"unknown op", `{}`,
+			`[{"op":"frob","path":"/a"}]`,
+			"", ErrMalformed},
+		{"pointer without a slash", `{"a":1}`,
+			`[{"op":"remove","path":"a"}]`,
+			"", ErrMalformed},
+		{"not an array", `{"a":1}`,
+			`{"op":"remove","path":"/a"}`,
+			"", ErrMalformed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := JSONPatch([]byte(tt.doc), []byte(tt.patch))
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) || got != nil {
+					t.Fatalf("got %s, %v; want error %v", got, err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !jsonEqual(t, got, tt.want) {
+				t.Fatalf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package payment
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Test tokens understood by the fake provider. Any other non-empty token is
+// charged successfully.
+const (
+	TokenDecline = "tok_decline"
+)
+
+// Fake is an in-process provider for development and tests. It never talks to
+// the network and forgets everything on restart.
+type Fake struct {
+	mu       sync.Mutex
+	next     int
+	byKey    map[string]Charge
+	charges  map[string]Charge
+	refunded map[string]bool
+}
+
+func NewFake() *Fake {
+	return &Fake{
+		byKey:    make(map[string]Charge),
+		charges:  make(map[string]Charge),
+		refunded: make(map[string]bool),
+	}
+}
+
+func (f *Fake) Charge(ctx context.Context, req ChargeRequest) (Charge, error) {
+	if err := ctx.Err(); err != nil {
+		return Charge{}, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if c, ok := f.byKey[req.IdempotencyKey]; ok && req.IdempotencyKey != "" {
+		return c, nil
+	}
+	if req.Token == "" || req.Token == TokenDecline {
+		return Charge{}, ErrDeclined
+	}
+	f.next++
+	c := Charge{ID: fmt.Sprintf("fake_ch_%d", f.next), AmountCents: req.AmountCents, Currency: req.Currency}
+	f.charges[c.ID] = c
+	if req.IdempotencyKey != "" {
+		f.byKey[req.IdempotencyKey] = c
+	}
+	return c, nil
+}
+
+func (f *Fake) Refund(ctx context.Context, chargeID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.charges[chargeID]; !ok {
+		return ErrUnknownCharge
+	}
+	f.refunded[chargeID] = true
+	return nil
+}
+
+// Refunded reports whether chargeID has been refunded.
+func (f *Fake) Refunded(chargeID string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.refunded[chargeID]
+}
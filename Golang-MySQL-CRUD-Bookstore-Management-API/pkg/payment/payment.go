@@ -0,0 +1,55 @@
+// Package payment charges customers for orders through a pluggable Provider.
+package payment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrDeclined means the provider refused the charge; the customer may
+	// retry with another payment method.
+	ErrDeclined = errors.New("payment: declined")
+	// ErrUnknownCharge means a refund named a charge the provider never made.
+	ErrUnknownCharge = errors.New("payment: unknown charge")
+)
+
+type ChargeRequest struct {
+	AmountCents int64
+	Currency    string
+	// Token identifies the payment method, as issued by the provider's
+	// client-side library.
+	Token string
+	// IdempotencyKey makes retries safe: a second charge with the same key
+	// returns the first result instead of charging again.
+	IdempotencyKey string
+}
+
+type Charge struct {
+	ID          string
+	AmountCents int64
+	Currency    string
+}
+
+// Provider is implemented once per payment service.
+type Provider interface {
+	Charge(ctx context.Context, req ChargeRequest) (Charge, error)
+	// Refund returns the full amount of a charge. Refunding the same charge
+	// twice is not an error.
+	Refund(ctx context.Context, chargeID string) error
+}
+
+// Supported provider names.
+const (
+	ProviderFake = "fake"
+)
+
+// New returns the provider called name.
+func New(name string) (Provider, error) {
+	switch name {
+	case ProviderFake:
+		return NewFake(), nil
+	}
+	return nil, fmt.Errorf("payment: unknown provider %q", name)
+}
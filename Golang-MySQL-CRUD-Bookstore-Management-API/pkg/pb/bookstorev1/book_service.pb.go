@@ -0,0 +1,941 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: bookstore/v1/book_service.proto
+
+package bookstorev1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type BookEvent_Action int32
+
+const (
+	BookEvent_ACTION_UNSPECIFIED BookEvent_Action = 0
+	BookEvent_ACTION_CREATE      BookEvent_Action = 1
+	BookEvent_ACTION_UPDATE      BookEvent_Action = 2
+	BookEvent_ACTION_DELETE      BookEvent_Action = 3
+	BookEvent_ACTION_RESTORE     BookEvent_Action = 4
+	BookEvent_ACTION_PURGE       BookEvent_Action = 5
+)
+
+// Enum value maps for BookEvent_Action.
+var (
+	BookEvent_Action_name = map[int32]string{
+		0: "ACTION_UNSPECIFIED",
+		1: "ACTION_CREATE",
+		2: "ACTION_UPDATE",
+		3: "ACTION_DELETE",
+		4: "ACTION_RESTORE",
+		5: "ACTION_PURGE",
+	}
+	BookEvent_Action_value = map[string]int32{
+		"ACTION_UNSPECIFIED": 0,
+		"ACTION_CREATE":      1,
+		"ACTION_UPDATE":      2,
+		"ACTION_DELETE":      3,
+		"ACTION_RESTORE":     4,
+		"ACTION_PURGE":       5,
+	}
+)
+
+func (x BookEvent_Action) Enum() *BookEvent_Action {
+	p := new(BookEvent_Action)
+	*p = x
+	return p
+}
+
+func (x BookEvent_Action) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (BookEvent_Action) Descriptor() protoreflect.EnumDescriptor {
+	return file_bookstore_v1_book_service_proto_enumTypes[0].Descriptor()
+}
+
+func (BookEvent_Action) Type() protoreflect.EnumType {
+	return &file_bookstore_v1_book_service_proto_enumTypes[0]
+}
+
+func (x BookEvent_Action) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use BookEvent_Action.Descriptor instead.
+func (BookEvent_Action) EnumDescriptor() ([]byte, []int) {
+	return file_bookstore_v1_book_service_proto_rawDescGZIP(), []int{8, 0}
+}
+
+type Book struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Id          uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name        string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Author      string                 `protobuf:"bytes,3,opt,name=author,proto3" json:"author,omitempty"`
+	Publication string                 `protobuf:"bytes,4,opt,name=publication,proto3" json:"publication,omitempty"`
+	Version     uint32                 `protobuf:"varint,5,opt,name=version,proto3" json:"version,omitempty"`
+	Sku         *string                `protobuf:"bytes,6,opt,name=sku,proto3,oneof" json:"sku,omitempty"`
+	Isbn13      *string                `protobuf:"bytes,7,opt,name=isbn13,proto3,oneof" json:"isbn13,omitempty"`
+	Isbn10      *string                `protobuf:"bytes,8,opt,name=isbn10,proto3,oneof" json:"isbn10,omitempty"`
+	PriceCents  int64                  `protobuf:"varint,9,opt,name=price_cents,json=priceCents,proto3" json:"price_cents,omitempty"`
+	PublisherId *uint64                `protobuf:"varint,10,opt,name=publisher_id,json=publisherId,proto3,oneof" json:"publisher_id,omitempty"`
+	AuthorIds   []uint64               `protobuf:"varint,11,rep,packed,name=author_ids,json=authorIds,proto3" json:"author_ids,omitempty"`
+	CreateTime  *timestamppb.Timestamp `protobuf:"bytes,12,opt,name=create_time,json=createTime,proto3" json:"create_time,omitempty"`
+	UpdateTime  *timestamppb.Timestamp `protobuf:"bytes,13,opt,name=update_time,json=updateTime,proto3" json:"update_time,omitempty"`
+	// delete_time is set while the book is in the trash.
+	DeleteTime *timestamppb.Timestamp `protobuf:"bytes,14,opt,name=delete_time,json=deleteTime,proto3" json:"delete_time,omitempty"`
+	// rating_average and rating_count summarize the approved reviews; they
+	// are ignored on write.
+	RatingAverage float64 `protobuf:"fixed64,15,opt,name=rating_average,json=ratingAverage,proto3" json:"rating_average,omitempty"`
+	RatingCount   uint32  `protobuf:"varint,16,opt,name=rating_count,json=ratingCount,proto3" json:"rating_count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Book) Reset() {
+	*x = Book{}
+	mi := &file_bookstore_v1_book_service_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Book) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Book) ProtoMessage() {}
+
+func (x *Book) ProtoReflect() protoreflect.Message {
+	mi := &file_bookstore_v1_book_service_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Book.ProtoReflect.Descriptor instead.
+func (*Book) Descriptor() ([]byte, []int) {
+	return file_bookstore_v1_book_service_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Book) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Book) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Book) GetAuthor() string {
+	if x != nil {
+		return x.Author
+	}
+	return ""
+}
+
+func (x *Book) GetPublication() string {
+	if x != nil {
+		return x.Publication
+	}
+	return ""
+}
+
+func (x *Book) GetVersion() uint32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *Book) GetSku() string {
+	if x != nil && x.Sku != nil {
+		return *x.Sku
+	}
+	return ""
+}
+
+func (x *Book) GetIsbn13() string {
+	if x != nil && x.Isbn13 != nil {
+		return *x.Isbn13
+	}
+	return ""
+}
+
+func (x *Book) GetIsbn10() string {
+	if x != nil && x.Isbn10 != nil {
+		return *x.Isbn10
+	}
+	return ""
+}
+
+func (x *Book) GetPriceCents() int64 {
+	if x != nil {
+		return x.PriceCents
+	}
+	return 0
+}
+
+func (x *Book) GetPublisherId() uint64 {
+	if x != nil && x.PublisherId != nil {
+		return *x.PublisherId
+	}
+	return 0
+}
+
+func (x *Book) GetAuthorIds() []uint64 {
+	if x != nil {
+		return x.AuthorIds
+	}
+	return nil
+}
+
+func (x *Book) GetCreateTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreateTime
+	}
+	return nil
+}
+
+func (x *Book) GetUpdateTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdateTime
+	}
+	return nil
+}
+
+func (x *Book) GetDeleteTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.DeleteTime
+	}
+	return nil
+}
+
+func (x *Book) GetRatingAverage() float64 {
+	if x != nil {
+		return x.RatingAverage
+	}
+	return 0
+}
+
+func (x *Book) GetRatingCount() uint32 {
+	if x != nil {
+		return x.RatingCount
+	}
+	return 0
+}
+
+type GetBookRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetBookRequest) Reset() {
+	*x = GetBookRequest{}
+	mi := &file_bookstore_v1_book_service_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetBookRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetBookRequest) ProtoMessage() {}
+
+func (x *GetBookRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bookstore_v1_book_service_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetBookRequest.ProtoReflect.Descriptor instead.
+func (*GetBookRequest) Descriptor() ([]byte, []int) {
+	return file_bookstore_v1_book_service_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *GetBookRequest) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type ListBooksRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// page_size defaults to 20 and is capped at 100.
+	PageSize int32 `protobuf:"varint,1,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	// page_token is the next_page_token of the previous page.
+	PageToken string `protobuf:"bytes,2,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	// order_by is a sort spec such as "-created_at,name".
+	OrderBy       string `protobuf:"bytes,3,opt,name=order_by,json=orderBy,proto3" json:"order_by,omitempty"`
+	Name          string `protobuf:"bytes,4,opt,name=name,proto3" json:"name,omitempty"`
+	Author        string `protobuf:"bytes,5,opt,name=author,proto3" json:"author,omitempty"`
+	Publication   string `protobuf:"bytes,6,opt,name=publication,proto3" json:"publication,omitempty"`
+	AuthorId      uint64 `protobuf:"varint,7,opt,name=author_id,json=authorId,proto3" json:"author_id,omitempty"`
+	PublisherId   uint64 `protobuf:"varint,8,opt,name=publisher_id,json=publisherId,proto3" json:"publisher_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListBooksRequest) Reset() {
+	*x = ListBooksRequest{}
+	mi := &file_bookstore_v1_book_service_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListBooksRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListBooksRequest) ProtoMessage() {}
+
+func (x *ListBooksRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bookstore_v1_book_service_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListBooksRequest.ProtoReflect.Descriptor instead.
+func (*ListBooksRequest) Descriptor() ([]byte, []int) {
+	return file_bookstore_v1_book_service_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ListBooksRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListBooksRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+func (x *ListBooksRequest) GetOrderBy() string {
+	if x != nil {
+		return x.OrderBy
+	}
+	return ""
+}
+
+func (x *ListBooksRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ListBooksRequest) GetAuthor() string {
+	if x != nil {
+		return x.Author
+	}
+	return ""
+}
+
+func (x *ListBooksRequest) GetPublication() string {
+	if x != nil {
+		return x.Publication
+	}
+	return ""
+}
+
+func (x *ListBooksRequest) GetAuthorId() uint64 {
+	if x != nil {
+		return x.AuthorId
+	}
+	return 0
+}
+
+func (x *ListBooksRequest) GetPublisherId() uint64 {
+	if x != nil {
+		return x.PublisherId
+	}
+	return 0
+}
+
+type ListBooksResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Books []*Book                `protobuf:"bytes,1,rep,name=books,proto3" json:"books,omitempty"`
+	// next_page_token is empty on the last page.
+	NextPageToken string `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+	TotalSize     int64  `protobuf:"varint,3,opt,name=total_size,json=totalSize,proto3" json:"total_size,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListBooksResponse) Reset() {
+	*x = ListBooksResponse{}
+	mi := &file_bookstore_v1_book_service_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListBooksResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListBooksResponse) ProtoMessage() {}
+
+func (x *ListBooksResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_bookstore_v1_book_service_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListBooksResponse.ProtoReflect.Descriptor instead.
+func (*ListBooksResponse) Descriptor() ([]byte, []int) {
+	return file_bookstore_v1_book_service_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ListBooksResponse) GetBooks() []*Book {
+	if x != nil {
+		return x.Books
+	}
+	return nil
+}
+
+func (x *ListBooksResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+func (x *ListBooksResponse) GetTotalSize() int64 {
+	if x != nil {
+		return x.TotalSize
+	}
+	return 0
+}
+
+type CreateBookRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// book's id, version and timestamps are ignored.
+	Book          *Book `protobuf:"bytes,1,opt,name=book,proto3" json:"book,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateBookRequest) Reset() {
+	*x = CreateBookRequest{}
+	mi := &file_bookstore_v1_book_service_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateBookRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateBookRequest) ProtoMessage() {}
+
+func (x *CreateBookRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bookstore_v1_book_service_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateBookRequest.ProtoReflect.Descriptor instead.
+func (*CreateBookRequest) Descriptor() ([]byte, []int) {
+	return file_bookstore_v1_book_service_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *CreateBookRequest) GetBook() *Book {
+	if x != nil {
+		return x.Book
+	}
+	return nil
+}
+
+type UpdateBookRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// book.id names the book; its version and timestamps are ignored.
+	Book *Book `protobuf:"bytes,1,opt,name=book,proto3" json:"book,omitempty"`
+	// expected_version, when set, must match the stored version, as with
+	// If-Match; otherwise the call fails with FAILED_PRECONDITION.
+	ExpectedVersion *uint32 `protobuf:"varint,2,opt,name=expected_version,json=expectedVersion,proto3,oneof" json:"expected_version,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *UpdateBookRequest) Reset() {
+	*x = UpdateBookRequest{}
+	mi := &file_bookstore_v1_book_service_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateBookRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateBookRequest) ProtoMessage() {}
+
+func (x *UpdateBookRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bookstore_v1_book_service_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateBookRequest.ProtoReflect.Descriptor instead.
+func (*UpdateBookRequest) Descriptor() ([]byte, []int) {
+	return file_bookstore_v1_book_service_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *UpdateBookRequest) GetBook() *Book {
+	if x != nil {
+		return x.Book
+	}
+	return nil
+}
+
+func (x *UpdateBookRequest) GetExpectedVersion() uint32 {
+	if x != nil && x.ExpectedVersion != nil {
+		return *x.ExpectedVersion
+	}
+	return 0
+}
+
+type DeleteBookRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Id              uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	ExpectedVersion *uint32                `protobuf:"varint,2,opt,name=expected_version,json=expectedVersion,proto3,oneof" json:"expected_version,omitempty"`
+	Purge           bool                   `protobuf:"varint,3,opt,name=purge,proto3" json:"purge,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *DeleteBookRequest) Reset() {
+	*x = DeleteBookRequest{}
+	mi := &file_bookstore_v1_book_service_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteBookRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteBookRequest) ProtoMessage() {}
+
+func (x *DeleteBookRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bookstore_v1_book_service_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteBookRequest.ProtoReflect.Descriptor instead.
+func (*DeleteBookRequest) Descriptor() ([]byte, []int) {
+	return file_bookstore_v1_book_service_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *DeleteBookRequest) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *DeleteBookRequest) GetExpectedVersion() uint32 {
+	if x != nil && x.ExpectedVersion != nil {
+		return *x.ExpectedVersion
+	}
+	return 0
+}
+
+func (x *DeleteBookRequest) GetPurge() bool {
+	if x != nil {
+		return x.Purge
+	}
+	return false
+}
+
+type WatchBooksRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// book_id limits the stream to one book; zero watches every book.
+	BookId uint64 `protobuf:"varint,1,opt,name=book_id,json=bookId,proto3" json:"book_id,omitempty"`
+	// after_event_id resumes after a previously received event. When unset
+	// the stream starts with the next change.
+	AfterEventId  *uint64 `protobuf:"varint,2,opt,name=after_event_id,json=afterEventId,proto3,oneof" json:"after_event_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchBooksRequest) Reset() {
+	*x = WatchBooksRequest{}
+	mi := &file_bookstore_v1_book_service_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchBooksRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchBooksRequest) ProtoMessage() {}
+
+func (x *WatchBooksRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bookstore_v1_book_service_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchBooksRequest.ProtoReflect.Descriptor instead.
+func (*WatchBooksRequest) Descriptor() ([]byte, []int) {
+	return file_bookstore_v1_book_service_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *WatchBooksRequest) GetBookId() uint64 {
+	if x != nil {
+		return x.BookId
+	}
+	return 0
+}
+
+func (x *WatchBooksRequest) GetAfterEventId() uint64 {
+	if x != nil && x.AfterEventId != nil {
+		return *x.AfterEventId
+	}
+	return 0
+}
+
+type BookEvent struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// id is the audit log entry's ID, usable as after_event_id.
+	Id       uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	BookId   uint64                 `protobuf:"varint,2,opt,name=book_id,json=bookId,proto3" json:"book_id,omitempty"`
+	Revision uint32                 `protobuf:"varint,3,opt,name=revision,proto3" json:"revision,omitempty"`
+	Action   BookEvent_Action       `protobuf:"varint,4,opt,name=action,proto3,enum=bookstore.v1.BookEvent_Action" json:"action,omitempty"`
+	Actor    string                 `protobuf:"bytes,5,opt,name=actor,proto3" json:"actor,omitempty"`
+	Time     *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=time,proto3" json:"time,omitempty"`
+	// book is the state after the change; it is unset for purges.
+	Book          *Book `protobuf:"bytes,7,opt,name=book,proto3" json:"book,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BookEvent) Reset() {
+	*x = BookEvent{}
+	mi := &file_bookstore_v1_book_service_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BookEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BookEvent) ProtoMessage() {}
+
+func (x *BookEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_bookstore_v1_book_service_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BookEvent.ProtoReflect.Descriptor instead.
+func (*BookEvent) Descriptor() ([]byte, []int) {
+	return file_bookstore_v1_book_service_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *BookEvent) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *BookEvent) GetBookId() uint64 {
+	if x != nil {
+		return x.BookId
+	}
+	return 0
+}
+
+func (x *BookEvent) GetRevision() uint32 {
+	if x != nil {
+		return x.Revision
+	}
+	return 0
+}
+
+func (x *BookEvent) GetAction() BookEvent_Action {
+	if x != nil {
+		return x.Action
+	}
+	return BookEvent_ACTION_UNSPECIFIED
+}
+
+func (x *BookEvent) GetActor() string {
+	if x != nil {
+		return x.Actor
+	}
+	return ""
+}
+
+func (x *BookEvent) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+func (x *BookEvent) GetBook() *Book {
+	if x != nil {
+		return x.Book
+	}
+	return nil
+}
+
+var File_bookstore_v1_book_service_proto protoreflect.FileDescriptor
+
+const file_bookstore_v1_book_service_proto_rawDesc = "" +
+	"\n" +
+	"\x1fbookstore/v1/book_service.proto\x12\fbookstore.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\xe7\x04\n" +
+	"\x04Book\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x16\n" +
+	"\x06author\x18\x03 \x01(\tR\x06author\x12 \n" +
+	"\vpublication\x18\x04 \x01(\tR\vpublication\x12\x18\n" +
+	"\aversion\x18\x05 \x01(\rR\aversion\x12\x15\n" +
+	"\x03sku\x18\x06 \x01(\tH\x00R\x03sku\x88\x01\x01\x12\x1b\n" +
+	"\x06isbn13\x18\a \x01(\tH\x01R\x06isbn13\x88\x01\x01\x12\x1b\n" +
+	"\x06isbn10\x18\b \x01(\tH\x02R\x06isbn10\x88\x01\x01\x12\x1f\n" +
+	"\vprice_cents\x18\t \x01(\x03R\n" +
+	"priceCents\x12&\n" +
+	"\fpublisher_id\x18\n" +
+	" \x01(\x04H\x03R\vpublisherId\x88\x01\x01\x12\x1d\n" +
+	"\n" +
+	"author_ids\x18\v \x03(\x04R\tauthorIds\x12;\n" +
+	"\vcreate_time\x18\f \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"createTime\x12;\n" +
+	"\vupdate_time\x18\r \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"updateTime\x12;\n" +
+	"\vdelete_time\x18\x0e \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"deleteTime\x12%\n" +
+	"\x0erating_average\x18\x0f \x01(\x01R\rratingAverage\x12!\n" +
+	"\frating_count\x18\x10 \x01(\rR\vratingCountB\x06\n" +
+	"\x04_skuB\t\n" +
+	"\a_isbn13B\t\n" +
+	"\a_isbn10B\x0f\n" +
+	"\r_publisher_id\" \n" +
+	"\x0eGetBookRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\"\xf7\x01\n" +
+	"\x10ListBooksRequest\x12\x1b\n" +
+	"\tpage_size\x18\x01 \x01(\x05R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x02 \x01(\tR\tpageToken\x12\x19\n" +
+	"\border_by\x18\x03 \x01(\tR\aorderBy\x12\x12\n" +
+	"\x04name\x18\x04 \x01(\tR\x04name\x12\x16\n" +
+	"\x06author\x18\x05 \x01(\tR\x06author\x12 \n" +
+	"\vpublication\x18\x06 \x01(\tR\vpublication\x12\x1b\n" +
+	"\tauthor_id\x18\a \x01(\x04R\bauthorId\x12!\n" +
+	"\fpublisher_id\x18\b \x01(\x04R\vpublisherId\"\x84\x01\n" +
+	"\x11ListBooksResponse\x12(\n" +
+	"\x05books\x18\x01 \x03(\v2\x12.bookstore.v1.BookR\x05books\x12&\n" +
+	"\x0fnext_page_token\x18\x02 \x01(\tR\rnextPageToken\x12\x1d\n" +
+	"\n" +
+	"total_size\x18\x03 \x01(\x03R\ttotalSize\";\n" +
+	"\x11CreateBookRequest\x12&\n" +
+	"\x04book\x18\x01 \x01(\v2\x12.bookstore.v1.BookR\x04book\"\x80\x01\n" +
+	"\x11UpdateBookRequest\x12&\n" +
+	"\x04book\x18\x01 \x01(\v2\x12.bookstore.v1.BookR\x04book\x12.\n" +
+	"\x10expected_version\x18\x02 \x01(\rH\x00R\x0fexpectedVersion\x88\x01\x01B\x13\n" +
+	"\x11_expected_version\"~\n" +
+	"\x11DeleteBookRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12.\n" +
+	"\x10expected_version\x18\x02 \x01(\rH\x00R\x0fexpectedVersion\x88\x01\x01\x12\x14\n" +
+	"\x05purge\x18\x03 \x01(\bR\x05purgeB\x13\n" +
+	"\x11_expected_version\"j\n" +
+	"\x11WatchBooksRequest\x12\x17\n" +
+	"\abook_id\x18\x01 \x01(\x04R\x06bookId\x12)\n" +
+	"\x0eafter_event_id\x18\x02 \x01(\x04H\x00R\fafterEventId\x88\x01\x01B\x11\n" +
+	"\x0f_after_event_id\"\xf7\x02\n" +
+	"\tBookEvent\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12\x17\n" +
+	"\abook_id\x18\x02 \x01(\x04R\x06bookId\x12\x1a\n" +
+	"\brevision\x18\x03 \x01(\rR\brevision\x126\n" +
+	"\x06action\x18\x04 \x01(\x0e2\x1e.bookstore.v1.BookEvent.ActionR\x06action\x12\x14\n" +
+	"\x05actor\x18\x05 \x01(\tR\x05actor\x12.\n" +
+	"\x04time\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\x04time\x12&\n" +
+	"\x04book\x18\a \x01(\v2\x12.bookstore.v1.BookR\x04book\"\x7f\n" +
+	"\x06Action\x12\x16\n" +
+	"\x12ACTION_UNSPECIFIED\x10\x00\x12\x11\n" +
+	"\rACTION_CREATE\x10\x01\x12\x11\n" +
+	"\rACTION_UPDATE\x10\x02\x12\x11\n" +
+	"\rACTION_DELETE\x10\x03\x12\x12\n" +
+	"\x0eACTION_RESTORE\x10\x04\x12\x10\n" +
+	"\fACTION_PURGE\x10\x052\xab\x03\n" +
+	"\vBookService\x12;\n" +
+	"\aGetBook\x12\x1c.bookstore.v1.GetBookRequest\x1a\x12.bookstore.v1.Book\x12L\n" +
+	"\tListBooks\x12\x1e.bookstore.v1.ListBooksRequest\x1a\x1f.bookstore.v1.ListBooksResponse\x12A\n" +
+	"\n" +
+	"CreateBook\x12\x1f.bookstore.v1.CreateBookRequest\x1a\x12.bookstore.v1.Book\x12A\n" +
+	"\n" +
+	"UpdateBook\x12\x1f.bookstore.v1.UpdateBookRequest\x1a\x12.bookstore.v1.Book\x12A\n" +
+	"\n" +
+	"DeleteBook\x12\x1f.bookstore.v1.DeleteBookRequest\x1a\x12.bookstore.v1.Book\x12H\n" +
+	"\n" +
+	"WatchBooks\x12\x1f.bookstore.v1.WatchBooksRequest\x1a\x17.bookstore.v1.BookEvent0\x01B5Z3github.com/yoloxsta/go-bookstore/pkg/pb/bookstorev1b\x06proto3"
+
+var (
+	file_bookstore_v1_book_service_proto_rawDescOnce sync.Once
+	file_bookstore_v1_book_service_proto_rawDescData []byte
+)
+
+func file_bookstore_v1_book_service_proto_rawDescGZIP() []byte {
+	file_bookstore_v1_book_service_proto_rawDescOnce.Do(func() {
+		file_bookstore_v1_book_service_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_bookstore_v1_book_service_proto_rawDesc), len(file_bookstore_v1_book_service_proto_rawDesc)))
+	})
+	return file_bookstore_v1_book_service_proto_rawDescData
+}
+
+var file_bookstore_v1_book_service_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_bookstore_v1_book_service_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
+var file_bookstore_v1_book_service_proto_goTypes = []any{
+	(BookEvent_Action)(0),         // 0: bookstore.v1.BookEvent.Action
+	(*Book)(nil),                  // 1: bookstore.v1.Book
+	(*GetBookRequest)(nil),        // 2: bookstore.v1.GetBookRequest
+	(*ListBooksRequest)(nil),      // 3: bookstore.v1.ListBooksRequest
+	(*ListBooksResponse)(nil),     // 4: bookstore.v1.ListBooksResponse
+	(*CreateBookRequest)(nil),     // 5: bookstore.v1.CreateBookRequest
+	(*UpdateBookRequest)(nil),     // 6: bookstore.v1.UpdateBookRequest
+	(*DeleteBookRequest)(nil),     // 7: bookstore.v1.DeleteBookRequest
+	(*WatchBooksRequest)(nil),     // 8: bookstore.v1.WatchBooksRequest
+	(*BookEvent)(nil),             // 9: bookstore.v1.BookEvent
+	(*timestamppb.Timestamp)(nil), // 10: google.protobuf.Timestamp
+}
+var file_bookstore_v1_book_service_proto_depIdxs = []int32{
+	10, // 0: bookstore.v1.Book.create_time:type_name -> google.protobuf.Timestamp
+	10, // 1: bookstore.v1.Book.update_time:type_name -> google.protobuf.Timestamp
+	10, // 2: bookstore.v1.Book.delete_time:type_name -> google.protobuf.Timestamp
+	1,  // 3: bookstore.v1.ListBooksResponse.books:type_name -> bookstore.v1.Book
+	1,  // 4: bookstore.v1.CreateBookRequest.book:type_name -> bookstore.v1.Book
+	1,  // 5: bookstore.v1.UpdateBookRequest.book:type_name -> bookstore.v1.Book
+	0,  // 6: bookstore.v1.BookEvent.action:type_name -> bookstore.v1.BookEvent.Action
+	10, // 7: bookstore.v1.BookEvent.time:type_name -> google.protobuf.Timestamp
+	1,  // 8: bookstore.v1.BookEvent.book:type_name -> bookstore.v1.Book
+	2,  // 9: bookstore.v1.BookService.GetBook:input_type -> bookstore.v1.GetBookRequest
+	3,  // 10: bookstore.v1.BookService.ListBooks:input_type -> bookstore.v1.ListBooksRequest
+	5,  // 11: bookstore.v1.BookService.CreateBook:input_type -> bookstore.v1.CreateBookRequest
+	6,  // 12: bookstore.v1.BookService.UpdateBook:input_type -> bookstore.v1.UpdateBookRequest
+	7,  // 13: bookstore.v1.BookService.DeleteBook:input_type -> bookstore.v1.DeleteBookRequest
+	8,  // 14: bookstore.v1.BookService.WatchBooks:input_type -> bookstore.v1.WatchBooksRequest
+	1,  // 15: bookstore.v1.BookService.GetBook:output_type -> bookstore.v1.Book
+	4,  // 16: bookstore.v1.BookService.ListBooks:output_type -> bookstore.v1.ListBooksResponse
+	1,  // 17: bookstore.v1.BookService.CreateBook:output_type -> bookstore.v1.Book
+	1,  // 18: bookstore.v1.BookService.UpdateBook:output_type -> bookstore.v1.Book
+	1,  // 19: bookstore.v1.BookService.DeleteBook:output_type -> bookstore.v1.Book
+	9,  // 20: bookstore.v1.BookService.WatchBooks:output_type -> bookstore.v1.BookEvent
+	15, // [15:21] is the sub-list for method output_type
+	9,  // [9:15] is the sub-list for method input_type
+	9,  // [9:9] is the sub-list for extension type_name
+	9,  // [9:9] is the sub-list for extension extendee
+	0,  // [0:9] is the sub-list for field type_name
+}
+
+func init() { file_bookstore_v1_book_service_proto_init() }
+func file_bookstore_v1_book_service_proto_init() {
+	if File_bookstore_v1_book_service_proto != nil {
+		return
+	}
+	file_bookstore_v1_book_service_proto_msgTypes[0].OneofWrappers = []any{}
+	file_bookstore_v1_book_service_proto_msgTypes[5].OneofWrappers = []any{}
+	file_bookstore_v1_book_service_proto_msgTypes[6].OneofWrappers = []any{}
+	file_bookstore_v1_book_service_proto_msgTypes[7].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_bookstore_v1_book_service_proto_rawDesc), len(file_bookstore_v1_book_service_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   9,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_bookstore_v1_book_service_proto_goTypes,
+		DependencyIndexes: file_bookstore_v1_book_service_proto_depIdxs,
+		EnumInfos:         file_bookstore_v1_book_service_proto_enumTypes,
+		MessageInfos:      file_bookstore_v1_book_service_proto_msgTypes,
+	}.Build()
+	File_bookstore_v1_book_service_proto = out.File
+	file_bookstore_v1_book_service_proto_goTypes = nil
+	file_bookstore_v1_book_service_proto_depIdxs = nil
+}
@@ -0,0 +1,14 @@
+package routes
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/yoloxsta/go-bookstore/pkg/controllers"
+)
+
+var RegisterAuthorRoutes = func(router *mux.Router, authors *controllers.AuthorController) {
+	router.HandleFunc("/author/", authors.CreateAuthor).Methods("POST")
+	router.HandleFunc("/author/", authors.GetAuthors).Methods("GET")
+	router.HandleFunc("/author/{authorId}", authors.GetAuthorById).Methods("GET")
+	router.HandleFunc("/author/{authorId}", authors.UpdateAuthor).Methods("PUT")
+	router.HandleFunc("/author/{authorId}", authors.DeleteAuthor).Methods("DELETE")
+}
@@ -5,10 +5,19 @@ import (
 	"github.com/yoloxsta/go-bookstore/pkg/controllers"
 )
 
-var RegisterBookStoreRoutes = func(router *mux.Router) {
-	router.HandleFunc("/book/", controllers.CreateBook).Methods("POST")
-	router.HandleFunc("/book/", controllers.GetBook).Methods("GET")
-	router.HandleFunc("/book/{bookId}", controllers.GetBookById).Methods("GET")
-	router.HandleFunc("/book/{bookId}", controllers.UpdateBook).Methods("PUT")
-	router.HandleFunc("/book/{bookId}", controllers.DeleteBook).Methods("DELETE")
+var RegisterBookStoreRoutes = func(router *mux.Router, books *controllers.BookController) {
+	router.HandleFunc("/book/", books.CreateBook).Methods("POST")
+	router.HandleFunc("/book/", books.GetBook).Methods("GET")
+	router.HandleFunc("/book/search", books.SearchBooks).Methods("GET")
+	router.HandleFunc("/book/trash", books.GetTrash).Methods("GET")
+	router.HandleFunc("/book/import", books.ImportBooks).Methods("POST")
+	router.HandleFunc("/book/export", books.ExportBooks).Methods("GET")
+	router.HandleFunc("/book/isbn/{isbn}", books.GetBookByISBN).Methods("GET")
+	router.HandleFunc("/book/{bookId}", books.GetBookById).Methods("GET")
+	router.HandleFunc("/book/{bookId}", books.UpdateBook).Methods("PUT")
+	router.HandleFunc("/book/{bookId}", books.PatchBook).Methods("PATCH")
+	router.HandleFunc("/book/{bookId}", books.DeleteBook).Methods("DELETE")
+	router.HandleFunc("/book/{bookId}/restore", books.RestoreBook).Methods("POST")
+	router.HandleFunc("/book/{bookId}/history", books.GetBookHistory).Methods("GET")
+	router.HandleFunc("/book/{bookId}/revert", books.RevertBook).Methods("POST")
 }
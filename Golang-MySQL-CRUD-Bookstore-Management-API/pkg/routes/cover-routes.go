@@ -0,0 +1,13 @@
+package routes
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/yoloxsta/go-bookstore/pkg/controllers"
+)
+
+var RegisterCoverRoutes = func(router *mux.Router, covers *controllers.CoverController) {
+	router.HandleFunc("/book/{bookId}/cover", covers.UploadCover).Methods("PUT")
+	router.HandleFunc("/book/{bookId}/cover", covers.GetCover).Methods("GET")
+	router.HandleFunc("/book/{bookId}/cover", covers.DeleteCover).Methods("DELETE")
+	router.HandleFunc("/book/{bookId}/cover/{size}", covers.GetCover).Methods("GET")
+}
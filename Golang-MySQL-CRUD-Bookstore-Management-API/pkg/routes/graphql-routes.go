@@ -0,0 +1,14 @@
+package routes
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/yoloxsta/go-bookstore/pkg/graph"
+)
+
+// GraphQLRoute names the GraphQL route so auth.ByMethod can exempt it: the
+// handler checks roles per operation rather than per HTTP method.
+const GraphQLRoute = "graphql"
+
+var RegisterGraphQLRoutes = func(router *mux.Router, handler *graph.Handler) {
+	router.Handle("/graphql", handler).Methods("POST").Name(GraphQLRoute)
+}
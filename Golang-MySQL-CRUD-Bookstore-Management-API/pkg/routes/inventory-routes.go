@@ -0,0 +1,14 @@
+package routes
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/yoloxsta/go-bookstore/pkg/controllers"
+)
+
+var RegisterInventoryRoutes = func(router *mux.Router, inventory *controllers.InventoryController) {
+	router.HandleFunc("/inventory/report", inventory.GetReport).Methods("GET")
+	router.HandleFunc("/inventory/{bookId}", inventory.GetStock).Methods("GET")
+	router.HandleFunc("/inventory/{bookId}", inventory.UpdateStock).Methods("PUT")
+	router.HandleFunc("/inventory/{bookId}/movements", inventory.CreateMovement).Methods("POST")
+	router.HandleFunc("/inventory/{bookId}/movements", inventory.GetMovements).Methods("GET")
+}
@@ -0,0 +1,31 @@
+package routes
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/yoloxsta/go-bookstore/pkg/controllers"
+)
+
+// CustomerRoutes names the cart and order routes readers shop through, so
+// auth.ByMethod can exempt them: the handlers check roles and ownership
+// themselves. Shipping and refunding still need the editor role.
+var CustomerRoutes = []string{
+	"create-cart", "get-cart", "delete-cart", "set-cart-item", "delete-cart-item",
+	"create-order", "list-orders", "get-order", "get-order-history", "pay-order", "cancel-order",
+}
+
+var RegisterOrderRoutes = func(router *mux.Router, carts *controllers.CartController, orders *controllers.OrderController) {
+	router.HandleFunc("/cart/", carts.CreateCart).Methods("POST").Name("create-cart")
+	router.HandleFunc("/cart/{cartId}", carts.GetCart).Methods("GET").Name("get-cart")
+	router.HandleFunc("/cart/{cartId}", carts.DeleteCart).Methods("DELETE").Name("delete-cart")
+	router.HandleFunc("/cart/{cartId}/items/{bookId}", carts.SetCartItem).Methods("PUT").Name("set-cart-item")
+	router.HandleFunc("/cart/{cartId}/items/{bookId}", carts.DeleteCartItem).Methods("DELETE").Name("delete-cart-item")
+
+	router.HandleFunc("/order/", orders.CreateOrder).Methods("POST").Name("create-order")
+	router.HandleFunc("/order/", orders.GetOrders).Methods("GET").Name("list-orders")
+	router.HandleFunc("/order/{orderId}", orders.GetOrderById).Methods("GET").Name("get-order")
+	router.HandleFunc("/order/{orderId}/history", orders.GetOrderEvents).Methods("GET").Name("get-order-history")
+	router.HandleFunc("/order/{orderId}/pay", orders.PayOrder).Methods("POST").Name("pay-order")
+	router.HandleFunc("/order/{orderId}/ship", orders.ShipOrder).Methods("POST")
+	router.HandleFunc("/order/{orderId}/cancel", orders.CancelOrder).Methods("POST").Name("cancel-order")
+	router.HandleFunc("/order/{orderId}/refund", orders.RefundOrder).Methods("POST")
+}
@@ -0,0 +1,14 @@
+package routes
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/yoloxsta/go-bookstore/pkg/controllers"
+)
+
+var RegisterPublisherRoutes = func(router *mux.Router, publishers *controllers.PublisherController) {
+	router.HandleFunc("/publisher/", publishers.CreatePublisher).Methods("POST")
+	router.HandleFunc("/publisher/", publishers.GetPublishers).Methods("GET")
+	router.HandleFunc("/publisher/{publisherId}", publishers.GetPublisherById).Methods("GET")
+	router.HandleFunc("/publisher/{publisherId}", publishers.UpdatePublisher).Methods("PUT")
+	router.HandleFunc("/publisher/{publisherId}", publishers.DeletePublisher).Methods("DELETE")
+}
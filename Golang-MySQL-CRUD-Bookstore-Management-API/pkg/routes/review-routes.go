@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/yoloxsta/go-bookstore/pkg/controllers"
+)
+
+// CreateReviewRoute names the route readers post reviews to, so
+// auth.ByMethod can exempt it from the editor role other POSTs need.
+const CreateReviewRoute = "create-review"
+
+var RegisterReviewRoutes = func(router *mux.Router, reviews *controllers.ReviewController) {
+	router.HandleFunc("/book/{bookId}/reviews", reviews.CreateReview).Methods("POST").Name(CreateReviewRoute)
+	router.HandleFunc("/book/{bookId}/reviews", reviews.GetReviews).Methods("GET")
+	router.HandleFunc("/book/{bookId}/reviews/{reviewId}", reviews.GetReview).Methods("GET")
+	router.HandleFunc("/book/{bookId}/reviews/{reviewId}/approve", reviews.ApproveReview).Methods("POST")
+	router.HandleFunc("/book/{bookId}/reviews/{reviewId}/reject", reviews.RejectReview).Methods("POST")
+}
@@ -0,0 +1,186 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/yoloxsta/go-bookstore/pkg/auth"
+	"github.com/yoloxsta/go-bookstore/pkg/middleware"
+	"github.com/yoloxsta/go-bookstore/pkg/models"
+	pb "github.com/yoloxsta/go-bookstore/pkg/pb/bookstorev1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// watchBatch caps how many audit entries one poll of WatchBooks reads.
+const watchBatch = 100
+
+// bookService implements pb.BookServiceServer on the same repositories and
+// rules as the REST BookController.
+type bookService struct {
+	pb.UnimplementedBookServiceServer
+
+	books         models.BookRepository
+	audit         models.AuditRepository
+	watchInterval time.Duration
+	// stopping is closed when the server begins shutting down, ending every
+	// WatchBooks stream.
+	stopping chan struct{}
+}
+
+// booksFor returns the book repository that records the caller in the audit
+// log.
+func (s *bookService) booksFor(ctx context.Context) models.BookRepository {
+	return s.books.WithAudit(models.AuditInfo{Actor: auth.Actor(ctx), RequestID: middleware.GetRequestID(ctx)})
+}
+
+func (s *bookService) GetBook(ctx context.Context, req *pb.GetBookRequest) (*pb.Book, error) {
+	b, err := s.books.Get(int64(req.GetId()))
+	if err != nil {
+		return nil, statusError(ctx, err)
+	}
+	return toProto(b), nil
+}
+
+func (s *bookService) ListBooks(ctx context.Context, req *pb.ListBooksRequest) (*pb.ListBooksResponse, error) {
+	sort, err := models.ParseSort(req.GetOrderBy())
+	if err != nil {
+		return nil, statusError(ctx, err)
+	}
+	if req.GetPageSize() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "page_size must not be negative")
+	}
+	page, err := s.books.List(models.BookQuery{
+		Limit:       int(req.GetPageSize()),
+		Cursor:      req.GetPageToken(),
+		Sort:        sort,
+		Name:        req.GetName(),
+		Author:      req.GetAuthor(),
+		Publication: req.GetPublication(),
+		AuthorID:    uint(req.GetAuthorId()),
+		PublisherID: uint(req.GetPublisherId()),
+	})
+	if err != nil {
+		return nil, statusError(ctx, err)
+	}
+	res := &pb.ListBooksResponse{NextPageToken: page.NextCursor, TotalSize: page.Total}
+	for i := range page.Books {
+		res.Books = append(res.Books, toProto(&page.Books[i]))
+	}
+	return res, nil
+}
+
+func (s *bookService) CreateBook(ctx context.Context, req *pb.CreateBookRequest) (*pb.Book, error) {
+	b := fromProto(req.GetBook())
+	if err := b.Validate(); err != nil {
+		return nil, statusError(ctx, err)
+	}
+	if err := s.booksFor(ctx).Create(b); err != nil {
+		return nil, statusError(ctx, err)
+	}
+	return toProto(b), nil
+}
+
+func (s *bookService) UpdateBook(ctx context.Context, req *pb.UpdateBookRequest) (*pb.Book, error) {
+	current, err := s.current(ctx, req.GetBook().GetId(), req.ExpectedVersion)
+	if err != nil {
+		return nil, err
+	}
+	replacement := fromProto(req.GetBook())
+	replacement.Model = current.Model
+	replacement.Version = current.Version
+	if err := replacement.Validate(); err != nil {
+		return nil, statusError(ctx, err)
+	}
+	if err := s.booksFor(ctx).Update(replacement); err != nil {
+		return nil, statusError(ctx, err)
+	}
+	return toProto(replacement), nil
+}
+
+func (s *bookService) DeleteBook(ctx context.Context, req *pb.DeleteBookRequest) (*pb.Book, error) {
+	b, err := s.books.Get(int64(req.GetId()))
+	if req.GetPurge() && errors.Is(err, models.ErrNotFound) {
+		b, err = s.books.GetDeleted(int64(req.GetId()))
+	}
+	if err != nil {
+		return nil, statusError(ctx, err)
+	}
+	if err := checkVersion(b, req.ExpectedVersion); err != nil {
+		return nil, err
+	}
+	if req.GetPurge() {
+		err = s.booksFor(ctx).Purge(b)
+	} else {
+		err = s.booksFor(ctx).Delete(b)
+	}
+	if err != nil {
+		return nil, statusError(ctx, err)
+	}
+	return toProto(b), nil
+}
+
+// current loads the live book an update changes.
+func (s *bookService) current(ctx context.Context, id uint64, expected *uint32) (*models.Book, error) {
+	b, err := s.books.Get(int64(id))
+	if err != nil {
+		return nil, statusError(ctx, err)
+	}
+	if err := checkVersion(b, expected); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// checkVersion plays the part of If-Match.
+func checkVersion(b *models.Book, expected *uint32) error {
+	if expected != nil && uint(*expected) != b.Version {
+		return status.Errorf(codes.FailedPrecondition, "book is at version %d, not %d; reload and retry", b.Version, *expected)
+	}
+	return nil
+}
+
+// WatchBooks tails the audit log, polling every watchInterval, until the
+// client goes away or the server shuts down.
+func (s *bookService) WatchBooks(req *pb.WatchBooksRequest, stream pb.BookService_WatchBooksServer) error {
+	ctx := stream.Context()
+	var after uint
+	if req.AfterEventId != nil {
+		after = uint(req.GetAfterEventId())
+	} else {
+		last, err := s.audit.LastID()
+		if err != nil {
+			return statusError(ctx, err)
+		}
+		after = last
+	}
+	ticker := time.NewTicker(s.watchInterval)
+	defer ticker.Stop()
+	for {
+		entries, err := s.audit.Since(after, int64(req.GetBookId()), watchBatch)
+		if err != nil {
+			return statusError(ctx, err)
+		}
+		for _, e := range entries {
+			ev, err := toEvent(e)
+			if err != nil {
+				return statusError(ctx, err)
+			}
+			if err := stream.Send(ev); err != nil {
+				return err
+			}
+			after = e.ID
+		}
+		if len(entries) == watchBatch {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-s.stopping:
+			return status.Error(codes.Unavailable, "server is shutting down")
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/yoloxsta/go-bookstore/pkg/auth"
+	"github.com/yoloxsta/go-bookstore/pkg/config"
+	"github.com/yoloxsta/go-bookstore/pkg/controllers"
+	"github.com/yoloxsta/go-bookstore/pkg/models"
+	pb "github.com/yoloxsta/go-bookstore/pkg/pb/bookstorev1"
+	"github.com/yoloxsta/go-bookstore/pkg/routes"
+	"github.com/yoloxsta/go-bookstore/pkg/utils"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+)
+
+// racingBooks simulates a concurrent writer: while raced is set, the next
+// Update first changes the same book behind the caller's back, so the
+// caller's write hits a version conflict.
+type racingBooks struct {
+	models.BookRepository
+	raced *atomic.Bool
+}
+
+func (r racingBooks) Update(b *models.Book) error {
+	if r.raced.CompareAndSwap(true, false) {
+		other, err := r.BookRepository.Get(int64(b.ID))
+		if err != nil {
+			return err
+		}
+		other.PriceCents++
+		if err := r.BookRepository.Update(other); err != nil {
+			return err
+		}
+	}
+	return r.BookRepository.Update(b)
+}
+
+func (r racingBooks) WithAudit(info models.AuditInfo) models.BookRepository {
+	return racingBooks{r.BookRepository.WithAudit(info), r.raced}
+}
+
+type parity struct {
+	rest  *httptest.Server
+	rpc   pb.BookServiceClient
+	raced *atomic.Bool
+}
+
+// newParity serves one memory store over REST, through httptest, and gRPC,
+// through bufconn, seeded with n books.
+func newParity(t *testing.T, n int) *parity {
+	t.Helper()
+	cfg := config.Default()
+	store := models.NewMemoryStore(cfg.Inventory)
+	raced := new(atomic.Bool)
+	store.Books = racingBooks{store.Books, raced}
+	for i := 1; i <= n; i++ {
+		b := &models.Book{Name: fmt.Sprintf("Book %02d", i), Author: "Author", Publication: "Press", PriceCents: int64(100 * i)}
+		if err := store.Books.Create(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	router := mux.NewRouter()
+	router.Use(auth.Anonymous)
+	routes.RegisterBookStoreRoutes(router, controllers.NewBookController(store, 0))
+	rest := httptest.NewServer(router)
+	t.Cleanup(rest.Close)
+
+	lis := bufconn.Listen(1 << 20)
+	srv := NewServer(store, nil, cfg.GRPC)
+	go srv.Serve(lis)
+	t.Cleanup(func() { srv.Shutdown(context.Background()) })
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return &parity{rest: rest, rpc: pb.NewBookServiceClient(conn), raced: raced}
+}
+
+// do sends a REST request and decodes a JSON response into v, if given.
+func (p *parity) do(t *testing.T, method, url, body string, v interface{}, headers ...string) *http.Response {
+	t.Helper()
+	if !strings.HasPrefix(url, "http") {
+		url = p.rest.URL + url
+	}
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	res, err := p.rest.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	if v != nil {
+		if err := json.NewDecoder(res.Body).Decode(v); err != nil {
+			t.Fatalf("%s %s: decoding: %v", method, url, err)
+		}
+	}
+	return res
+}
+
+func wantCode(t *testing.T, err error, code codes.Code) {
+	t.Helper()
+	if status.Code(err) != code {
+		t.Fatalf("gRPC error = %v, want %s", err, code)
+	}
+}
+
+func wantRESTError(t *testing.T, res *http.Response, body utils.ErrorResponse, status int, code string) {
+	t.Helper()
+	if res.StatusCode != status || body.Error.Code != code {
+		t.Fatalf("REST = %d %q, want %d %q", res.StatusCode, body.Error.Code, status, code)
+	}
+}
+
+func TestGetBookParity(t *testing.T) {
+	p := newParity(t, 3)
+	ctx := context.Background()
+
+	var rest models.Book
+	if res := p.do(t, http.MethodGet, "/book/2", "", &rest); res.StatusCode != http.StatusOK {
+		t.Fatalf("REST status = %d", res.StatusCode)
+	}
+	got, err := p.rpc.GetBook(ctx, &pb.GetBookRequest{Id: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := toProto(&rest); !proto.Equal(got, want) {
+		t.Errorf("gRPC book = %v\nREST book = %v", got, want)
+	}
+
+	var notFound utils.ErrorResponse
+	wantRESTError(t, p.do(t, http.MethodGet, "/book/99", "", &notFound), notFound, http.StatusNotFound, utils.CodeNotFound)
+	_, err = p.rpc.GetBook(ctx, &pb.GetBookRequest{Id: 99})
+	wantCode(t, err, codes.NotFound)
+}
+
+var nextLink = regexp.MustCompile(`<([^>]+)>; rel="next"`)
+
+func TestListBooksPagingParity(t *testing.T) {
+	p := newParity(t, 7)
+	ctx := context.Background()
+
+	var restPages, rpcPages [][]uint
+	url := "/book/?limit=3&sort=-name"
+	for url != "" {
+		var books []models.Book
+		res := p.do(t, http.MethodGet, url, "", &books)
+		if res.StatusCode != http.StatusOK || res.Header.Get("X-Total-Count") != "7" {
+			t.Fatalf("REST status = %d, X-Total-Count = %q", res.StatusCode, res.Header.Get("X-Total-Count"))
+		}
+		var ids []uint
+		for _, b := range books {
+			ids = append(ids, b.ID)
+		}
+		restPages = append(restPages, ids)
+		url = ""
+		if m := nextLink.FindStringSubmatch(res.Header.Get("Link")); m != nil {
+			url = m[1]
+		}
+	}
+
+	req := &pb.ListBooksRequest{PageSize: 3, OrderBy: "-name"}
+	for {
+		res, err := p.rpc.ListBooks(ctx, req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.TotalSize != 7 {
+			t.Fatalf("gRPC total_size = %d, want 7", res.TotalSize)
+		}
+		var ids []uint
+		for _, b := range res.Books {
+			ids = append(ids, uint(b.Id))
+		}
+		rpcPages = append(rpcPages, ids)
+		if res.NextPageToken == "" {
+			break
+		}
+		req.PageToken = res.NextPageToken
+	}
+
+	want := [][]uint{{7, 6, 5}, {4, 3, 2}, {1}}
+	if fmt.Sprint(restPages) != fmt.Sprint(want) || fmt.Sprint(rpcPages) != fmt.Sprint(want) {
+		t.Errorf("pages: REST %v, gRPC %v, want %v", restPages, rpcPages, want)
+	}
+
+	var bad utils.ErrorResponse
+	wantRESTError(t, p.do(t, http.MethodGet, "/book/?sort=colour", "", &bad), bad, http.StatusBadRequest, utils.CodeBadRequest)
+	_, err := p.rpc.ListBooks(ctx, &pb.ListBooksRequest{OrderBy: "colour"})
+	wantCode(t, err, codes.InvalidArgument)
+}
+
+func TestUpdateBookConflictParity(t *testing.T) {
+	p := newParity(t, 1)
+	ctx := context.Background()
+	body := `{"name":"Renamed","author":"Author","publication":"Press"}`
+	replacement := &pb.Book{Id: 1, Name: "Renamed", Author: "Author", Publication: "Press"}
+
+	// A stale validator fails the precondition.
+	var stale utils.ErrorResponse
+	wantRESTError(t, p.do(t, http.MethodPut, "/book/1", body, &stale, "If-Match", `"1.9"`),
+		stale, http.StatusPreconditionFailed, utils.CodePreconditionFailed)
+	_, err := p.rpc.UpdateBook(ctx, &pb.UpdateBookRequest{Book: replacement, ExpectedVersion: proto.Uint32(9)})
+	wantCode(t, err, codes.FailedPrecondition)
+
+	// A write that loses a race with another is a conflict.
+	p.raced.Store(true)
+	var conflict utils.ErrorResponse
+	wantRESTError(t, p.do(t, http.MethodPut, "/book/1", body, &conflict), conflict, http.StatusConflict, utils.CodeConflict)
+	p.raced.Store(true)
+	_, err = p.rpc.UpdateBook(ctx, &pb.UpdateBookRequest{Book: replacement})
+	wantCode(t, err, codes.Aborted)
+
+	// Both then see the same book, changed only by the two racing writers.
+	var rest models.Book
+	p.do(t, http.MethodGet, "/book/1", "", &rest)
+	got, err := p.rpc.GetBook(ctx, &pb.GetBookRequest{Id: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rest.Name != "Book 01" || rest.Version != 3 || !proto.Equal(got, toProto(&rest)) {
+		t.Errorf("after conflicts: REST %+v, gRPC %v", rest, got)
+	}
+}
@@ -0,0 +1,86 @@
+package rpc
+
+import (
+	"github.com/yoloxsta/go-bookstore/pkg/models"
+	pb "github.com/yoloxsta/go-bookstore/pkg/pb/bookstorev1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func toProto(b *models.Book) *pb.Book {
+	p := &pb.Book{
+		Id:            uint64(b.ID),
+		Name:          b.Name,
+		Author:        b.Author,
+		Publication:   b.Publication,
+		Version:       uint32(b.Version),
+		Sku:           b.SKU,
+		Isbn13:        b.ISBN13,
+		Isbn10:        b.ISBN10,
+		PriceCents:    b.PriceCents,
+		RatingAverage: b.RatingAverage,
+		RatingCount:   uint32(b.RatingCount),
+		AuthorIds:     make([]uint64, len(b.AuthorIDs)),
+		CreateTime:    timestamppb.New(b.CreatedAt),
+		UpdateTime:    timestamppb.New(b.UpdatedAt),
+	}
+	for i, id := range b.AuthorIDs {
+		p.AuthorIds[i] = uint64(id)
+	}
+	if b.PublisherID != nil {
+		id := uint64(*b.PublisherID)
+		p.PublisherId = &id
+	}
+	if b.DeletedAt != nil {
+		p.DeleteTime = timestamppb.New(*b.DeletedAt)
+	}
+	return p
+}
+
+// fromProto copies the client-writable fields of p; the ID, version and
+// timestamps are left for the caller to fill in.
+func fromProto(p *pb.Book) *models.Book {
+	b := &models.Book{
+		Name:        p.GetName(),
+		Author:      p.GetAuthor(),
+		Publication: p.GetPublication(),
+		SKU:         p.Sku,
+		ISBN13:      p.Isbn13,
+		ISBN10:      p.Isbn10,
+		PriceCents:  p.GetPriceCents(),
+	}
+	for _, id := range p.GetAuthorIds() {
+		b.AuthorIDs = append(b.AuthorIDs, uint(id))
+	}
+	if p.PublisherId != nil {
+		id := uint(*p.PublisherId)
+		b.PublisherID = &id
+	}
+	return b
+}
+
+var actions = map[string]pb.BookEvent_Action{
+	models.AuditCreate:  pb.BookEvent_ACTION_CREATE,
+	models.AuditUpdate:  pb.BookEvent_ACTION_UPDATE,
+	models.AuditDelete:  pb.BookEvent_ACTION_DELETE,
+	models.AuditRestore: pb.BookEvent_ACTION_RESTORE,
+	models.AuditPurge:   pb.BookEvent_ACTION_PURGE,
+}
+
+func toEvent(e models.BookAudit) (*pb.BookEvent, error) {
+	ev := &pb.BookEvent{
+		Id:       uint64(e.ID),
+		BookId:   uint64(e.BookID),
+		Revision: uint32(e.Revision),
+		Action:   actions[e.Action],
+		Actor:    e.Actor,
+		Time:     timestamppb.New(e.CreatedAt),
+	}
+	if e.After != "" {
+		b, err := e.Book()
+		if err != nil {
+			return nil, err
+		}
+		ev.Book = toProto(b)
+	}
+	return ev, nil
+}
@@ -0,0 +1,47 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/yoloxsta/go-bookstore/pkg/models"
+	"github.com/yoloxsta/go-bookstore/pkg/validation"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// statusError maps repository errors to gRPC statuses the way the REST
+// controllers map them to HTTP ones, logging and hiding anything unexpected.
+func statusError(ctx context.Context, err error) error {
+	var fieldErrs validation.Errors
+	switch {
+	case errors.As(err, &fieldErrs):
+		return invalidArgument(fieldErrs)
+	case errors.Is(err, models.ErrNotFound):
+		return status.Error(codes.NotFound, "book not found")
+	case errors.Is(err, models.ErrConflict):
+		return status.Error(codes.Aborted, "book was changed by another request; reload and retry")
+	case errors.Is(err, models.ErrInvalidQuery):
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	slog.ErrorContext(ctx, "internal error", "err", err)
+	return status.Error(codes.Internal, "internal server error")
+}
+
+// invalidArgument reports each field error in a google.rpc.BadRequest detail.
+func invalidArgument(errs validation.Errors) error {
+	br := &errdetails.BadRequest{}
+	for _, fe := range errs {
+		br.FieldViolations = append(br.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       fe.Field,
+			Description: fe.Message,
+		})
+	}
+	st, err := status.New(codes.InvalidArgument, "request failed validation").WithDetails(br)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, errs.Error())
+	}
+	return st.Err()
+}
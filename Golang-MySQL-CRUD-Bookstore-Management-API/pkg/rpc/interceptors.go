@@ -0,0 +1,86 @@
+package rpc
+
+import (
+	"context"
+	"strings"
+
+	"github.com/yoloxsta/go-bookstore/pkg/auth"
+	"github.com/yoloxsta/go-bookstore/pkg/middleware"
+	pb "github.com/yoloxsta/go-bookstore/pkg/pb/bookstorev1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// requiredRoles gives each method the role its REST counterpart needs.
+// Methods not listed, such as reflection, need a reader.
+var requiredRoles = map[string]auth.Role{
+	pb.BookService_CreateBook_FullMethodName: auth.RoleEditor,
+	pb.BookService_UpdateBook_FullMethodName: auth.RoleEditor,
+	pb.BookService_DeleteBook_FullMethodName: auth.RoleAdmin,
+}
+
+// authorizer tags each call with a request ID and the caller's claims. With
+// a nil verifier every caller gets auth.AnonymousClaims.
+type authorizer struct {
+	verifier *auth.Verifier
+}
+
+func (a authorizer) unary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := a.authorize(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (a authorizer) stream(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := a.authorize(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &contextStream{ss, ctx})
+}
+
+func (a authorizer) authorize(ctx context.Context, method string) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	id := middleware.RequestIDOr(first(md, "x-request-id"))
+	grpc.SetHeader(ctx, metadata.Pairs("x-request-id", id))
+	ctx = middleware.WithRequestID(ctx, id)
+
+	claims := auth.AnonymousClaims
+	if a.verifier != nil {
+		scheme, token, ok := strings.Cut(first(md, "authorization"), " ")
+		if !ok || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+			return nil, status.Error(codes.Unauthenticated, "a bearer token is required")
+		}
+		var err error
+		if claims, err = a.verifier.Verify(strings.TrimSpace(token)); err != nil {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+	}
+	need, ok := requiredRoles[method]
+	if !ok {
+		need = auth.RoleReader
+	}
+	if claims.HighestRole() < need {
+		return nil, status.Errorf(codes.PermissionDenied, "%s requires the %s role", method, need)
+	}
+	return auth.NewContext(ctx, claims), nil
+}
+
+func first(md metadata.MD, key string) string {
+	if v := md.Get(key); len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+// contextStream overrides the context of a server stream.
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context { return s.ctx }
@@ -0,0 +1,63 @@
+// Package rpc serves the bookstore.v1.BookService gRPC API defined in
+// proto/bookstore/v1, on the same repositories as the HTTP API.
+package rpc
+
+import (
+	"context"
+	"net"
+	"sync"
+
+	"github.com/yoloxsta/go-bookstore/pkg/auth"
+	"github.com/yoloxsta/go-bookstore/pkg/config"
+	"github.com/yoloxsta/go-bookstore/pkg/models"
+	pb "github.com/yoloxsta/go-bookstore/pkg/pb/bookstorev1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+)
+
+// Server is a gRPC server with the BookService and server reflection
+// registered.
+type Server struct {
+	grpc *grpc.Server
+	svc  *bookService
+	stop sync.Once
+}
+
+// NewServer authenticates callers with verifier, or lets everyone in as
+// auth.AnonymousClaims when it is nil.
+func NewServer(store *models.Store, verifier *auth.Verifier, cfg config.GRPCConfig) *Server {
+	a := authorizer{verifier: verifier}
+	s := &Server{
+		grpc: grpc.NewServer(grpc.ChainUnaryInterceptor(a.unary), grpc.ChainStreamInterceptor(a.stream)),
+		svc: &bookService{
+			books:         store.Books,
+			audit:         store.Audit,
+			watchInterval: cfg.WatchInterval,
+			stopping:      make(chan struct{}),
+		},
+	}
+	pb.RegisterBookServiceServer(s.grpc, s.svc)
+	reflection.Register(s.grpc)
+	return s
+}
+
+// Serve accepts connections on lis until Shutdown.
+func (s *Server) Serve(lis net.Listener) error {
+	return s.grpc.Serve(lis)
+}
+
+// Shutdown ends every WatchBooks stream, then waits for other calls to
+// finish until ctx is done, when it closes them.
+func (s *Server) Shutdown(ctx context.Context) {
+	s.stop.Do(func() { close(s.svc.stopping) })
+	done := make(chan struct{})
+	go func() {
+		s.grpc.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		s.grpc.Stop()
+	}
+}
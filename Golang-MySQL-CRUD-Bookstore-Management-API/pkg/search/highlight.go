@@ -0,0 +1,40 @@
+package search
+
+import (
+	"html"
+	"strings"
+	"unicode"
+)
+
+// Highlight HTML-escapes text and wraps every word matching one of the query
+// terms in <em>. ok is false when nothing matched.
+func Highlight(text string, terms []string) (out string, ok bool) {
+	var b strings.Builder
+	runes := []rune(text)
+	isWord := func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }
+	for i := 0; i < len(runes); {
+		j := i
+		word := isWord(runes[i])
+		for j < len(runes) && isWord(runes[j]) == word {
+			j++
+		}
+		chunk := string(runes[i:j])
+		if word && matchesAny(strings.ToLower(chunk), terms) {
+			b.WriteString("<em>" + html.EscapeString(chunk) + "</em>")
+			ok = true
+		} else {
+			b.WriteString(html.EscapeString(chunk))
+		}
+		i = j
+	}
+	return b.String(), ok
+}
+
+func matchesAny(word string, terms []string) bool {
+	for _, t := range terms {
+		if termWeight(t, word) > 0 {
+			return true
+		}
+	}
+	return false
+}
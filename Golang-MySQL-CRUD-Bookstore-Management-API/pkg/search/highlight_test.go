@@ -0,0 +1,30 @@
+package search
+
+import (
+	"testing"
+)
+
+func TestHighlight(t *testing.T) {
+	tests := []struct {
+		text  string
+		terms []string
+		want  string
+		ok    bool
+	}{
+		{"The Go Programming Language", []string{"go"}, "The <em>Go</em> Programming Language", true},
+		{"The Go Programming Language", []string{"prog", "lang"}, "The Go <em>Programming</em> <em>Language</em>", true},
+		{"The Go Programming Language", []string{"programing"}, "The Go <em>Programming</em> Language", true},
+		// Words are matched whole, so "go" does not light up inside "Gopher".
+		{"Gophers", []string{"go"}, "Gophers", false},
+		{"Tom & Jerry <3", []string{"tom"}, "<em>Tom</em> &amp; Jerry &lt;3", true},
+		{"<b>bold</b>", []string{"bold"}, "&lt;b&gt;<em>bold</em>&lt;/b&gt;", true},
+		{"Crème Brûlée", []string{"crème"}, "<em>Crème</em> Brûlée", true},
+		{"", []string{"go"}, "", false},
+	}
+	for _, tt := range tests {
+		got, ok := Highlight(tt.text, tt.terms)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("Highlight(%q, %q) = %q, %v; want %q, %v", tt.text, tt.terms, got, ok, tt.want, tt.ok)
+		}
+	}
+}
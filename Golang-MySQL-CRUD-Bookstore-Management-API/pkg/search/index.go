@@ -0,0 +1,234 @@
+package search
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Field weights decide how much a match in each field counts towards the
+// score; a name match outranks the same word in the publication.
+type Field struct {
+	Name   string
+	Weight float64
+}
+
+// Hit is one ranked document with its matching fields highlighted.
+type Hit struct {
+	ID         uint
+	Score      float64
+	Highlights map[string]string
+}
+
+type posting struct {
+	// tf counts occurrences per field index.
+	tf []int
+}
+
+// Index is an in-process inverted index with prefix and typo-tolerant
+// matching. It is safe for concurrent use.
+type Index struct {
+	fields []Field
+
+	mu       sync.RWMutex
+	postings map[string]map[uint]*posting
+	docs     map[uint][]string
+}
+
+func NewIndex(fields ...Field) *Index {
+	return &Index{
+		fields:   fields,
+		postings: make(map[string]map[uint]*posting),
+		docs:     make(map[uint][]string),
+	}
+}
+
+// Put indexes (or re-indexes) a document; values follow the order of the
+// fields given to NewIndex.
+func (ix *Index) Put(id uint, values ...string) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.remove(id)
+	ix.docs[id] = values
+	for fi, v := range values {
+		for _, tok := range Tokenize(v) {
+			docs := ix.postings[tok]
+			if docs == nil {
+				docs = make(map[uint]*posting)
+				ix.postings[tok] = docs
+			}
+			p := docs[id]
+			if p == nil {
+				p = &posting{tf: make([]int, len(ix.fields))}
+				docs[id] = p
+			}
+			p.tf[fi]++
+		}
+	}
+}
+
+func (ix *Index) Delete(id uint) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.remove(id)
+}
+
+func (ix *Index) remove(id uint) {
+	values, ok := ix.docs[id]
+	if !ok {
+		return
+	}
+	for _, v := range values {
+		for _, tok := range Tokenize(v) {
+			if docs := ix.postings[tok]; docs != nil {
+				delete(docs, id)
+				if len(docs) == 0 {
+					delete(ix.postings, tok)
+				}
+			}
+		}
+	}
+	delete(ix.docs, id)
+}
+
+// Search ranks documents against query. Each query term matches index terms
+// exactly, by prefix, or within a small edit distance, at decreasing weight.
+func (ix *Index) Search(query string, limit int) []Hit {
+	terms := Tokenize(query)
+	if len(terms) == 0 {
+		return nil
+	}
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	n := float64(len(ix.docs))
+	scores := make(map[uint]float64)
+	for _, qt := range terms {
+		// best keeps the strongest match of this query term per document so a
+		// term cannot score twice through several fuzzy variants.
+		best := make(map[uint]float64)
+		for term, docs := range ix.postings {
+			w := termWeight(qt, term)
+			if w == 0 {
+				continue
+			}
+			idf := math.Log(1 + n/float64(len(docs)))
+			for id, p := range docs {
+				s := 0.0
+				for fi, tf := range p.tf {
+					if tf > 0 {
+						s += ix.fields[fi].Weight * (1 + math.Log(float64(tf)))
+					}
+				}
+				if s *= w * idf; s > best[id] {
+					best[id] = s
+				}
+			}
+		}
+		for id, s := range best {
+			scores[id] += s
+		}
+	}
+
+	hits := make([]Hit, 0, len(scores))
+	for id, s := range scores {
+		hits = append(hits, Hit{ID: id, Score: s})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].ID < hits[j].ID
+	})
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+	for i := range hits {
+		hits[i].Highlights = make(map[string]string)
+		for fi, v := range ix.docs[hits[i].ID] {
+			if h, ok := Highlight(v, terms); ok {
+				hits[i].Highlights[ix.fields[fi].Name] = h
+			}
+		}
+	}
+	return hits
+}
+
+// Tokenize lower-cases s and splits it into letter/digit runs.
+func Tokenize(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// termWeight scores how well an index term satisfies a query term: 1 for an
+// exact match, 0.8 for a prefix, 0.5 within the allowed typo distance.
+func termWeight(query, term string) float64 {
+	switch {
+	case term == query:
+		return 1
+	case len(query) >= 3 && strings.HasPrefix(term, query):
+		return 0.8
+	}
+	max := maxTypos(query)
+	if max == 0 || abs(len(term)-len(query)) > max {
+		return 0
+	}
+	if editDistance(query, term, max) <= max {
+		return 0.5
+	}
+	return 0
+}
+
+// maxTypos allows no typos in short words, where a single edit too often
+// turns one real word into another.
+func maxTypos(term string) int {
+	switch n := len([]rune(term)); {
+	case n < 4:
+		return 0
+	case n < 8:
+		return 1
+	}
+	return 2
+}
+
+// editDistance is the optimal-string-alignment distance between a and b,
+// giving up once it exceeds max.
+func editDistance(a, b string, max int) int {
+	ra, rb := []rune(a), []rune(b)
+	prev2 := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		rowMin := cur[0]
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				cur[j] = min(cur[j], prev2[j-2]+1)
+			}
+			rowMin = min(rowMin, cur[j])
+		}
+		if rowMin > max {
+			return max + 1
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return prev[len(rb)]
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
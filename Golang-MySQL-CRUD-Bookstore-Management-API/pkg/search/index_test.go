@@ -0,0 +1,113 @@
+package search
+
+import (
+	"testing"
+)
+
+func newTestIndex() *Index {
+	ix := NewIndex(Field{Name: "name", Weight: 3}, Field{Name: "author", Weight: 2}, Field{Name: "publication", Weight: 1})
+	ix.Put(1, "Cooking at Home", "Ann Smith", "Garden Press")
+	ix.Put(2, "Garden Design", "Bob Jones", "Home Books")
+	ix.Put(3, "The Go Programming Language", "Alan Donovan", "Addison-Wesley")
+	return ix
+}
+
+func ids(hits []Hit) []uint {
+	out := make([]uint, len(hits))
+	for i, h := range hits {
+		out[i] = h.ID
+	}
+	return out
+}
+
+func equalIDs(a, b []uint) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestSearchRanking(t *testing.T) {
+	ix := newTestIndex()
+	tests := []struct {
+		query string
+		want  []uint
+	}{
+		// A name match outranks the same word in the publication.
+		{"garden", []uint{2, 1}},
+		{"home", []uint{1, 2}},
+		{"go", []uint{3}},
+		{"program", []uint{3}},
+		{"", nil},
+		{"nothing", nil},
+	}
+	for _, tt := range tests {
+		if got := ids(ix.Search(tt.query, 10)); !equalIDs(got, tt.want) {
+			t.Errorf("Search(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+
+	// An exact match outranks a prefix, which outranks a typo.
+	exact, prefix, typo := ix.Search("design", 1), ix.Search("desig", 1), ix.Search("desing", 1)
+	if len(exact) != 1 || len(prefix) != 1 || len(typo) != 1 {
+		t.Fatalf("design/desig/desing found %v/%v/%v", ids(exact), ids(prefix), ids(typo))
+	}
+	if !(exact[0].Score > prefix[0].Score && prefix[0].Score > typo[0].Score) {
+		t.Fatalf("scores exact %v, prefix %v, typo %v not decreasing", exact[0].Score, prefix[0].Score, typo[0].Score)
+	}
+
+	if got := ids(ix.Search("garden", 1)); !equalIDs(got, []uint{2}) {
+		t.Fatalf("limit 1 = %v, want [2]", got)
+	}
+}
+
+func TestSearchTypoTolerance(t *testing.T) {
+	ix := newTestIndex()
+	tests := []struct {
+		query string
+		want  []uint
+	}{
+		{"cookign", []uint{1}},    // transposition
+		{"gardne", []uint{2, 1}},  // transposition
+		{"programing", []uint{3}}, // one deletion in a long word
+		{"porgraming", []uint{3}}, // two edits allowed from eight letters
+		{"porgrmanig", nil},       // three are not
+		{"gi", nil},               // no typos below four letters
+		{"hime", []uint{1, 2}},    // one substitution from four letters
+		{"hoem", []uint{1, 2}},    // a transposition counts as one edit
+		{"hxxe", nil},             // two edits in a short word
+	}
+	for _, tt := range tests {
+		if got := ids(ix.Search(tt.query, 10)); !equalIDs(got, tt.want) {
+			t.Errorf("Search(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestSearchReindexAndDelete(t *testing.T) {
+	ix := newTestIndex()
+	ix.Put(2, "Rose Beds", "Bob Jones", "Home Books")
+	if got := ids(ix.Search("garden", 10)); !equalIDs(got, []uint{1}) {
+		t.Fatalf("after reindex = %v, want [1]", got)
+	}
+	ix.Delete(1)
+	if got := ids(ix.Search("garden", 10)); len(got) != 0 {
+		t.Fatalf("after delete = %v, want none", got)
+	}
+}
+
+func TestSearchHighlights(t *testing.T) {
+	hits := newTestIndex().Search("gardne", 10)
+	if len(hits) != 2 {
+		t.Fatalf("found %v, want 2 hits", ids(hits))
+	}
+	want := map[string]string{"name": "<em>Garden</em> Design"}
+	if got := hits[0].Highlights; len(got) != len(want) || got["name"] != want["name"] {
+		t.Fatalf("highlights = %v, want %v", got, want)
+	}
+}
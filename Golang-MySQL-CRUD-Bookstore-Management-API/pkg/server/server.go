@@ -0,0 +1,206 @@
+// Package server runs an HTTP handler with production timeouts, liveness and
+// readiness endpoints, and a graceful drain on SIGTERM or SIGINT.
+package server
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/yoloxsta/go-bookstore/pkg/config"
+	"github.com/yoloxsta/go-bookstore/pkg/utils"
+)
+
+// Paths of the health endpoints. They are served ahead of the wrapped
+// handler, so its middleware such as authentication never sees them.
+const (
+	LivePath  = "/healthz"
+	ReadyPath = "/readyz"
+)
+
+// A Check reports whether a dependency such as the database can serve
+// traffic.
+type Check func(ctx context.Context) error
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// Server wraps an http.Server. It is not ready until Run has bound its
+// listener, and stops being ready as soon as shutdown begins.
+type Server struct {
+	cfg  config.ServerConfig
+	http *http.Server
+	mux  *http.ServeMux
+
+	ready atomic.Bool
+
+	mu       sync.Mutex
+	checks   []namedCheck
+	closers  []func() error
+	shutdown []func(ctx context.Context)
+}
+
+// New returns a Server for h configured by cfg.
+func New(cfg config.ServerConfig, h http.Handler) *Server {
+	s := &Server{cfg: cfg, mux: http.NewServeMux()}
+	s.mux.HandleFunc(LivePath, s.live)
+	s.mux.HandleFunc(ReadyPath, s.readyz)
+	s.mux.Handle("/", h)
+	s.http = &http.Server{
+		Addr:         cfg.Addr,
+		Handler:      s.mux,
+		ReadTimeout:  cfg.ReadTimeout,
+		WriteTimeout: cfg.WriteTimeout,
+		IdleTimeout:  cfg.IdleTimeout,
+	}
+	return s
+}
+
+// Handle serves h at pattern ahead of the wrapped handler, like the health
+// endpoints. It must be called before Run.
+func (s *Server) Handle(pattern string, h http.Handler) {
+	s.mux.Handle(pattern, h)
+}
+
+// AddCheck makes readiness depend on check, reported under name.
+func (s *Server) AddCheck(name string, check Check) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.checks = append(s.checks, namedCheck{name, check})
+}
+
+// OnShutdown registers f to run once in-flight requests have drained, or
+// the shutdown timeout has passed, with a context bounded by that timeout.
+// Use it to stop servers that share this process.
+func (s *Server) OnShutdown(f func(ctx context.Context)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.shutdown = append(s.shutdown, f)
+}
+
+// OnClose registers a resource, such as the database, to close after
+// shutdown. Closers run last-registered first.
+func (s *Server) OnClose(close func() error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closers = append(s.closers, close)
+}
+
+// Run serves until ctx is cancelled or the process receives SIGTERM or
+// SIGINT, then drains: readiness fails at once, the listener stays open for
+// DrainDelay, and in-flight requests get ShutdownTimeout to finish before
+// the registered closers run. It returns every error from serving,
+// shutting down and closing.
+func (s *Server) Run(ctx context.Context) error {
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGTERM, os.Interrupt)
+	defer stop()
+
+	ln, err := net.Listen("tcp", s.cfg.Addr)
+	if err != nil {
+		s.close()
+		return err
+	}
+	return s.serve(ctx, ln)
+}
+
+func (s *Server) serve(ctx context.Context, ln net.Listener) error {
+	served := make(chan error, 1)
+	go func() { served <- s.http.Serve(ln) }()
+	s.ready.Store(true)
+	slog.Info("server: listening", "addr", ln.Addr().String())
+
+	var errs []error
+	select {
+	case err := <-served:
+		s.ready.Store(false)
+		errs = append(errs, err)
+	case <-ctx.Done():
+		s.ready.Store(false)
+		slog.Info("server: shutting down", "drain_delay", s.cfg.DrainDelay)
+		time.Sleep(s.cfg.DrainDelay)
+		errs = append(errs, s.drain())
+	}
+	errs = append(errs, s.close())
+	return errors.Join(errs...)
+}
+
+func (s *Server) drain() error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.cfg.ShutdownTimeout)
+	defer cancel()
+	err := s.http.Shutdown(ctx)
+	if errors.Is(err, context.DeadlineExceeded) {
+		slog.Warn("server: requests still running; closing them", "shutdown_timeout", s.cfg.ShutdownTimeout)
+		err = s.http.Close()
+	}
+	s.mu.Lock()
+	shutdown := s.shutdown
+	s.mu.Unlock()
+	var wg sync.WaitGroup
+	for _, f := range shutdown {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			f(ctx)
+		}()
+	}
+	wg.Wait()
+	return err
+}
+
+func (s *Server) close() error {
+	s.mu.Lock()
+	closers := s.closers
+	s.mu.Unlock()
+	var errs []error
+	for i := len(closers) - 1; i >= 0; i-- {
+		errs = append(errs, closers[i]())
+	}
+	return errors.Join(errs...)
+}
+
+// live reports that the process is up and serving. It stays 200 during a
+// drain so orchestrators do not restart a server that is shutting down.
+func (s *Server) live(w http.ResponseWriter, r *http.Request) {
+	utils.RespondJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+type readiness struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// readyz reports whether the server should receive traffic: it has started,
+// is not draining, and every check passes.
+func (s *Server) readyz(w http.ResponseWriter, r *http.Request) {
+	if !s.ready.Load() {
+		utils.RespondJSON(w, http.StatusServiceUnavailable, readiness{Status: "unavailable"})
+		return
+	}
+	s.mu.Lock()
+	checks := s.checks
+	s.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
+	defer cancel()
+	res, status := readiness{Status: "ok"}, http.StatusOK
+	for _, c := range checks {
+		if err := c.check(ctx); err != nil {
+			if res.Checks == nil {
+				res.Checks = map[string]string{}
+			}
+			res.Checks[c.name] = err.Error()
+			res.Status, status = "unavailable", http.StatusServiceUnavailable
+		}
+	}
+	utils.RespondJSON(w, status, res)
+}
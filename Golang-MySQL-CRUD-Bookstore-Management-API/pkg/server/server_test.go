@@ -0,0 +1,158 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/yoloxsta/go-bookstore/pkg/config"
+)
+
+// start serves s on a free local port and returns its base URL and the
+// result of serve.
+func start(t *testing.T, ctx context.Context, s *Server) (string, <-chan error) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- s.serve(ctx, ln) }()
+	return "http://" + ln.Addr().String(), done
+}
+
+func status(t *testing.T, url string) int {
+	t.Helper()
+	res, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.Copy(io.Discard, res.Body)
+	res.Body.Close()
+	return res.StatusCode
+}
+
+// waitStatus polls url until it answers want or a second has passed.
+func waitStatus(t *testing.T, url string, want int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		got := status(t, url)
+		if got == want {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("GET %s = %d, want %d", url, got, want)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestShutdownDrainsInFlightRequests(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	var finished atomic.Bool
+	slow := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		io.WriteString(w, "done")
+		finished.Store(true)
+	})
+	s := New(config.ServerConfig{DrainDelay: 200 * time.Millisecond, ShutdownTimeout: 5 * time.Second}, slow)
+	var closedAfterDrain atomic.Bool
+	s.OnClose(func() error {
+		closedAfterDrain.Store(finished.Load())
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	base, done := start(t, ctx, s)
+	waitStatus(t, base+ReadyPath, http.StatusOK)
+
+	type result struct {
+		body string
+		err  error
+	}
+	slowDone := make(chan result, 1)
+	go func() {
+		res, err := http.Get(base + "/slow")
+		if err != nil {
+			slowDone <- result{err: err}
+			return
+		}
+		defer res.Body.Close()
+		body, err := io.ReadAll(res.Body)
+		slowDone <- result{string(body), err}
+	}()
+	<-started
+
+	cancel()
+	// The listener stays open for the drain delay, reporting not ready while
+	// still alive.
+	waitStatus(t, base+ReadyPath, http.StatusServiceUnavailable)
+	if got := status(t, base+LivePath); got != http.StatusOK {
+		t.Errorf("liveness during drain = %d, want 200", got)
+	}
+
+	select {
+	case err := <-done:
+		t.Fatalf("serve returned %v with a request in flight", err)
+	case <-time.After(3 * s.cfg.DrainDelay):
+	}
+	close(release)
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("serve: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("serve did not return after the request finished")
+	}
+	if !finished.Load() || !closedAfterDrain.Load() {
+		t.Fatal("serve returned, or closed its resources, before the in-flight request finished")
+	}
+	if r := <-slowDone; r.err != nil || r.body != "done" {
+		t.Fatalf("in-flight request got %q, %v", r.body, r.err)
+	}
+}
+
+func TestReadinessChecks(t *testing.T) {
+	s := New(config.ServerConfig{}, http.NotFoundHandler())
+	var failing atomic.Bool
+	s.AddCheck("database", func(ctx context.Context) error {
+		if failing.Load() {
+			return errors.New("connection refused")
+		}
+		return nil
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	base, done := start(t, ctx, s)
+	waitStatus(t, base+ReadyPath, http.StatusOK)
+
+	failing.Store(true)
+	res, err := http.Get(base + ReadyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	var got readiness
+	if err := json.NewDecoder(res.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != http.StatusServiceUnavailable || got.Status != "unavailable" || got.Checks["database"] != "connection refused" {
+		t.Fatalf("readiness = %d %+v, want 503 naming the database", res.StatusCode, got)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("serve: %v", err)
+	}
+}
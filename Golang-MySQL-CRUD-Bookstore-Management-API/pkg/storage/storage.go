@@ -0,0 +1,54 @@
+// Package storage keeps binary blobs, such as cover images, outside the
+// database behind a small Storage interface.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/yoloxsta/go-bookstore/pkg/config"
+)
+
+var (
+	ErrNotFound = errors.New("storage: blob not found")
+	// ErrInvalidKey rejects keys that could escape the storage root.
+	ErrInvalidKey = errors.New("storage: invalid key")
+)
+
+// Storage is implemented once per backend. Keys are slash-separated paths
+// such as "covers/12/original".
+type Storage interface {
+	// Put writes data under key, replacing any previous blob.
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	// Get opens the blob under key; the caller closes it.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the blob under key. Deleting a missing blob is not an
+	// error.
+	Delete(ctx context.Context, key string) error
+}
+
+// New returns the backend selected by cfg.Driver.
+func New(cfg config.StorageConfig) (Storage, error) {
+	switch cfg.Driver {
+	case config.StorageLocal:
+		return NewLocal(cfg.Dir), nil
+	case config.StorageS3:
+		return NewS3(cfg.S3)
+	}
+	return nil, fmt.Errorf("storage: unknown driver %q", cfg.Driver)
+}
+
+func checkKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return ErrInvalidKey
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return ErrInvalidKey
+		}
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/yoloxsta/go-bookstore/pkg/middleware"
+	"github.com/yoloxsta/go-bookstore/pkg/validation"
+)
+
+// Error codes used in the "code" field of every error response.
+const (
+	CodeBadRequest         = "bad_request"
+	CodeUnauthorized       = "unauthorized"
+	CodeForbidden          = "forbidden"
+	CodeNotFound           = "not_found"
+	CodeMethod             = "method_not_allowed"
+	CodeConflict           = "conflict"
+	CodePreconditionFailed = "precondition_failed"
+	CodeTooLarge           = "body_too_large"
+	CodeValidation         = "validation_failed"
+	CodeUnsupportedMedia   = "unsupported_media_type"
+	CodePatchFailed        = "patch_failed"
+	CodeInsufficientStock  = "insufficient_stock"
+	CodePaymentDeclined    = "payment_declined"
+	CodeInternal           = "internal_error"
+)
+
+type ErrorBody struct {
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	RequestID string `json:"request_id,omitempty"`
+
+	Fields validation.Errors `json:"fields,omitempty"`
+}
+
+// ErrorResponse is the envelope shared by every handler's error responses.
+type ErrorResponse struct {
+	Error ErrorBody `json:"error"`
+}
+
+func RespondJSON(w http.ResponseWriter, status int, v interface{}) {
+	res, err := json.Marshal(v)
+	if err != nil {
+		slog.Error("encoding response", "err", err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"error":{"code":"` + CodeInternal + `","message":"internal server error"}}`))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(res)
+}
+
+func RespondError(w http.ResponseWriter, r *http.Request, status int, code, message string) {
+	RespondJSON(w, status, ErrorResponse{Error: ErrorBody{
+		Code:      code,
+		Message:   message,
+		RequestID: middleware.GetRequestID(r.Context()),
+	}})
+}
+
+func RespondValidationError(w http.ResponseWriter, r *http.Request, errs validation.Errors) {
+	RespondJSON(w, http.StatusUnprocessableEntity, ErrorResponse{Error: ErrorBody{
+		Code:      CodeValidation,
+		Message:   "request body failed validation",
+		RequestID: middleware.GetRequestID(r.Context()),
+		Fields:    errs,
+	}})
+}
+
+// RespondInternalError logs err with the request ID and hides it from the client.
+func RespondInternalError(w http.ResponseWriter, r *http.Request, err error) {
+	slog.ErrorContext(r.Context(), "internal error", "err", err)
+	RespondError(w, r, http.StatusInternalServerError, CodeInternal, "internal server error")
+}
+
+// NotFoundHandler and MethodNotAllowedHandler give unmatched routes the same
+// error envelope as the handlers.
+var NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	RespondError(w, r, http.StatusNotFound, CodeNotFound, "no route for "+r.URL.Path)
+})
+
+var MethodNotAllowedHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	RespondError(w, r, http.StatusMethodNotAllowed, CodeMethod, r.Method+" is not allowed on "+r.URL.Path)
+})
@@ -1,15 +1,86 @@
 package utils
 
 import (
+	"bytes"
 	"encoding/json"
-	"io/ioutil"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"strings"
+
+	"github.com/yoloxsta/go-bookstore/pkg/validation"
 )
 
-func ParseBody(r *http.Request, x interface{}) {
-	if body, err := ioutil.ReadAll(r.Body); err == nil {
-		if err := json.Unmarshal([]byte(body), x); err != nil {
-			return
-		}
+// MaxBodyBytes caps request bodies read by ParseBody.
+const MaxBodyBytes = 1 << 20
+
+var ErrBodyTooLarge = fmt.Errorf("request body exceeds %d bytes", MaxBodyBytes)
+
+// ParseBody decodes a single JSON value into x. Unknown fields and type
+// mismatches come back as validation.Errors so they can be reported per field.
+func ParseBody(r *http.Request, x interface{}) error {
+	return decodeStrict(http.MaxBytesReader(nil, r.Body, MaxBodyBytes), x)
+}
+
+// ReadBody returns the raw request body, enforcing MaxBodyBytes.
+func ReadBody(r *http.Request) ([]byte, error) {
+	data, err := io.ReadAll(http.MaxBytesReader(nil, r.Body, MaxBodyBytes))
+	if err != nil {
+		return nil, decodeError(err)
+	}
+	return data, nil
+}
+
+// DecodeJSON applies the same strict rules as ParseBody to an in-memory
+// document, such as the result of applying a patch.
+func DecodeJSON(data []byte, x interface{}) error {
+	return decodeStrict(bytes.NewReader(data), x)
+}
+
+func decodeStrict(r io.Reader, x interface{}) error {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(x); err != nil {
+		return decodeError(err)
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return errors.New("request body must contain a single JSON value")
+	}
+	return nil
+}
+
+func decodeError(err error) error {
+	var (
+		maxErr  *http.MaxBytesError
+		typeErr *json.UnmarshalTypeError
+		errs    validation.Errors
+	)
+	switch {
+	case errors.As(err, &maxErr):
+		return ErrBodyTooLarge
+	case errors.As(err, &typeErr):
+		errs.Add(typeErr.Field, "must be a %s", typeErr.Type.String())
+		return errs
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		errs.Add(field, "is not a recognised field")
+		return errs
+	case errors.Is(err, io.EOF):
+		return errors.New("request body is empty")
+	}
+	return err
+}
+
+// RespondBodyError maps errors from ParseBody and Validate to 413, 422 or 400.
+func RespondBodyError(w http.ResponseWriter, r *http.Request, err error) {
+	var errs validation.Errors
+	switch {
+	case errors.Is(err, ErrBodyTooLarge):
+		RespondError(w, r, http.StatusRequestEntityTooLarge, CodeTooLarge, err.Error())
+	case errors.As(err, &errs):
+		RespondValidationError(w, r, errs)
+	default:
+		RespondError(w, r, http.StatusBadRequest, CodeBadRequest, "invalid request body: "+err.Error())
 	}
 }
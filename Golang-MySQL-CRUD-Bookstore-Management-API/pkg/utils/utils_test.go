@@ -0,0 +1,94 @@
+package utils
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/yoloxsta/go-bookstore/pkg/middleware"
+	"github.com/yoloxsta/go-bookstore/pkg/validation"
+)
+
+type testBody struct {
+	Name  string `json:"name"`
+	Price int64  `json:"price_cents"`
+}
+
+// respondTo parses body as ParseBody does and writes the resulting error the
+// way the controllers do.
+func respondTo(t *testing.T, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/book/", strings.NewReader(body))
+	req = req.WithContext(middleware.WithRequestID(req.Context(), "req-7"))
+	rec := httptest.NewRecorder()
+	var v testBody
+	if err := ParseBody(req, &v); err != nil {
+		RespondBodyError(rec, req, err)
+	} else {
+		RespondJSON(rec, http.StatusOK, v)
+	}
+	return rec
+}
+
+func TestRespondBodyError(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		code   string
+		fields validation.Errors
+	}{
+		{"valid", `{"name":"Dune","price_cents":100}`, http.StatusOK, "", nil},
+		{"unknown field", `{"name":"Dune","colour":"red"}`, http.StatusUnprocessableEntity, CodeValidation,
+			validation.Errors{{Field: "colour", Message: "is not a recognised field"}}},
+		{"wrong type", `{"price_cents":"cheap"}`, http.StatusUnprocessableEntity, CodeValidation,
+			validation.Errors{{Field: "price_cents", Message: "must be a int64"}}},
+		{"empty", ``, http.StatusBadRequest, CodeBadRequest, nil},
+		{"malformed", `{"name":`, http.StatusBadRequest, CodeBadRequest, nil},
+		{"two values", `{} {}`, http.StatusBadRequest, CodeBadRequest, nil},
+		{"too large", `{"name":"` + strings.Repeat("a", MaxBodyBytes) + `"}`, http.StatusRequestEntityTooLarge, CodeTooLarge, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := respondTo(t, tt.body)
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d; body %.200s", rec.Code, tt.status, rec.Body)
+			}
+			if tt.code == "" {
+				return
+			}
+			var res ErrorResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil {
+				t.Fatal(err)
+			}
+			if res.Error.Code != tt.code || res.Error.RequestID != "req-7" || res.Error.Message == "" {
+				t.Errorf("error = %+v, want code %s with request_id req-7", res.Error, tt.code)
+			}
+			if !reflect.DeepEqual(res.Error.Fields, tt.fields) {
+				t.Errorf("fields = %+v, want %+v", res.Error.Fields, tt.fields)
+			}
+		})
+	}
+}
+
+func TestRespondValidationErrorShape(t *testing.T) {
+	var errs validation.Errors
+	errs.Required("name", "")
+	errs.MaxLength("author", "abcd", 3)
+	req := httptest.NewRequest(http.MethodPost, "/book/", nil)
+	req = req.WithContext(middleware.WithRequestID(req.Context(), "req-7"))
+	rec := httptest.NewRecorder()
+	RespondBodyError(rec, req, errs.Err())
+
+	if rec.Code != http.StatusUnprocessableEntity || rec.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("status = %d, Content-Type %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	want := `{"error":{"code":"validation_failed","message":"request body failed validation","request_id":"req-7",` +
+		`"fields":[{"field":"name","message":"is required"},{"field":"author","message":"must be at most 3 characters, got 4"}]}}`
+	if got := rec.Body.String(); got != want {
+		t.Fatalf("body = %s\nwant %s", got, want)
+	}
+}
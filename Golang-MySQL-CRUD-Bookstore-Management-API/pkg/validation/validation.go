@@ -0,0 +1,62 @@
+package validation
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// FieldError describes one problem with one field of a request body.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Errors collects field errors so a client sees every problem in one response.
+// Any resource can build one through the helper methods and return Err().
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	parts := make([]string, len(e))
+	for i, fe := range e {
+		parts[i] = fe.Field + ": " + fe.Message
+	}
+	return "validation failed: " + strings.Join(parts, "; ")
+}
+
+// Err returns nil when nothing was recorded, so callers can `return errs.Err()`.
+func (e Errors) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+func (e *Errors) Add(field, format string, args ...interface{}) {
+	*e = append(*e, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+func (e *Errors) Required(field, value string) bool {
+	if strings.TrimSpace(value) == "" {
+		e.Add(field, "is required")
+		return false
+	}
+	return true
+}
+
+func (e *Errors) MaxLength(field, value string, max int) bool {
+	if n := utf8.RuneCountInString(value); n > max {
+		e.Add(field, "must be at most %d characters, got %d", max, n)
+		return false
+	}
+	return true
+}
+
+func (e *Errors) Match(field, value string, re *regexp.Regexp, description string) bool {
+	if value != "" && !re.MatchString(value) {
+		e.Add(field, "must be %s", description)
+		return false
+	}
+	return true
+}
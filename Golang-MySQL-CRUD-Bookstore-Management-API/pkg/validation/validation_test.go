@@ -0,0 +1,107 @@
+package validation
+
+import (
+	"encoding/json"
+	"regexp"
+	"testing"
+)
+
+func TestRequired(t *testing.T) {
+	tests := []struct {
+		value string
+		ok    bool
+	}{
+		{"Dune", true},
+		{" x ", true},
+		{"", false},
+		{"   ", false},
+		{"\t\n", false},
+	}
+	for _, tt := range tests {
+		var errs Errors
+		if ok := errs.Required("name", tt.value); ok != tt.ok || (len(errs) == 0) != tt.ok {
+			t.Errorf("Required(%q) = %v with %v, want %v", tt.value, ok, errs, tt.ok)
+		}
+		if !tt.ok && (errs[0] != FieldError{Field: "name", Message: "is required"}) {
+			t.Errorf("Required(%q) recorded %+v", tt.value, errs[0])
+		}
+	}
+}
+
+func TestMaxLength(t *testing.T) {
+	tests := []struct {
+		value   string
+		ok      bool
+		message string
+	}{
+		{"", true, ""},
+		{"abcde", true, ""},
+		{"abcdef", false, "must be at most 5 characters, got 6"},
+		// Characters, not bytes, are counted.
+		{"éééé€", true, ""},
+		{"日本語の本です", false, "must be at most 5 characters, got 7"},
+	}
+	for _, tt := range tests {
+		var errs Errors
+		ok := errs.MaxLength("name", tt.value, 5)
+		if ok != tt.ok || (len(errs) == 0) != tt.ok {
+			t.Errorf("MaxLength(%q) = %v with %v, want %v", tt.value, ok, errs, tt.ok)
+			continue
+		}
+		if !tt.ok && errs[0].Message != tt.message {
+			t.Errorf("MaxLength(%q) message = %q, want %q", tt.value, errs[0].Message, tt.message)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	sku := regexp.MustCompile(`^[A-Z0-9-]+$`)
+	tests := []struct {
+		value string
+		ok    bool
+	}{
+		{"DUNE-1", true},
+		// Empty values are left to Required.
+		{"", true},
+		{"dune 1", false},
+	}
+	for _, tt := range tests {
+		var errs Errors
+		if ok := errs.Match("sku", tt.value, sku, "upper-case letters, digits or '-'"); ok != tt.ok || (len(errs) == 0) != tt.ok {
+			t.Errorf("Match(%q) = %v with %v, want %v", tt.value, ok, errs, tt.ok)
+		}
+		if !tt.ok && errs[0].Message != "must be upper-case letters, digits or '-'" {
+			t.Errorf("Match(%q) message = %q", tt.value, errs[0].Message)
+		}
+	}
+}
+
+func TestErrorsCollectEveryProblem(t *testing.T) {
+	var errs Errors
+	if errs.Err() != nil {
+		t.Fatal("Err of no errors is not nil")
+	}
+	errs.Required("name", "")
+	errs.MaxLength("author", "too long", 3)
+	errs.Add("author_ids", "lists author %d twice", 7)
+
+	err := errs.Err()
+	if err == nil {
+		t.Fatal("Err is nil")
+	}
+	want := "validation failed: name: is required; author: must be at most 3 characters, got 8; author_ids: lists author 7 twice"
+	if err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+
+	data, err := json.Marshal(errs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantJSON := `[{"field":"name","message":"is required"},` +
+		`{"field":"author","message":"must be at most 3 characters, got 8"},` +
+		`{"field":"author_ids","message":"lists author 7 twice"}]`
+	if string(data) != wantJSON {
+		t.Errorf("JSON = %s, want %s", data, wantJSON)
+	}
+}